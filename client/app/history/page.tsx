@@ -3,6 +3,7 @@
 import { useState, useEffect } from "react"
 import { useRouter, useSearchParams } from "next/navigation"
 import { Button } from "@/components/ui/button"
+import { deviceId } from "@/lib/device"
 import { Card } from "@/components/ui/card"
 import {
   ArrowLeftIcon,
@@ -124,6 +125,7 @@ export default function HistoryPage() {
                   headers: {
                     'Content-Type': 'application/json',
                     'Accept': 'application/json',
+                    'X-Device-ID': deviceId(),
                   },
                   body: JSON.stringify({
                     name: username
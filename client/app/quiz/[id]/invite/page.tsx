@@ -3,6 +3,7 @@
 import { useState, useEffect } from "react"
 import { useParams, useSearchParams, useRouter } from "next/navigation"
 import { Button } from "@/components/ui/button"
+import { deviceId } from "@/lib/device"
 import { Card } from "@/components/ui/card"
 import { GlobeIcon, TrophyIcon } from "lucide-react"
 
@@ -63,7 +64,13 @@ export default function InvitePage() {
             });
 
             if (!registerResponse.ok) {
-                throw new Error('Username already taken');
+                const body = await registerResponse.json().catch(() => ({}));
+                if (registerResponse.status === 409 && body.suggestions?.length) {
+                    setError(`Username already taken. Try ${body.suggestions.join(", ")}.`);
+                    return;
+                }
+                setError(body.error ?? "Username already taken. Please choose another one.");
+                return;
             }
 
             // Create a new quiz for the invited user
@@ -72,9 +79,10 @@ export default function InvitePage() {
                 headers: {
                     'Content-Type': 'application/json',
                     'Accept': 'application/json',
+                    'X-Device-ID': deviceId(),
                 },
                 body: JSON.stringify({
-                    username: username.trim()
+                    name: username.trim()
                 }),
             });
 
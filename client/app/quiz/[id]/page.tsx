@@ -3,6 +3,7 @@
 import { useState, useEffect } from "react"
 import { useSearchParams, useRouter, useParams } from "next/navigation"
 import { Button } from "@/components/ui/button"
+import { deviceId } from "@/lib/device"
 import { Card } from "@/components/ui/card"
 import {
     MapPinIcon,
@@ -26,6 +27,7 @@ interface Destination {
     fun_fact: string[]
     trivia: string[]
     options: string[]
+    token?: string
     created_at: string
     updated_at: string
 }
@@ -128,7 +130,8 @@ export default function QuizPage() {
                     answer: option,
                     user_name: username,
                     quiz_id: quizId,
-                    question_id: destination.id
+                    question_id: destination.id,
+                    token: destination.token
                 })
             });
 
@@ -171,9 +174,10 @@ export default function QuizPage() {
                 headers: {
                     'Content-Type': 'application/json',
                     'Accept': 'application/json',
+                    'X-Device-ID': deviceId(),
                 },
                 body: JSON.stringify({
-                    username: username
+                    name: username
                 }),
             });
 
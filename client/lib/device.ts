@@ -0,0 +1,12 @@
+const storageKey = "globetrotter-device-id"
+
+// deviceId returns an id for this browser, kept in localStorage so that it
+// survives reloads. The server uses it to spot accounts sharing a device.
+export function deviceId(): string {
+  let id = localStorage.getItem(storageKey)
+  if (!id) {
+    id = crypto.randomUUID()
+    localStorage.setItem(storageKey, id)
+  }
+  return id
+}
@@ -0,0 +1,106 @@
+// Package achievements declares the badges players can earn and decides which
+// of them a player's history qualifies for.
+package achievements
+
+// Trigger says when rules are checked.
+type Trigger int
+
+const (
+	// OnAnswer rules are checked after every answer.
+	OnAnswer Trigger = iota
+	// OnQuizCompleted rules are checked when a quiz runs out of questions.
+	OnQuizCompleted
+	// OnBackfill checks every rule, to award badges for past history.
+	OnBackfill
+)
+
+// Facts summarizes a player's whole history. Rules only look at facts, so the
+// same rule works live and when backfilling.
+type Facts struct {
+	CorrectAnswers    int
+	BestStreak        int
+	ContinentsCorrect int
+	TotalContinents   int
+	PerfectQuizzes    int
+	ChallengesWon     int
+}
+
+type Rule struct {
+	Id          string
+	Name        string
+	Description string
+	Trigger     Trigger
+	Earned      func(Facts) bool
+}
+
+// MinPerfectQuizLength is the fewest questions a quiz needs for a perfect
+// score to count.
+const MinPerfectQuizLength = 5
+
+// Rules are all the achievements, in display order. Ids are stored with
+// earned badges and must not change.
+var Rules = []Rule{
+	{
+		Id:          "first_correct",
+		Name:        "First Stamp",
+		Description: "Answer a question correctly",
+		Trigger:     OnAnswer,
+		Earned:      func(f Facts) bool { return f.CorrectAnswers >= 1 },
+	},
+	{
+		Id:          "streak_10",
+		Name:        "On a Roll",
+		Description: "Answer 10 questions correctly in a row",
+		Trigger:     OnAnswer,
+		Earned:      func(f Facts) bool { return f.BestStreak >= 10 },
+	},
+	{
+		Id:          "every_continent",
+		Name:        "Globetrotter",
+		Description: "Correctly answer a city on every continent",
+		Trigger:     OnAnswer,
+		Earned: func(f Facts) bool {
+			return f.TotalContinents > 0 && f.ContinentsCorrect >= f.TotalContinents
+		},
+	},
+	{
+		Id:          "perfect_quiz",
+		Name:        "Flawless Journey",
+		Description: "Finish a quiz of at least 5 questions without a wrong answer",
+		Trigger:     OnQuizCompleted,
+		Earned:      func(f Facts) bool { return f.PerfectQuizzes >= 1 },
+	},
+	{
+		Id:          "challenge_won",
+		Name:        "Challenge Accepted",
+		Description: "Beat a friend's score on their challenge",
+		Trigger:     OnQuizCompleted,
+		Earned:      func(f Facts) bool { return f.ChallengesWon >= 1 },
+	},
+}
+
+// Lookup returns the rule with the given id.
+func Lookup(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Id == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Evaluate returns the rules checked at trigger that facts satisfy and that
+// are not already in earned. Completing a quiz also re-checks answer rules,
+// since the last answer of a quiz goes through both.
+func Evaluate(trigger Trigger, facts Facts, earned map[string]bool) []Rule {
+	var newlyEarned []Rule
+	for _, rule := range Rules {
+		if earned[rule.Id] || rule.Trigger > trigger {
+			continue
+		}
+		if rule.Earned(facts) {
+			newlyEarned = append(newlyEarned, rule)
+		}
+	}
+	return newlyEarned
+}
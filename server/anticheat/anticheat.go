@@ -0,0 +1,162 @@
+// Package anticheat holds the rules that decide whether a quiz looks played
+// by a bot or a cheat: answers faster than anyone reads, implausible
+// streaks, brute-forced options, accounts sharing an address or device, and
+// answer sequences copied from other accounts. The rules only look at a
+// History, so they can be run against recorded quizzes.
+package anticheat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule names, as stored with a flag.
+const (
+	RuleFastAnswers      = "fast_answers"
+	RuleCorrectStreak    = "correct_streak"
+	RuleRepeatedAnswers  = "repeated_answers"
+	RuleUnissuedAnswers  = "unissued_answers"
+	RuleSharedIP         = "shared_ip"
+	RuleSharedDevice     = "shared_device"
+	RuleIdenticalAnswers = "identical_answers"
+)
+
+// Answer is one graded answer, in the order it was given.
+type Answer struct {
+	QuestionId uuid.UUID
+	Correct    bool
+	// ResponseTime runs from when the question was issued; nil if it never
+	// was, which the official client never does.
+	ResponseTime *time.Duration
+}
+
+// History is what the rules know about one quiz.
+type History struct {
+	Answers []Answer
+	// AccountsOnIP and AccountsOnDevice count the other accounts that
+	// started quizzes from this quiz's IP address or device recently.
+	AccountsOnIP     int
+	AccountsOnDevice int
+	// IdenticalQuizzes counts other accounts' quizzes that gave the same
+	// answers to the same questions in the same order.
+	IdenticalQuizzes int
+}
+
+// Rules are the thresholds a quiz may reach before it is flagged. A zero
+// threshold turns its rule off, except for MaxRepeatedAnswers and
+// MaxUnissuedAnswers, where any answer over the limit counts.
+type Rules struct {
+	// FastAnswerTime is the quickest a person can read a question and
+	// answer it; MaxFastAnswers correct answers quicker than that are
+	// allowed.
+	FastAnswerTime time.Duration
+	MaxFastAnswers int
+	// MaxCorrectStreak is the longest run of correct answers allowed.
+	MaxCorrectStreak int
+	// MaxRepeatedAnswers is how many extra answers a quiz may send for
+	// questions it has already answered, which is how options are
+	// brute-forced.
+	MaxRepeatedAnswers int
+	// MaxUnissuedAnswers is how many answers may be for questions the
+	// quiz was never given.
+	MaxUnissuedAnswers int
+	// MaxAccountsPerIP and MaxAccountsPerDevice are how many other
+	// accounts may share the quiz's address or device.
+	MaxAccountsPerIP     int
+	MaxAccountsPerDevice int
+	// MaxIdenticalQuizzes is how many other accounts' quizzes may match
+	// this one answer for answer, once it has MinSequenceLength answers.
+	MaxIdenticalQuizzes int
+	MinSequenceLength   int
+}
+
+// Flag is one rule a quiz broke.
+type Flag struct {
+	Rule   string
+	Detail string
+}
+
+// Check returns every rule h breaks; none means the quiz looks fair.
+func (r Rules) Check(h History) []Flag {
+	var flags []Flag
+	flag := func(rule, format string, args ...any) {
+		flags = append(flags, Flag{Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if r.FastAnswerTime > 0 {
+		if fast := countFastAnswers(h.Answers, r.FastAnswerTime); fast > r.MaxFastAnswers {
+			flag(RuleFastAnswers, "%d correct answers in under %s", fast, r.FastAnswerTime)
+		}
+	}
+	if r.MaxCorrectStreak > 0 {
+		if streak := longestCorrectStreak(h.Answers); streak > r.MaxCorrectStreak {
+			flag(RuleCorrectStreak, "%d correct answers in a row", streak)
+		}
+	}
+	if repeated := countRepeatedAnswers(h.Answers); repeated > r.MaxRepeatedAnswers {
+		flag(RuleRepeatedAnswers, "%d answers to questions already answered", repeated)
+	}
+	if unissued := countUnissuedAnswers(h.Answers); unissued > r.MaxUnissuedAnswers {
+		flag(RuleUnissuedAnswers, "%d answers to questions never asked", unissued)
+	}
+	if r.MaxAccountsPerIP > 0 && h.AccountsOnIP > r.MaxAccountsPerIP {
+		flag(RuleSharedIP, "%d other accounts played from the same address", h.AccountsOnIP)
+	}
+	if r.MaxAccountsPerDevice > 0 && h.AccountsOnDevice > r.MaxAccountsPerDevice {
+		flag(RuleSharedDevice, "%d other accounts played from the same device", h.AccountsOnDevice)
+	}
+	if r.MinSequenceLength > 0 && len(h.Answers) >= r.MinSequenceLength && h.IdenticalQuizzes > r.MaxIdenticalQuizzes {
+		flag(RuleIdenticalAnswers, "%d quizzes by other accounts gave the same answers in the same order", h.IdenticalQuizzes)
+	}
+
+	return flags
+}
+
+func countFastAnswers(answers []Answer, limit time.Duration) int {
+	fast := 0
+	for _, answer := range answers {
+		if answer.Correct && answer.ResponseTime != nil && *answer.ResponseTime < limit {
+			fast++
+		}
+	}
+	return fast
+}
+
+func longestCorrectStreak(answers []Answer) int {
+	longest, current := 0, 0
+	for _, answer := range answers {
+		if !answer.Correct {
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+func countRepeatedAnswers(answers []Answer) int {
+	seen := make(map[uuid.UUID]bool, len(answers))
+	repeated := 0
+	for _, answer := range answers {
+		if seen[answer.QuestionId] {
+			repeated++
+		}
+		seen[answer.QuestionId] = true
+	}
+	return repeated
+}
+
+func countUnissuedAnswers(answers []Answer) int {
+	unissued := 0
+	for _, answer := range answers {
+		if answer.ResponseTime == nil {
+			unissued++
+		}
+	}
+	return unissued
+}
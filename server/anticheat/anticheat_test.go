@@ -0,0 +1,233 @@
+package anticheat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testRules mirror the server's defaults, with every rule switched on.
+var testRules = Rules{
+	FastAnswerTime:       800 * time.Millisecond,
+	MaxFastAnswers:       3,
+	MaxCorrectStreak:     40,
+	MaxRepeatedAnswers:   0,
+	MaxUnissuedAnswers:   0,
+	MaxAccountsPerIP:     5,
+	MaxAccountsPerDevice: 2,
+	MaxIdenticalQuizzes:  0,
+	MinSequenceLength:    5,
+}
+
+// answered returns an answer to a fresh question, given after ms
+// milliseconds.
+func answered(correct bool, ms int) Answer {
+	responseTime := time.Duration(ms) * time.Millisecond
+	return Answer{QuestionId: uuid.New(), Correct: correct, ResponseTime: &responseTime}
+}
+
+// unissued returns an answer to a question the quiz was never given.
+func unissued(correct bool) Answer {
+	return Answer{QuestionId: uuid.New(), Correct: correct}
+}
+
+// repeat returns another answer to the same question as a.
+func repeat(a Answer, correct bool) Answer {
+	a.Correct = correct
+	return a
+}
+
+// series returns n answers that are all correct or all wrong, each given
+// after ms milliseconds.
+func series(n int, correct bool, ms int) []Answer {
+	answers := make([]Answer, n)
+	for i := range answers {
+		answers[i] = answered(correct, ms)
+	}
+	return answers
+}
+
+func concat(parts ...[]Answer) []Answer {
+	var answers []Answer
+	for _, part := range parts {
+		answers = append(answers, part...)
+	}
+	return answers
+}
+
+func TestCheck(t *testing.T) {
+	first := answered(false, 4000)
+
+	tests := []struct {
+		name    string
+		rules   Rules
+		history History
+		want    []string
+	}{
+		{
+			name:    "empty quiz",
+			rules:   testRules,
+			history: History{},
+		},
+		{
+			name:  "fair quiz",
+			rules: testRules,
+			history: History{
+				Answers:          concat(series(8, true, 3000), series(2, false, 5000), series(3, true, 500)),
+				AccountsOnIP:     1,
+				AccountsOnDevice: 1,
+			},
+		},
+		{
+			name:    "fast correct answers over the limit",
+			rules:   testRules,
+			history: History{Answers: concat(series(4, true, 300), series(2, false, 3000))},
+			want:    []string{RuleFastAnswers},
+		},
+		{
+			name:    "fast wrong answers do not count",
+			rules:   testRules,
+			history: History{Answers: concat(series(3, true, 300), series(10, false, 300))},
+		},
+		{
+			name:    "answers at the fast answer time are not fast",
+			rules:   testRules,
+			history: History{Answers: series(10, true, 800)},
+		},
+		{
+			name:    "zero fast answer time turns the rule off",
+			rules:   with(testRules, func(r *Rules) { r.FastAnswerTime = 0 }),
+			history: History{Answers: series(10, true, 100)},
+		},
+		{
+			name:    "correct streak over the limit",
+			rules:   testRules,
+			history: History{Answers: concat(series(2, false, 3000), series(41, true, 3000))},
+			want:    []string{RuleCorrectStreak},
+		},
+		{
+			name:    "broken streaks stay under the limit",
+			rules:   testRules,
+			history: History{Answers: concat(series(40, true, 3000), series(1, false, 3000), series(40, true, 3000))},
+		},
+		{
+			name:    "zero correct streak turns the rule off",
+			rules:   with(testRules, func(r *Rules) { r.MaxCorrectStreak = 0 }),
+			history: History{Answers: series(100, true, 3000)},
+		},
+		{
+			name:    "answering a question again",
+			rules:   testRules,
+			history: History{Answers: []Answer{first, repeat(first, true)}},
+			want:    []string{RuleRepeatedAnswers},
+		},
+		{
+			name:    "repeated answers within the limit",
+			rules:   with(testRules, func(r *Rules) { r.MaxRepeatedAnswers = 1 }),
+			history: History{Answers: []Answer{first, repeat(first, true)}},
+		},
+		{
+			name:    "answering a question that was never issued",
+			rules:   testRules,
+			history: History{Answers: []Answer{answered(true, 3000), unissued(true)}},
+			want:    []string{RuleUnissuedAnswers},
+		},
+		{
+			name:    "unissued answers within the limit",
+			rules:   with(testRules, func(r *Rules) { r.MaxUnissuedAnswers = 2 }),
+			history: History{Answers: []Answer{unissued(true), unissued(false)}},
+		},
+		{
+			name:    "too many accounts on one address",
+			rules:   testRules,
+			history: History{Answers: series(3, true, 3000), AccountsOnIP: 6},
+			want:    []string{RuleSharedIP},
+		},
+		{
+			name:    "accounts on one address at the limit",
+			rules:   testRules,
+			history: History{Answers: series(3, true, 3000), AccountsOnIP: 5},
+		},
+		{
+			name:    "zero accounts per address turns the rule off",
+			rules:   with(testRules, func(r *Rules) { r.MaxAccountsPerIP = 0 }),
+			history: History{Answers: series(3, true, 3000), AccountsOnIP: 50},
+		},
+		{
+			name:    "too many accounts on one device",
+			rules:   testRules,
+			history: History{Answers: series(3, true, 3000), AccountsOnDevice: 3},
+			want:    []string{RuleSharedDevice},
+		},
+		{
+			name:    "zero accounts per device turns the rule off",
+			rules:   with(testRules, func(r *Rules) { r.MaxAccountsPerDevice = 0 }),
+			history: History{Answers: series(3, true, 3000), AccountsOnDevice: 50},
+		},
+		{
+			name:    "answers copied from another account",
+			rules:   testRules,
+			history: History{Answers: series(5, true, 3000), IdenticalQuizzes: 1},
+			want:    []string{RuleIdenticalAnswers},
+		},
+		{
+			name:    "sequences shorter than the minimum are not compared",
+			rules:   testRules,
+			history: History{Answers: series(4, true, 3000), IdenticalQuizzes: 3},
+		},
+		{
+			name:    "identical quizzes within the limit",
+			rules:   with(testRules, func(r *Rules) { r.MaxIdenticalQuizzes = 1 }),
+			history: History{Answers: series(5, true, 3000), IdenticalQuizzes: 1},
+		},
+		{
+			name:    "zero sequence length turns the rule off",
+			rules:   with(testRules, func(r *Rules) { r.MinSequenceLength = 0 }),
+			history: History{Answers: series(5, true, 3000), IdenticalQuizzes: 3},
+		},
+		{
+			name:  "every rule broken at once",
+			rules: testRules,
+			history: History{
+				Answers:          concat([]Answer{first, repeat(first, true), unissued(true)}, series(41, true, 100)),
+				AccountsOnIP:     6,
+				AccountsOnDevice: 3,
+				IdenticalQuizzes: 1,
+			},
+			want: []string{RuleFastAnswers, RuleCorrectStreak, RuleRepeatedAnswers, RuleUnissuedAnswers, RuleSharedIP, RuleSharedDevice, RuleIdenticalAnswers},
+		},
+		{
+			name:  "zero rules only keep the repeated and unissued limits",
+			rules: Rules{},
+			history: History{
+				Answers:          concat([]Answer{first, repeat(first, true), unissued(true)}, series(41, true, 100)),
+				AccountsOnIP:     6,
+				AccountsOnDevice: 3,
+				IdenticalQuizzes: 1,
+			},
+			want: []string{RuleRepeatedAnswers, RuleUnissuedAnswers},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, flag := range tt.rules.Check(tt.history) {
+				if flag.Detail == "" {
+					t.Errorf("flag %s has no detail", flag.Rule)
+				}
+				got = append(got, flag.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() flagged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func with(r Rules, change func(*Rules)) Rules {
+	change(&r)
+	return r
+}
@@ -0,0 +1,113 @@
+// Package apperrors classifies domain errors so that every handler answers
+// them with the same HTTP status and JSON body.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	// Internal is anything not classified below: a bug or a failing
+	// dependency.
+	Internal Kind = iota
+	NotFound
+	Conflict
+	Validation
+	Forbidden
+	// Unauthorized is a missing or invalid credential.
+	Unauthorized
+	TooManyRequests
+	// Unavailable is a feature that is switched off.
+	Unavailable
+)
+
+var kindCodes = map[Kind]string{
+	Internal:   "internal",
+	NotFound:   "not_found",
+	Conflict:   "conflict",
+	Validation: "validation",
+	Forbidden:  "forbidden",
+
+	Unauthorized:    "unauthorized",
+	TooManyRequests: "too_many_requests",
+	Unavailable:     "unavailable",
+}
+
+var kindStatuses = map[Kind]int{
+	Internal:   http.StatusInternalServerError,
+	NotFound:   http.StatusNotFound,
+	Conflict:   http.StatusConflict,
+	Validation: http.StatusBadRequest,
+	Forbidden:  http.StatusForbidden,
+
+	Unauthorized:    http.StatusUnauthorized,
+	TooManyRequests: http.StatusTooManyRequests,
+	Unavailable:     http.StatusServiceUnavailable,
+}
+
+func (k Kind) String() string {
+	return kindCodes[k]
+}
+
+// Error is a domain error of a known kind. Services declare their sentinel
+// errors with New and may wrap them with fmt.Errorf("%w: ...") for detail.
+type Error struct {
+	Kind    Kind
+	Message string
+	err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap classifies err as kind, keeping its message.
+func Wrap(kind Kind, err error) error {
+	return &Error{Kind: kind, Message: err.Error(), err: err}
+}
+
+// Invalid marks err, typically from parsing a request, as a validation error.
+func Invalid(err error) error {
+	return Wrap(Validation, err)
+}
+
+// KindOf returns the kind of the first Error in err's chain, or Internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Response is the JSON body of every error answer.
+type Response struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// HTTPStatus returns the status code that err's kind is answered with.
+func HTTPStatus(err error) int {
+	return kindStatuses[KindOf(err)]
+}
+
+// ResponseOf returns the JSON body for err. Internal errors are not
+// described, since their messages can reveal how the server is set up.
+func ResponseOf(err error) Response {
+	kind := KindOf(err)
+	if kind == Internal {
+		return Response{Error: "internal server error", Code: kind.String()}
+	}
+	return Response{Error: err.Error(), Code: kind.String()}
+}
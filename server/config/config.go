@@ -0,0 +1,532 @@
+// Package config loads the server's settings. Each layer overrides the one
+// before it: built-in defaults, an optional JSON file, environment variables
+// and finally command-line flags.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/anticheat"
+	"github.com/axitdhola/globetrotter/server/auth"
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/questiontoken"
+	"github.com/axitdhola/globetrotter/server/ratelimit"
+	"github.com/axitdhola/globetrotter/server/rating"
+)
+
+type Config struct {
+	Server     Server     `json:"server"`
+	Database   Database   `json:"database"`
+	Auth       Auth       `json:"auth"`
+	Quiz       Quiz       `json:"quiz"`
+	Tournament Tournament `json:"tournament"`
+	Features   Features   `json:"features"`
+	Log        Log        `json:"log"`
+	Metrics    Metrics    `json:"metrics"`
+	Tracing    Tracing    `json:"tracing"`
+	RateLimit  RateLimit  `json:"rate_limit"`
+	AntiCheat  AntiCheat  `json:"anti_cheat"`
+	// QuestionToken signs the token each issued question carries, which its
+	// answer must send back.
+	QuestionToken QuestionToken `json:"question_token"`
+}
+
+type Server struct {
+	Addr         string   `json:"addr"`
+	CORSOrigins  []string `json:"cors_origins"`
+	ReadTimeout  Duration `json:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests get to finish after
+	// SIGINT or SIGTERM.
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	// DrainDelay is how long readiness fails before the server stops
+	// accepting connections, so load balancers notice and stop sending
+	// traffic first.
+	DrainDelay Duration `json:"drain_delay"`
+	// RequestTimeout bounds how long a request's database work may run.
+	// RouteTimeouts overrides it for single routes, keyed by method and
+	// pattern such as "POST /admin/ratings/recompute".
+	RequestTimeout Duration            `json:"request_timeout"`
+	RouteTimeouts  map[string]Duration `json:"route_timeouts"`
+	// TrustedProxies are the proxy addresses or CIDR ranges whose
+	// X-Forwarded-For header names the client. Without any, the client is
+	// the connection's peer.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+type Database struct {
+	URL             string   `json:"url"`
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+	// The database is pinged at startup up to ConnectAttempts times, waiting
+	// ConnectBackoff after the first failure and twice as long after each
+	// one after that.
+	ConnectAttempts int      `json:"connect_attempts"`
+	ConnectBackoff  Duration `json:"connect_backoff"`
+	// Queries that take at least SlowQueryThreshold are logged as warnings.
+	SlowQueryThreshold Duration `json:"slow_query_threshold"`
+}
+
+type Auth struct {
+	// Secret signs session tokens. Sessions are disabled without one.
+	Secret     string   `json:"secret"`
+	SessionTTL Duration `json:"session_ttl"`
+}
+
+// QuestionToken holds the keys that sign question tokens, each written as
+// "id:secret". The first key signs; the others still verify, so a key can be
+// rotated by putting the new one first and dropping the old one once its
+// tokens have expired. Without any key the server signs with a random key
+// made at startup, which only suits a single instance.
+type QuestionToken struct {
+	Keys []string `json:"keys"`
+	// TTL is how long after issue a question may be answered.
+	TTL Duration `json:"ttl"`
+}
+
+// Keyring returns the configured keys, first the one that signs.
+func (q QuestionToken) Keyring() []questiontoken.Key {
+	keys := make([]questiontoken.Key, 0, len(q.Keys))
+	for _, entry := range q.Keys {
+		id, secret, _ := strings.Cut(entry, ":")
+		keys = append(keys, questiontoken.Key{Id: id, Secret: []byte(secret)})
+	}
+	return keys
+}
+
+// Quiz holds the settings a quiz gets when neither the request nor the
+// player's preferences choose them. Zero means unlimited.
+type Quiz struct {
+	DefaultLength       int    `json:"default_length"`
+	DefaultDifficulty   string `json:"default_difficulty"`
+	DefaultTimerSeconds int    `json:"default_timer_seconds"`
+}
+
+type Tournament struct {
+	// TickInterval is how often rounds are checked against their schedule.
+	TickInterval Duration `json:"tick_interval"`
+}
+
+// Features switch whole areas of the API on or off.
+type Features struct {
+	Friends     bool `json:"friends"`
+	Groups      bool `json:"groups"`
+	Tournaments bool `json:"tournaments"`
+}
+
+type Log struct {
+	// Format is "text" or "json".
+	Format string `json:"format"`
+	// Level is "debug", "info", "warn" or "error". Every database query is
+	// logged at debug.
+	Level string `json:"level"`
+}
+
+type Metrics struct {
+	// Enabled serves Prometheus metrics at /metrics.
+	Enabled bool `json:"enabled"`
+}
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+)
+
+type Tracing struct {
+	// Exporter is "none", "otlp" or "stdout".
+	Exporter string `json:"exporter"`
+	// OTLPEndpoint is the OTLP/HTTP collector's base URL for the otlp
+	// exporter. Spans go to its /v1/traces path.
+	OTLPEndpoint string `json:"otlp_endpoint"`
+	// SampleRatio is the share of new traces recorded, from 0 to 1. Requests
+	// that arrive with a trace context follow its decision instead.
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// RateLimit caps how often a client may call the routes in each group, per
+// IP address and, when the request carries a session token, per user.
+type RateLimit struct {
+	Enabled bool `json:"enabled"`
+	// Answer covers POST /quiz/answer.
+	Answer RateLimitGroup `json:"answer"`
+	// Register covers POST /user/register.
+	Register RateLimitGroup `json:"register"`
+	// Session covers POST /user/session.
+	Session RateLimitGroup `json:"session"`
+}
+
+type RateLimitGroup struct {
+	PerIP   Rate `json:"per_ip"`
+	PerUser Rate `json:"per_user"`
+}
+
+// Rate allows Requests per Per, in bursts of up to Burst. Zero Requests is
+// no limit.
+type Rate struct {
+	Requests int      `json:"requests"`
+	Per      Duration `json:"per"`
+	Burst    int      `json:"burst"`
+}
+
+func (r Rate) Limit() ratelimit.Limit {
+	return ratelimit.Per(r.Requests, r.Per.Duration, r.Burst)
+}
+
+// AntiCheat flags quizzes whose answers look impossible for admins to review.
+// Flagged quizzes stay off leaderboards until cleared. The thresholds are
+// explained on anticheat.Rules.
+type AntiCheat struct {
+	Enabled bool `json:"enabled"`
+	// CheckEvery is how many answers pass between checks of a quiz. Quizzes
+	// are also checked when they are completed.
+	CheckEvery         int      `json:"check_every"`
+	FastAnswerTime     Duration `json:"fast_answer_time"`
+	MaxFastAnswers     int      `json:"max_fast_answers"`
+	MaxCorrectStreak   int      `json:"max_correct_streak"`
+	MaxRepeatedAnswers int      `json:"max_repeated_answers"`
+	MaxUnissuedAnswers int      `json:"max_unissued_answers"`
+	// MaxAccountsPerIP is off by default: a school or office behind one
+	// NAT address puts many honest accounts on it, and a flag takes their
+	// quizzes off ratings and leaderboards. Turn it on only where players
+	// rarely share an address.
+	MaxAccountsPerIP     int `json:"max_accounts_per_ip"`
+	MaxAccountsPerDevice int `json:"max_accounts_per_device"`
+	// AccountWindow is how far back other accounts on the same IP address
+	// or device are counted.
+	AccountWindow       Duration `json:"account_window"`
+	MaxIdenticalQuizzes int      `json:"max_identical_quizzes"`
+	MinSequenceLength   int      `json:"min_sequence_length"`
+}
+
+func (a AntiCheat) Rules() anticheat.Rules {
+	return anticheat.Rules{
+		FastAnswerTime:       a.FastAnswerTime.Duration,
+		MaxFastAnswers:       a.MaxFastAnswers,
+		MaxCorrectStreak:     a.MaxCorrectStreak,
+		MaxRepeatedAnswers:   a.MaxRepeatedAnswers,
+		MaxUnissuedAnswers:   a.MaxUnissuedAnswers,
+		MaxAccountsPerIP:     a.MaxAccountsPerIP,
+		MaxAccountsPerDevice: a.MaxAccountsPerDevice,
+		MaxIdenticalQuizzes:  a.MaxIdenticalQuizzes,
+		MinSequenceLength:    a.MinSequenceLength,
+	}
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            ":8080",
+			CORSOrigins:     []string{"http://localhost:3000"},
+			ReadTimeout:     Duration{15 * time.Second},
+			WriteTimeout:    Duration{30 * time.Second},
+			IdleTimeout:     Duration{60 * time.Second},
+			ShutdownTimeout: Duration{20 * time.Second},
+			DrainDelay:      Duration{5 * time.Second},
+			RequestTimeout:  Duration{10 * time.Second},
+			RouteTimeouts: map[string]Duration{
+				"POST /admin/achievements/backfill": {25 * time.Second},
+				"POST /admin/ratings/recompute":     {25 * time.Second},
+			},
+		},
+		Database: Database{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration{30 * time.Minute},
+			ConnMaxIdleTime: Duration{5 * time.Minute},
+			ConnectAttempts: 5,
+			ConnectBackoff:  Duration{time.Second},
+
+			SlowQueryThreshold: Duration{200 * time.Millisecond},
+		},
+		Auth: Auth{
+			SessionTTL: Duration{auth.DefaultTTL},
+		},
+		QuestionToken: QuestionToken{
+			TTL: Duration{questiontoken.DefaultTTL},
+		},
+		Tournament: Tournament{
+			TickInterval: Duration{30 * time.Second},
+		},
+		Features: Features{
+			Friends:     true,
+			Groups:      true,
+			Tournaments: true,
+		},
+		Log: Log{
+			Format: logging.FormatText,
+			Level:  "info",
+		},
+		Metrics: Metrics{
+			Enabled: true,
+		},
+		Tracing: Tracing{
+			Exporter:     TraceExporterNone,
+			OTLPEndpoint: "http://localhost:4318",
+			SampleRatio:  1,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Answer: RateLimitGroup{
+				PerIP:   Rate{Requests: 120, Per: Duration{time.Minute}, Burst: 30},
+				PerUser: Rate{Requests: 60, Per: Duration{time.Minute}, Burst: 15},
+			},
+			Register: RateLimitGroup{
+				PerIP: Rate{Requests: 10, Per: Duration{time.Hour}, Burst: 5},
+			},
+			Session: RateLimitGroup{
+				PerIP: Rate{Requests: 30, Per: Duration{time.Minute}, Burst: 10},
+			},
+		},
+		AntiCheat: AntiCheat{
+			Enabled:              true,
+			CheckEvery:           5,
+			FastAnswerTime:       Duration{800 * time.Millisecond},
+			MaxFastAnswers:       3,
+			MaxCorrectStreak:     40,
+			MaxAccountsPerDevice: 2,
+			AccountWindow:        Duration{24 * time.Hour},
+			MinSequenceLength:    5,
+		},
+	}
+}
+
+// Validate reports every setting that the server cannot start with.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(len(c.Server.CORSOrigins) > 0, "server.cors_origins needs at least one origin")
+	for _, origin := range c.Server.CORSOrigins {
+		check(validOrigin(origin), "server.cors_origins: %q is not an http(s) origin or *", origin)
+	}
+	check(c.Server.ReadTimeout.Duration > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout.Duration > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout.Duration > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout.Duration > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.DrainDelay.Duration >= 0, "server.drain_delay must not be negative")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+	}
+	check(c.Server.RequestTimeout.Duration > 0, "server.request_timeout must be positive")
+	for route, timeout := range c.Server.RouteTimeouts {
+		check(validRoute(route), "server.route_timeouts: %q is not a method and path such as \"GET /quiz/:quiz_id/question\"", route)
+		check(timeout.Duration > 0, "server.route_timeouts: %q must be positive", route)
+	}
+
+	check(c.Database.URL != "", "database.url is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns cannot be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns cannot be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns cannot exceed database.max_open_conns")
+	check(c.Database.ConnMaxLifetime.Duration >= 0, "database.conn_max_lifetime cannot be negative")
+	check(c.Database.ConnMaxIdleTime.Duration >= 0, "database.conn_max_idle_time cannot be negative")
+	check(c.Database.ConnectAttempts >= 1, "database.connect_attempts must be at least 1")
+	check(c.Database.ConnectBackoff.Duration > 0, "database.connect_backoff must be positive")
+	check(c.Database.SlowQueryThreshold.Duration > 0, "database.slow_query_threshold must be positive")
+
+	check(c.Auth.SessionTTL.Duration > 0, "auth.session_ttl must be positive")
+
+	keyIds := map[string]bool{}
+	for i, entry := range c.QuestionToken.Keys {
+		id, secret, ok := strings.Cut(entry, ":")
+		check(ok && validKeyId(id) && secret != "", "question_token.keys[%d] must be written as id:secret, with an id of letters, digits, - or _", i)
+		check(!keyIds[id], "question_token.keys[%d]: key id %q is used twice", i, id)
+		keyIds[id] = true
+	}
+	check(c.QuestionToken.TTL.Duration > 0, "question_token.ttl must be positive")
+
+	check(c.Quiz.DefaultLength >= 0 && c.Quiz.DefaultLength <= models.MaxQuizLength,
+		"quiz.default_length must be between 0 and %d", models.MaxQuizLength)
+	_, ok := rating.DifficultyBand(c.Quiz.DefaultDifficulty)
+	check(ok, "quiz.default_difficulty: unknown difficulty %q", c.Quiz.DefaultDifficulty)
+	check(c.Quiz.DefaultTimerSeconds == 0 ||
+		(c.Quiz.DefaultTimerSeconds >= models.MinQuizTimerSeconds && c.Quiz.DefaultTimerSeconds <= models.MaxQuizTimerSeconds),
+		"quiz.default_timer_seconds must be 0 or between %d and %d", models.MinQuizTimerSeconds, models.MaxQuizTimerSeconds)
+
+	check(c.Tournament.TickInterval.Duration > 0, "tournament.tick_interval must be positive")
+
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON,
+		"log.format must be %q or %q", logging.FormatText, logging.FormatJSON)
+	_, err := logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %v", err)
+
+	switch c.Tracing.Exporter {
+	case TraceExporterNone, TraceExporterStdout:
+	case TraceExporterOTLP:
+		parsed, err := url.Parse(c.Tracing.OTLPEndpoint)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+			"tracing.otlp_endpoint: %q is not an http(s) URL", c.Tracing.OTLPEndpoint)
+	default:
+		check(false, "tracing.exporter must be %q, %q or %q", TraceExporterNone, TraceExporterOTLP, TraceExporterStdout)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	rates := []struct {
+		name string
+		rate Rate
+	}{
+		{"answer.per_ip", c.RateLimit.Answer.PerIP},
+		{"answer.per_user", c.RateLimit.Answer.PerUser},
+		{"register.per_ip", c.RateLimit.Register.PerIP},
+		{"register.per_user", c.RateLimit.Register.PerUser},
+		{"session.per_ip", c.RateLimit.Session.PerIP},
+		{"session.per_user", c.RateLimit.Session.PerUser},
+	}
+	for _, r := range rates {
+		check(r.rate.Requests >= 0, "rate_limit.%s.requests cannot be negative", r.name)
+		check(r.rate.Requests == 0 || (r.rate.Per.Duration > 0 && r.rate.Burst >= 1),
+			"rate_limit.%s needs a positive per and a burst of at least 1", r.name)
+	}
+
+	check(c.AntiCheat.CheckEvery >= 1, "anti_cheat.check_every must be at least 1")
+	check(c.AntiCheat.FastAnswerTime.Duration >= 0, "anti_cheat.fast_answer_time cannot be negative")
+	check(c.AntiCheat.AccountWindow.Duration > 0, "anti_cheat.account_window must be positive")
+	thresholds := []struct {
+		name  string
+		value int
+	}{
+		{"max_fast_answers", c.AntiCheat.MaxFastAnswers},
+		{"max_correct_streak", c.AntiCheat.MaxCorrectStreak},
+		{"max_repeated_answers", c.AntiCheat.MaxRepeatedAnswers},
+		{"max_unissued_answers", c.AntiCheat.MaxUnissuedAnswers},
+		{"max_accounts_per_ip", c.AntiCheat.MaxAccountsPerIP},
+		{"max_accounts_per_device", c.AntiCheat.MaxAccountsPerDevice},
+		{"max_identical_quizzes", c.AntiCheat.MaxIdenticalQuizzes},
+		{"min_sequence_length", c.AntiCheat.MinSequenceLength},
+	}
+	for _, t := range thresholds {
+		check(t.value >= 0, "anti_cheat.%s cannot be negative", t.name)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" && strings.TrimRight(parsed.Path, "/") == ""
+}
+
+func validProxy(proxy string) bool {
+	if _, _, err := net.ParseCIDR(proxy); err == nil {
+		return true
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+func validKeyId(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func validRoute(route string) bool {
+	method, path, ok := strings.Cut(route, " ")
+	return ok && method != "" && method == strings.ToUpper(method) && strings.HasPrefix(path, "/")
+}
+
+const redacted = "[redacted]"
+
+// Redacted returns a copy that is safe to print: secrets are replaced and the
+// database URL loses its password.
+func (c Config) Redacted() Config {
+	if c.Database.URL != "" {
+		if parsed, err := url.Parse(c.Database.URL); err == nil && parsed.Scheme != "" {
+			c.Database.URL = parsed.Redacted()
+		} else {
+			c.Database.URL = redacted
+		}
+	}
+	if c.Auth.Secret != "" {
+		c.Auth.Secret = redacted
+	}
+	keys := make([]string, len(c.QuestionToken.Keys))
+	for i, entry := range c.QuestionToken.Keys {
+		id, _, _ := strings.Cut(entry, ":")
+		keys[i] = id + ":" + redacted
+	}
+	c.QuestionToken.Keys = keys
+	c.Server.CORSOrigins = append([]string(nil), c.Server.CORSOrigins...)
+	c.Server.TrustedProxies = append([]string(nil), c.Server.TrustedProxies...)
+	routeTimeouts := make(map[string]Duration, len(c.Server.RouteTimeouts))
+	for route, timeout := range c.Server.RouteTimeouts {
+		routeTimeouts[route] = timeout
+	}
+	c.Server.RouteTimeouts = routeTimeouts
+	return c
+}
+
+// RouteTimeoutDurations returns RouteTimeouts as plain durations.
+func (s Server) RouteTimeoutDurations() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(s.RouteTimeouts))
+	for route, timeout := range s.RouteTimeouts {
+		timeouts[route] = timeout.Duration
+	}
+	return timeouts
+}
+
+// Preferences returns the quiz defaults as player preferences.
+func (q Quiz) Preferences() models.QuizPreferences {
+	var prefs models.QuizPreferences
+	if q.DefaultLength > 0 {
+		length := q.DefaultLength
+		prefs.Length = &length
+	}
+	prefs.Difficulty = q.DefaultDifficulty
+	if q.DefaultTimerSeconds > 0 {
+		timer := q.DefaultTimerSeconds
+		prefs.TimerSeconds = &timer
+	}
+	return prefs
+}
+
+// Duration is a time.Duration written as a string such as "30s" in config
+// files, environment variables and flags.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %v", err)
+	}
+	return d.Set(s)
+}
+
+func (d *Duration) Set(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FileEnv names the config file when the -config flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// setting is one value that environment variables and flags can override.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value func(c *Config) flag.Value
+}
+
+var settings = []setting{
+	{"LISTEN_ADDR", "addr", "address to listen on", func(c *Config) flag.Value { return (*stringValue)(&c.Server.Addr) }},
+	{"CORS_ORIGINS", "cors-origins", "comma-separated origins allowed by CORS", func(c *Config) flag.Value { return (*listValue)(&c.Server.CORSOrigins) }},
+	{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated proxy addresses or CIDR ranges whose X-Forwarded-For is believed", func(c *Config) flag.Value { return (*listValue)(&c.Server.TrustedProxies) }},
+	{"HTTP_READ_TIMEOUT", "read-timeout", "maximum time to read a request", func(c *Config) flag.Value { return &c.Server.ReadTimeout }},
+	{"HTTP_WRITE_TIMEOUT", "write-timeout", "maximum time to write a response", func(c *Config) flag.Value { return &c.Server.WriteTimeout }},
+	{"HTTP_IDLE_TIMEOUT", "idle-timeout", "how long idle keep-alive connections stay open", func(c *Config) flag.Value { return &c.Server.IdleTimeout }},
+	{"HTTP_SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long in-flight requests may run after a shutdown signal", func(c *Config) flag.Value { return &c.Server.ShutdownTimeout }},
+	{"HTTP_DRAIN_DELAY", "drain-delay", "how long readiness fails before a shutdown stops accepting connections", func(c *Config) flag.Value { return &c.Server.DrainDelay }},
+	{"REQUEST_TIMEOUT", "request-timeout", "how long a request's database work may run; see server.route_timeouts for single routes", func(c *Config) flag.Value { return &c.Server.RequestTimeout }},
+	{"DATABASE_URL", "database-url", "Postgres connection string", func(c *Config) flag.Value { return (*stringValue)(&c.Database.URL) }},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections (0 is unlimited)", func(c *Config) flag.Value { return (*intValue)(&c.Database.MaxOpenConns) }},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", func(c *Config) flag.Value { return (*intValue)(&c.Database.MaxIdleConns) }},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "how long a database connection may be reused (0 is forever)", func(c *Config) flag.Value { return &c.Database.ConnMaxLifetime }},
+	{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "how long a database connection may sit idle (0 is forever)", func(c *Config) flag.Value { return &c.Database.ConnMaxIdleTime }},
+	{"DB_CONNECT_ATTEMPTS", "db-connect-attempts", "how many times to try reaching the database at startup", func(c *Config) flag.Value { return (*intValue)(&c.Database.ConnectAttempts) }},
+	{"DB_CONNECT_BACKOFF", "db-connect-backoff", "wait after the first failed database connection, doubled each retry", func(c *Config) flag.Value { return &c.Database.ConnectBackoff }},
+	{"DB_SLOW_QUERY_THRESHOLD", "db-slow-query-threshold", "queries taking at least this long are logged as warnings", func(c *Config) flag.Value { return &c.Database.SlowQueryThreshold }},
+	{"AUTH_SECRET", "auth-secret", "secret that signs session tokens", func(c *Config) flag.Value { return (*stringValue)(&c.Auth.Secret) }},
+	{"SESSION_TTL", "session-ttl", "how long session tokens stay valid", func(c *Config) flag.Value { return &c.Auth.SessionTTL }},
+	{"QUESTION_TOKEN_KEYS", "question-token-keys", "comma-separated id:secret keys that sign question tokens; the first signs", func(c *Config) flag.Value { return (*listValue)(&c.QuestionToken.Keys) }},
+	{"QUESTION_TOKEN_TTL", "question-token-ttl", "how long after issue a question may be answered", func(c *Config) flag.Value { return &c.QuestionToken.TTL }},
+	{"QUIZ_DEFAULT_LENGTH", "quiz-default-length", "questions per quiz when the player sets none (0 is unlimited)", func(c *Config) flag.Value { return (*intValue)(&c.Quiz.DefaultLength) }},
+	{"QUIZ_DEFAULT_DIFFICULTY", "quiz-default-difficulty", "easy, medium or hard; empty for any", func(c *Config) flag.Value { return (*stringValue)(&c.Quiz.DefaultDifficulty) }},
+	{"QUIZ_DEFAULT_TIMER_SECONDS", "quiz-default-timer-seconds", "seconds per question when the player sets none (0 is untimed)", func(c *Config) flag.Value { return (*intValue)(&c.Quiz.DefaultTimerSeconds) }},
+	{"TOURNAMENT_TICK_INTERVAL", "tournament-tick-interval", "how often tournament rounds are opened and closed", func(c *Config) flag.Value { return &c.Tournament.TickInterval }},
+	{"FEATURE_FRIENDS", "feature-friends", "enable friends", func(c *Config) flag.Value { return (*boolValue)(&c.Features.Friends) }},
+	{"FEATURE_GROUPS", "feature-groups", "enable groups and assignments", func(c *Config) flag.Value { return (*boolValue)(&c.Features.Groups) }},
+	{"FEATURE_TOURNAMENTS", "feature-tournaments", "enable tournaments and their scheduler", func(c *Config) flag.Value { return (*boolValue)(&c.Features.Tournaments) }},
+	{"LOG_FORMAT", "log-format", "text or json", func(c *Config) flag.Value { return (*stringValue)(&c.Log.Format) }},
+	{"LOG_LEVEL", "log-level", "debug, info, warn or error", func(c *Config) flag.Value { return (*stringValue)(&c.Log.Level) }},
+	{"METRICS_ENABLED", "metrics-enabled", "serve Prometheus metrics at /metrics", func(c *Config) flag.Value { return (*boolValue)(&c.Metrics.Enabled) }},
+	{"TRACING_EXPORTER", "tracing-exporter", "where to send traces: none, otlp or stdout", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.Exporter) }},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "OTLP/HTTP collector base URL for the otlp trace exporter", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.OTLPEndpoint) }},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "share of new traces recorded, from 0 to 1", func(c *Config) flag.Value { return (*floatValue)(&c.Tracing.SampleRatio) }},
+	{"RATE_LIMIT_ENABLED", "rate-limit-enabled", "rate limit answers, registrations and sign-ins; see rate_limit in the config file", func(c *Config) flag.Value { return (*boolValue)(&c.RateLimit.Enabled) }},
+	{"ANTI_CHEAT_ENABLED", "anti-cheat-enabled", "flag suspicious quizzes for review; see anti_cheat in the config file", func(c *Config) flag.Value { return (*boolValue)(&c.AntiCheat.Enabled) }},
+}
+
+// Load builds the configuration from args, the environment and the config
+// file, and validates it.
+func Load(name string, args []string) (Config, error) {
+	// Flags are parsed first to find the config file, but applied last.
+	var scratch Config
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(FileEnv), "path to a JSON config file")
+	for _, s := range settings {
+		flags.Var(s.value(&scratch), s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if flags.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok {
+			if err := s.value(&cfg).Set(raw); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %v", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				flagErr = s.value(&cfg).Set(f.Value.String())
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Print writes cfg as indented JSON with its secrets redacted.
+func Print(w io.Writer, cfg Config) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cfg.Redacted())
+}
+
+type stringValue string
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string {
+	return strconv.FormatFloat(float64(*v), 'g', -1, 64)
+}
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+// IsBoolFlag lets boolean flags be given without a value.
+func (v *boolValue) IsBoolFlag() bool {
+	return true
+}
+
+// listValue is a comma-separated list. Setting it replaces the whole list.
+type listValue []string
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v = items
+	return nil
+}
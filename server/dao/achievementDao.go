@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/achievements"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type AchievementDao interface {
+	GetUserFacts(ctx context.Context, userId uuid.UUID) (achievements.Facts, error)
+	ListUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error)
+	AwardAchievements(ctx context.Context, userId uuid.UUID, quizId *uuid.UUID, achievementIds []string) ([]models.Achievement, error)
+	ListUserIds(ctx context.Context) ([]uuid.UUID, error)
+}
+
+type achievementDaoImpl struct {
+	db *sql.DB
+}
+
+func NewAchievementDao(db *sql.DB) AchievementDao {
+	return &achievementDaoImpl{
+		db: db,
+	}
+}
+
+// GetUserFacts summarizes everything the achievement rules look at for a
+// user in a single round trip.
+func (a *achievementDaoImpl) GetUserFacts(ctx context.Context, userId uuid.UUID) (achievements.Facts, error) {
+	var facts achievements.Facts
+
+	query := `
+	WITH ` + userAnswersCTE + `, ` + userStreaksCTE + `
+	SELECT
+		(SELECT COUNT(*) FROM answers WHERE is_correct),
+		COALESCE((SELECT MAX(length) FROM streaks), 0),
+		(SELECT COUNT(DISTINCT q.continent)
+			FROM answers a
+			JOIN questions q ON q.id = a.question_id
+			WHERE a.is_correct AND q.continent <> ''),
+		(SELECT COUNT(DISTINCT q.continent) FROM questions q WHERE ` + publishedClause + ` AND q.continent <> ''),
+		(SELECT COUNT(*)
+			FROM quiz z
+			WHERE z.user_id = $1 AND z.completed_at IS NOT NULL
+			AND (SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = z.id) >= $2
+			AND NOT EXISTS (SELECT 1 FROM quiz_questions qq WHERE qq.quiz_id = z.id AND NOT qq.is_correct)),
+		(SELECT COUNT(*)
+			FROM quiz z
+			JOIN quiz c ON c.id = z.challenge_quiz_id
+			WHERE z.user_id = $1 AND z.completed_at IS NOT NULL
+			AND c.user_id <> z.user_id AND z.score > c.score)
+	`
+
+	err := a.db.QueryRowContext(ctx, query, userId, achievements.MinPerfectQuizLength).Scan(
+		&facts.CorrectAnswers,
+		&facts.BestStreak,
+		&facts.ContinentsCorrect,
+		&facts.TotalContinents,
+		&facts.PerfectQuizzes,
+		&facts.ChallengesWon,
+	)
+	if err != nil {
+		return achievements.Facts{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return facts, nil
+}
+
+// ListUserAchievements returns the ids and award details of the user's
+// badges, oldest first. It returns sql.ErrNoRows when the user does not exist.
+func (a *achievementDaoImpl) ListUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error) {
+	var exists bool
+	if err := a.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", userId).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := a.db.QueryContext(ctx, "SELECT achievement_id, quiz_id, earned_at FROM user_achievements WHERE user_id = $1 ORDER BY earned_at, achievement_id", userId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	return scanAchievements(rows)
+}
+
+// AwardAchievements stores the badges for the user and returns the ones that
+// were not already earned.
+func (a *achievementDaoImpl) AwardAchievements(ctx context.Context, userId uuid.UUID, quizId *uuid.UUID, achievementIds []string) ([]models.Achievement, error) {
+	query := `
+	INSERT INTO user_achievements (user_id, achievement_id, quiz_id)
+	SELECT $1, unnest($2::text[]), $3
+	ON CONFLICT DO NOTHING
+	RETURNING achievement_id, quiz_id, earned_at
+	`
+
+	rows, err := a.db.QueryContext(ctx, query, userId, pq.Array(achievementIds), quizId)
+	if err != nil {
+		return nil, fmt.Errorf("error awarding achievements: %v", err)
+	}
+	defer rows.Close()
+
+	return scanAchievements(rows)
+}
+
+func (a *achievementDaoImpl) ListUserIds(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id FROM users ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var userIds []uuid.UUID
+	for rows.Next() {
+		var userId uuid.UUID
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
+
+func scanAchievements(rows *sql.Rows) ([]models.Achievement, error) {
+	earned := []models.Achievement{}
+	for rows.Next() {
+		var achievement models.Achievement
+		if err := rows.Scan(&achievement.Id, &achievement.QuizId, &achievement.EarnedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		earned = append(earned, achievement)
+	}
+	return earned, rows.Err()
+}
@@ -0,0 +1,221 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/anticheat"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+type AntiCheatDao interface {
+	IsQuizFlagged(ctx context.Context, quizId uuid.UUID) (bool, error)
+	ListQuizAnswers(ctx context.Context, quizId uuid.UUID) ([]anticheat.Answer, error)
+	CountSharedAccounts(ctx context.Context, quizId uuid.UUID, window time.Duration) (onIP int, onDevice int, err error)
+	CountIdenticalQuizzes(ctx context.Context, quizId uuid.UUID, minLength int) (int, error)
+	FlagQuiz(ctx context.Context, quizId uuid.UUID, reasons []models.QuizFlagReason) error
+	ListQuizFlags(ctx context.Context, status string) ([]models.QuizFlag, error)
+	ReviewQuizFlag(ctx context.Context, quizId uuid.UUID, status string, reviewer string) (models.QuizFlag, error)
+}
+
+type antiCheatDaoImpl struct {
+	db *sql.DB
+}
+
+func NewAntiCheatDao(db *sql.DB) AntiCheatDao {
+	return &antiCheatDaoImpl{db: db}
+}
+
+// quizFlagged is an SQL condition that holds when the quiz whose id is
+// quizId has a flag that has not been cleared. Leaderboards leave such
+// quizzes out, and their answers are not rated.
+func quizFlagged(quizId string) string {
+	return `EXISTS (SELECT 1 FROM quiz_flags f WHERE f.quiz_id = ` + quizId + ` AND f.status <> '` + models.QuizFlagCleared + `')`
+}
+
+// playerFlagged is an SQL condition that holds when the user whose id is
+// userId has any quiz with a flag that has not been cleared. Rating
+// leaderboards leave such players out, since the flagged quiz's earlier
+// answers may already have moved their rating.
+func playerFlagged(userId string) string {
+	return `EXISTS (SELECT 1 FROM quiz_flags f JOIN quiz fq ON fq.id = f.quiz_id WHERE fq.user_id = ` + userId + ` AND f.status <> '` + models.QuizFlagCleared + `')`
+}
+
+func (a *antiCheatDaoImpl) IsQuizFlagged(ctx context.Context, quizId uuid.UUID) (bool, error) {
+	var flagged bool
+	err := a.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM quiz_flags WHERE quiz_id = $1)", quizId).Scan(&flagged)
+	if err != nil {
+		return false, fmt.Errorf("query execution error: %v", err)
+	}
+	return flagged, nil
+}
+
+func (a *antiCheatDaoImpl) ListQuizAnswers(ctx context.Context, quizId uuid.UUID) ([]anticheat.Answer, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT question_id, is_correct, response_time_ms FROM quiz_questions WHERE quiz_id = $1 ORDER BY order_number, created_at", quizId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var answers []anticheat.Answer
+	for rows.Next() {
+		var answer anticheat.Answer
+		var responseTimeMs sql.NullInt64
+		if err := rows.Scan(&answer.QuestionId, &answer.Correct, &responseTimeMs); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		if responseTimeMs.Valid {
+			responseTime := time.Duration(responseTimeMs.Int64) * time.Millisecond
+			answer.ResponseTime = &responseTime
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, rows.Err()
+}
+
+// CountSharedAccounts counts the other accounts that started quizzes from
+// the quiz's IP address and from its device within the last window.
+func (a *antiCheatDaoImpl) CountSharedAccounts(ctx context.Context, quizId uuid.UUID, window time.Duration) (int, int, error) {
+	query := `
+	SELECT
+		(SELECT COUNT(DISTINCT o.user_id) FROM quiz o
+			WHERE o.client_ip = q.client_ip AND o.user_id <> q.user_id AND o.created_at > CURRENT_TIMESTAMP - make_interval(secs => $2)),
+		(SELECT COUNT(DISTINCT o.user_id) FROM quiz o
+			WHERE o.device_id = q.device_id AND o.user_id <> q.user_id AND o.created_at > CURRENT_TIMESTAMP - make_interval(secs => $2))
+	FROM quiz q
+	WHERE q.id = $1
+	`
+
+	var onIP, onDevice int
+	err := a.db.QueryRowContext(ctx, query, quizId, window.Seconds()).Scan(&onIP, &onDevice)
+	if err != nil {
+		return 0, 0, fmt.Errorf("query execution error: %v", err)
+	}
+	return onIP, onDevice, nil
+}
+
+// CountIdenticalQuizzes counts other accounts' quizzes that began with the
+// same questions, in the same order, answered with exactly the same text.
+// Quizzes with fewer than minLength answers are not compared.
+func (a *antiCheatDaoImpl) CountIdenticalQuizzes(ctx context.Context, quizId uuid.UUID, minLength int) (int, error) {
+	query := `
+	WITH mine AS (
+		SELECT q.user_id,
+			array_agg(qq.question_id ORDER BY qq.order_number) AS questions,
+			array_agg(qq.user_answer ORDER BY qq.order_number) AS answers
+		FROM quiz q
+		JOIN quiz_questions qq ON qq.quiz_id = q.id
+		WHERE q.id = $1
+		GROUP BY q.user_id
+	)
+	SELECT COUNT(*)
+	FROM mine
+	JOIN quiz_questions first ON first.question_id = mine.questions[1] AND first.order_number = 1
+	JOIN quiz o ON o.id = first.quiz_id AND o.user_id <> mine.user_id
+	CROSS JOIN LATERAL (
+		SELECT array_agg(qq.question_id ORDER BY qq.order_number) AS questions,
+			array_agg(qq.user_answer ORDER BY qq.order_number) AS answers
+		FROM quiz_questions qq
+		WHERE qq.quiz_id = o.id AND qq.order_number <= cardinality(mine.questions)
+	) theirs
+	WHERE cardinality(mine.questions) >= $2 AND theirs.questions = mine.questions AND theirs.answers = mine.answers
+	`
+
+	var count int
+	if err := a.db.QueryRowContext(ctx, query, quizId, minLength).Scan(&count); err != nil {
+		return 0, fmt.Errorf("query execution error: %v", err)
+	}
+	return count, nil
+}
+
+// FlagQuiz records the reasons a quiz was found suspicious. A quiz is only
+// flagged once; later calls leave the existing flag and its review alone.
+func (a *antiCheatDaoImpl) FlagQuiz(ctx context.Context, quizId uuid.UUID, reasons []models.QuizFlagReason) error {
+	encoded, err := json.Marshal(reasons)
+	if err != nil {
+		return fmt.Errorf("error encoding flag reasons: %v", err)
+	}
+
+	_, err = a.db.ExecContext(ctx, "INSERT INTO quiz_flags (quiz_id, reasons) VALUES ($1, $2) ON CONFLICT (quiz_id) DO NOTHING", quizId, encoded)
+	if err != nil {
+		return fmt.Errorf("error flagging quiz: %v", err)
+	}
+	return nil
+}
+
+const quizFlagColumns = `f.quiz_id, q.user_id, u.username, COALESCE(q.score, 0), f.reasons, f.status, f.flagged_at, f.reviewed_by, f.reviewed_at`
+
+const quizFlagTables = `quiz_flags f
+	JOIN quiz q ON q.id = f.quiz_id
+	JOIN users u ON u.id = q.user_id`
+
+func scanQuizFlag(row rowScanner) (models.QuizFlag, error) {
+	var flag models.QuizFlag
+	var reasons []byte
+	err := row.Scan(&flag.QuizId, &flag.UserId, &flag.UserName, &flag.Score, &reasons, &flag.Status, &flag.FlaggedAt, &flag.ReviewedBy, &flag.ReviewedAt)
+	if err != nil {
+		return models.QuizFlag{}, err
+	}
+	if err := json.Unmarshal(reasons, &flag.Reasons); err != nil {
+		return models.QuizFlag{}, fmt.Errorf("error decoding flag reasons: %v", err)
+	}
+	return flag, nil
+}
+
+// ListQuizFlags returns the flags with the given status, or all flags when
+// status is empty, newest first.
+func (a *antiCheatDaoImpl) ListQuizFlags(ctx context.Context, status string) ([]models.QuizFlag, error) {
+	query := `
+	SELECT ` + quizFlagColumns + `
+	FROM ` + quizFlagTables + `
+	WHERE $1 = '' OR f.status = $1
+	ORDER BY f.flagged_at DESC
+	`
+
+	rows, err := a.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	flags := []models.QuizFlag{}
+	for rows.Next() {
+		flag, err := scanQuizFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
+
+// ReviewQuizFlag records an admin's verdict. It returns sql.ErrNoRows if
+// the quiz was never flagged.
+func (a *antiCheatDaoImpl) ReviewQuizFlag(ctx context.Context, quizId uuid.UUID, status string, reviewer string) (models.QuizFlag, error) {
+	query := `
+	WITH f AS (
+		UPDATE quiz_flags SET status = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE quiz_id = $1
+		RETURNING *
+	)
+	SELECT ` + quizFlagColumns + `
+	FROM f
+	JOIN quiz q ON q.id = f.quiz_id
+	JOIN users u ON u.id = q.user_id
+	`
+
+	flag, err := scanQuizFlag(a.db.QueryRowContext(ctx, query, quizId, status, reviewer))
+	if err == sql.ErrNoRows {
+		return models.QuizFlag{}, err
+	}
+	if err != nil {
+		return models.QuizFlag{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return flag, nil
+}
@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+)
+
+type AuditDao interface {
+	RecordAudit(ctx context.Context, entry models.AuditEntry) error
+	ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditDaoImpl struct {
+	db *sql.DB
+}
+
+func NewAuditDao(db *sql.DB) AuditDao {
+	return &auditDaoImpl{db: db}
+}
+
+// RecordAudit appends an entry to the audit log. The table refuses updates
+// and deletes, so entries are permanent.
+func (a *auditDaoImpl) RecordAudit(ctx context.Context, entry models.AuditEntry) error {
+	target := entry.Target
+	if target == nil {
+		target = map[string]string{}
+	}
+	encoded, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("error encoding audit target: %v", err)
+	}
+
+	_, err = a.db.ExecContext(ctx, `
+	INSERT INTO audit_log (actor_id, actor, actor_role, action, target, status, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+	`, entry.ActorId, entry.Actor, entry.ActorRole, entry.Action, encoded, entry.Status, entry.RequestId)
+	if err != nil {
+		return fmt.Errorf("error recording audit entry: %v", err)
+	}
+	return nil
+}
+
+// ListAuditLog returns entries newest first, optionally only those by one
+// actor or for one action, and only those older than filter.Before.
+func (a *auditDaoImpl) ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `
+	SELECT id, actor_id, actor, actor_role, action, target, status, COALESCE(request_id, ''), created_at
+	FROM audit_log
+	WHERE ($1 = '' OR actor = $1) AND ($2 = '' OR action = $2) AND ($3 = 0 OR id < $3)
+	ORDER BY id DESC
+	LIMIT $4
+	`
+
+	rows, err := a.db.QueryContext(ctx, query, filter.Actor, filter.Action, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var target []byte
+		err := rows.Scan(&entry.Id, &entry.ActorId, &entry.Actor, &entry.ActorRole, &entry.Action, &target, &entry.Status, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		if err := json.Unmarshal(target, &entry.Target); err != nil {
+			return nil, fmt.Errorf("error decoding audit target: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
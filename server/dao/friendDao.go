@@ -0,0 +1,335 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+type FriendDao interface {
+	CreateFriendRequest(ctx context.Context, senderId uuid.UUID, recipientId uuid.UUID) (models.FriendRequest, error)
+	GetPendingFriendRequest(ctx context.Context, senderId uuid.UUID, recipientId uuid.UUID) (models.FriendRequest, error)
+	RespondToFriendRequest(ctx context.Context, requestId uuid.UUID, recipientId uuid.UUID, accept bool) error
+	ListFriendRequests(ctx context.Context, userId uuid.UUID) (models.FriendRequests, error)
+	ListFriends(ctx context.Context, userId uuid.UUID) ([]models.Friend, error)
+	AreFriends(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
+	RemoveFriend(ctx context.Context, userId uuid.UUID, friendId uuid.UUID) error
+	BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
+	GetFriendsLeaderboard(ctx context.Context, userId uuid.UUID) ([]models.FriendStanding, error)
+	GetFriendActivity(ctx context.Context, userId uuid.UUID, limit int) ([]models.FriendActivity, error)
+}
+
+type friendDaoImpl struct {
+	db *sql.DB
+}
+
+func NewFriendDao(db *sql.DB) FriendDao {
+	return &friendDaoImpl{
+		db: db,
+	}
+}
+
+const friendRequestColumns = `fr.id, fr.sender_id, s.username, fr.recipient_id, r.username, fr.status, fr.created_at, fr.responded_at`
+
+const friendRequestFrom = `
+	FROM friend_requests fr
+	JOIN users s ON s.id = fr.sender_id
+	JOIN users r ON r.id = fr.recipient_id`
+
+func scanFriendRequest(row rowScanner) (models.FriendRequest, error) {
+	var request models.FriendRequest
+	err := row.Scan(&request.Id, &request.SenderId, &request.SenderName, &request.RecipientId, &request.RecipientName,
+		&request.Status, &request.CreatedAt, &request.RespondedAt)
+	return request, err
+}
+
+// CreateFriendRequest opens a request from sender to recipient. It returns
+// sql.ErrNoRows when a request between the two is already open.
+func (f *friendDaoImpl) CreateFriendRequest(ctx context.Context, senderId uuid.UUID, recipientId uuid.UUID) (models.FriendRequest, error) {
+	var requestId uuid.UUID
+	err := f.db.QueryRowContext(ctx, "INSERT INTO friend_requests (sender_id, recipient_id) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING id",
+		senderId, recipientId).Scan(&requestId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.FriendRequest{}, err
+		}
+		return models.FriendRequest{}, fmt.Errorf("error creating friend request: %v", err)
+	}
+
+	request, err := scanFriendRequest(f.db.QueryRowContext(ctx, "SELECT "+friendRequestColumns+friendRequestFrom+" WHERE fr.id = $1", requestId))
+	if err != nil {
+		return models.FriendRequest{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return request, nil
+}
+
+func (f *friendDaoImpl) GetPendingFriendRequest(ctx context.Context, senderId uuid.UUID, recipientId uuid.UUID) (models.FriendRequest, error) {
+	query := "SELECT " + friendRequestColumns + friendRequestFrom + " WHERE fr.sender_id = $1 AND fr.recipient_id = $2 AND fr.status = 'pending'"
+
+	request, err := scanFriendRequest(f.db.QueryRowContext(ctx, query, senderId, recipientId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.FriendRequest{}, err
+		}
+		return models.FriendRequest{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return request, nil
+}
+
+// RespondToFriendRequest accepts or declines a pending request addressed to
+// recipientId. It returns sql.ErrNoRows when there is no such request.
+func (f *friendDaoImpl) RespondToFriendRequest(ctx context.Context, requestId uuid.UUID, recipientId uuid.UUID, accept bool) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	status := models.FriendRequestDeclined
+	if accept {
+		status = models.FriendRequestAccepted
+	}
+
+	var senderId uuid.UUID
+	err = tx.QueryRowContext(ctx, `UPDATE friend_requests SET status = $3, responded_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND recipient_id = $2 AND status = 'pending'
+		RETURNING sender_id`, requestId, recipientId, status).Scan(&senderId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return fmt.Errorf("error updating friend request: %v", err)
+	}
+
+	if accept {
+		_, err = tx.ExecContext(ctx, "INSERT INTO friendships (user_id, friend_id) VALUES ($1, $2), ($2, $1) ON CONFLICT DO NOTHING", senderId, recipientId)
+		if err != nil {
+			return fmt.Errorf("error creating friendship: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// ListFriendRequests returns the user's open requests, newest first.
+func (f *friendDaoImpl) ListFriendRequests(ctx context.Context, userId uuid.UUID) (models.FriendRequests, error) {
+	requests := models.FriendRequests{Incoming: []models.FriendRequest{}, Outgoing: []models.FriendRequest{}}
+
+	query := "SELECT " + friendRequestColumns + friendRequestFrom + `
+	WHERE (fr.sender_id = $1 OR fr.recipient_id = $1) AND fr.status = 'pending'
+	ORDER BY fr.created_at DESC
+	`
+
+	rows, err := f.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return models.FriendRequests{}, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		request, err := scanFriendRequest(rows)
+		if err != nil {
+			return models.FriendRequests{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		if request.RecipientId == userId {
+			requests.Incoming = append(requests.Incoming, request)
+		} else {
+			requests.Outgoing = append(requests.Outgoing, request)
+		}
+	}
+
+	return requests, rows.Err()
+}
+
+func (f *friendDaoImpl) ListFriends(ctx context.Context, userId uuid.UUID) ([]models.Friend, error) {
+	query := `
+	SELECT u.id, u.username, f.created_at
+	FROM friendships f
+	JOIN users u ON u.id = f.friend_id
+	WHERE f.user_id = $1
+	ORDER BY u.username
+	`
+
+	rows, err := f.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	friends := []models.Friend{}
+	for rows.Next() {
+		var friend models.Friend
+		if err := rows.Scan(&friend.UserId, &friend.UserName, &friend.Since); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		friends = append(friends, friend)
+	}
+
+	return friends, rows.Err()
+}
+
+func (f *friendDaoImpl) AreFriends(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	var friends bool
+	err := f.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM friendships WHERE user_id = $1 AND friend_id = $2)", userId, otherId).Scan(&friends)
+	if err != nil {
+		return false, fmt.Errorf("query execution error: %v", err)
+	}
+	return friends, nil
+}
+
+// RemoveFriend ends a friendship in both directions. It returns sql.ErrNoRows
+// when the two are not friends.
+func (f *friendDaoImpl) RemoveFriend(ctx context.Context, userId uuid.UUID, friendId uuid.UUID) error {
+	res, err := f.db.ExecContext(ctx, "DELETE FROM friendships WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)", userId, friendId)
+	if err != nil {
+		return fmt.Errorf("error removing friend: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// BlockUser blocks blockedId for blockerId, ending any friendship and
+// declining any open request between them.
+func (f *friendDaoImpl) BlockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", blockerId, blockedId)
+	if err != nil {
+		return fmt.Errorf("error blocking user: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM friendships WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)", blockerId, blockedId)
+	if err != nil {
+		return fmt.Errorf("error removing friend: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE friend_requests SET status = 'declined', responded_at = CURRENT_TIMESTAMP
+		WHERE status = 'pending' AND ((sender_id = $1 AND recipient_id = $2) OR (sender_id = $2 AND recipient_id = $1))`, blockerId, blockedId)
+	if err != nil {
+		return fmt.Errorf("error declining friend requests: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// UnblockUser lifts a block. It returns sql.ErrNoRows when there was none.
+func (f *friendDaoImpl) UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	res, err := f.db.ExecContext(ctx, "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerId, blockedId)
+	if err != nil {
+		return fmt.Errorf("error unblocking user: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (f *friendDaoImpl) IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	var blocked bool
+	err := f.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+	)`, userId, otherId).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("query execution error: %v", err)
+	}
+	return blocked, nil
+}
+
+// GetFriendsLeaderboard ranks the user and their friends by rating, alongside
+// their completed quizzes. Practice quizzes and quizzes flagged for cheating
+// do not count, and players with a flag that has not been cleared are left
+// out.
+func (f *friendDaoImpl) GetFriendsLeaderboard(ctx context.Context, userId uuid.UUID) ([]models.FriendStanding, error) {
+	query := `
+	WITH members AS (
+		SELECT friend_id AS id FROM friendships WHERE user_id = $1
+		UNION
+		SELECT $1
+	)
+	SELECT RANK() OVER (ORDER BY u.rating DESC), u.id, u.username, u.rating,
+		COUNT(z.id),
+		COALESCE(MAX(z.score), 0)
+	FROM members m
+	JOIN users u ON u.id = m.id
+	LEFT JOIN quiz z ON z.user_id = u.id AND z.completed_at IS NOT NULL AND z.mode <> 'practice' AND NOT ` + quizFlagged("z.id") + `
+	WHERE NOT ` + playerFlagged("u.id") + `
+	GROUP BY u.id
+	ORDER BY u.rating DESC, u.username
+	`
+
+	rows, err := f.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	standings := []models.FriendStanding{}
+	for rows.Next() {
+		var standing models.FriendStanding
+		err := rows.Scan(&standing.Rank, &standing.UserId, &standing.UserName, &standing.Rating, &standing.QuizzesCompleted, &standing.BestScore)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		standings = append(standings, standing)
+	}
+
+	return standings, rows.Err()
+}
+
+// GetFriendActivity lists quizzes the user's friends completed, newest first.
+// Blocks hide activity both ways, even if a friendship row lingers.
+func (f *friendDaoImpl) GetFriendActivity(ctx context.Context, userId uuid.UUID, limit int) ([]models.FriendActivity, error) {
+	query := `
+	SELECT u.id, u.username, z.id, COALESCE(p.title, ''), COALESCE(z.score, 0),
+		(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = z.id),
+		z.completed_at
+	FROM friendships f
+	JOIN users u ON u.id = f.friend_id
+	JOIN quiz z ON z.user_id = f.friend_id
+	LEFT JOIN packs p ON p.id = z.pack_id
+	WHERE f.user_id = $1 AND z.completed_at IS NOT NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = f.friend_id AND b.blocked_id = $1) OR (b.blocker_id = $1 AND b.blocked_id = f.friend_id)
+	)
+	ORDER BY z.completed_at DESC
+	LIMIT $2
+	`
+
+	rows, err := f.db.QueryContext(ctx, query, userId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	activity := []models.FriendActivity{}
+	for rows.Next() {
+		var item models.FriendActivity
+		err := rows.Scan(&item.UserId, &item.UserName, &item.QuizId, &item.PackTitle, &item.Score, &item.TotalQuestions, &item.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		activity = append(activity, item)
+	}
+
+	return activity, rows.Err()
+}
@@ -0,0 +1,316 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type GroupDao interface {
+	CreateGroup(ctx context.Context, group models.Group) (models.Group, error)
+	GetGroupById(ctx context.Context, groupId uuid.UUID) (models.Group, error)
+	GetGroupByInviteCode(ctx context.Context, inviteCode string) (models.Group, error)
+	ListUserGroups(ctx context.Context, userId uuid.UUID) ([]models.Group, error)
+	AddGroupMember(ctx context.Context, groupId uuid.UUID, userId uuid.UUID) error
+	IsGroupMember(ctx context.Context, groupId uuid.UUID, userId uuid.UUID) (bool, error)
+	ListGroupMembers(ctx context.Context, groupId uuid.UUID) ([]models.GroupMember, error)
+	CreateAssignment(ctx context.Context, assignment models.Assignment) (models.Assignment, error)
+	GetAssignmentById(ctx context.Context, assignmentId uuid.UUID) (models.Assignment, error)
+	ListGroupAssignments(ctx context.Context, groupId uuid.UUID) ([]models.Assignment, error)
+	ListOpenAssignments(ctx context.Context, userId uuid.UUID, now time.Time) ([]models.Assignment, error)
+	GetAssignmentReport(ctx context.Context, assignment models.Assignment, missedLimit int) (models.AssignmentReport, error)
+}
+
+type groupDaoImpl struct {
+	db *sql.DB
+}
+
+func NewGroupDao(db *sql.DB) GroupDao {
+	return &groupDaoImpl{
+		db: db,
+	}
+}
+
+const groupColumns = `g.id, g.name, g.owner_id, g.invite_code,
+	(SELECT COUNT(*) FROM group_members gm WHERE gm.group_id = g.id), g.created_at, g.updated_at`
+
+func scanGroup(row rowScanner) (models.Group, error) {
+	var group models.Group
+	err := row.Scan(&group.Id, &group.Name, &group.OwnerId, &group.InviteCode, &group.MemberCount, &group.CreatedAt, &group.UpdatedAt)
+	return group, err
+}
+
+const assignmentColumns = `a.id, a.group_id, g.name, a.title, a.pack_id, a.seed_quiz_id, a.due_at, a.created_at`
+
+func scanAssignment(row rowScanner) (models.Assignment, error) {
+	var assignment models.Assignment
+	err := row.Scan(&assignment.Id, &assignment.GroupId, &assignment.GroupName, &assignment.Title,
+		&assignment.PackId, &assignment.SeedQuizId, &assignment.DueAt, &assignment.CreatedAt)
+	return assignment, err
+}
+
+// CreateGroup inserts the group. It returns sql.ErrNoRows when the invite
+// code is already taken.
+func (g *groupDaoImpl) CreateGroup(ctx context.Context, input models.Group) (models.Group, error) {
+	var groupId uuid.UUID
+	err := g.db.QueryRowContext(ctx, "INSERT INTO groups (name, owner_id, invite_code) VALUES ($1, $2, $3) ON CONFLICT (invite_code) DO NOTHING RETURNING id",
+		input.Name, input.OwnerId, input.InviteCode).Scan(&groupId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Group{}, err
+		}
+		return models.Group{}, fmt.Errorf("error creating group: %v", err)
+	}
+
+	return g.GetGroupById(ctx, groupId)
+}
+
+func (g *groupDaoImpl) GetGroupById(ctx context.Context, groupId uuid.UUID) (models.Group, error) {
+	return g.getGroup(ctx, "g.id = $1", groupId)
+}
+
+func (g *groupDaoImpl) GetGroupByInviteCode(ctx context.Context, inviteCode string) (models.Group, error) {
+	return g.getGroup(ctx, "g.invite_code = $1", inviteCode)
+}
+
+func (g *groupDaoImpl) getGroup(ctx context.Context, where string, arg any) (models.Group, error) {
+	group, err := scanGroup(g.db.QueryRowContext(ctx, "SELECT "+groupColumns+" FROM groups g WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Group{}, err
+		}
+		return models.Group{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return group, nil
+}
+
+// ListUserGroups returns the groups the user owns or belongs to.
+func (g *groupDaoImpl) ListUserGroups(ctx context.Context, userId uuid.UUID) ([]models.Group, error) {
+	query := `
+	SELECT ` + groupColumns + `
+	FROM groups g
+	WHERE g.owner_id = $1 OR EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = g.id AND gm.user_id = $1)
+	ORDER BY g.name
+	`
+
+	rows, err := g.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (g *groupDaoImpl) AddGroupMember(ctx context.Context, groupId uuid.UUID, userId uuid.UUID) error {
+	_, err := g.db.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", groupId, userId)
+	if err != nil {
+		return fmt.Errorf("error adding group member: %v", err)
+	}
+	return nil
+}
+
+func (g *groupDaoImpl) IsGroupMember(ctx context.Context, groupId uuid.UUID, userId uuid.UUID) (bool, error) {
+	var member bool
+	err := g.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)", groupId, userId).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("query execution error: %v", err)
+	}
+	return member, nil
+}
+
+func (g *groupDaoImpl) ListGroupMembers(ctx context.Context, groupId uuid.UUID) ([]models.GroupMember, error) {
+	query := `
+	SELECT u.id, u.username, gm.joined_at
+	FROM group_members gm
+	JOIN users u ON u.id = gm.user_id
+	WHERE gm.group_id = $1
+	ORDER BY u.username
+	`
+
+	rows, err := g.db.QueryContext(ctx, query, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	members := []models.GroupMember{}
+	for rows.Next() {
+		var member models.GroupMember
+		if err := rows.Scan(&member.UserId, &member.UserName, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+func (g *groupDaoImpl) CreateAssignment(ctx context.Context, input models.Assignment) (models.Assignment, error) {
+	var assignmentId uuid.UUID
+	err := g.db.QueryRowContext(ctx, "INSERT INTO assignments (group_id, title, pack_id, seed_quiz_id, due_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		input.GroupId, input.Title, input.PackId, input.SeedQuizId, input.DueAt).Scan(&assignmentId)
+	if err != nil {
+		return models.Assignment{}, fmt.Errorf("error creating assignment: %v", err)
+	}
+
+	return g.GetAssignmentById(ctx, assignmentId)
+}
+
+func (g *groupDaoImpl) GetAssignmentById(ctx context.Context, assignmentId uuid.UUID) (models.Assignment, error) {
+	query := "SELECT " + assignmentColumns + " FROM assignments a JOIN groups g ON g.id = a.group_id WHERE a.id = $1"
+
+	assignment, err := scanAssignment(g.db.QueryRowContext(ctx, query, assignmentId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Assignment{}, err
+		}
+		return models.Assignment{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return assignment, nil
+}
+
+func (g *groupDaoImpl) ListGroupAssignments(ctx context.Context, groupId uuid.UUID) ([]models.Assignment, error) {
+	query := `
+	SELECT ` + assignmentColumns + `
+	FROM assignments a
+	JOIN groups g ON g.id = a.group_id
+	WHERE a.group_id = $1
+	ORDER BY a.due_at NULLS LAST, a.created_at
+	`
+	return g.queryAssignments(ctx, query, groupId)
+}
+
+// ListOpenAssignments returns assignments in the user's groups that are not
+// past due and that the user has not completed yet.
+func (g *groupDaoImpl) ListOpenAssignments(ctx context.Context, userId uuid.UUID, now time.Time) ([]models.Assignment, error) {
+	query := `
+	SELECT ` + assignmentColumns + `
+	FROM assignments a
+	JOIN groups g ON g.id = a.group_id
+	JOIN group_members gm ON gm.group_id = a.group_id AND gm.user_id = $1
+	WHERE (a.due_at IS NULL OR a.due_at > $2)
+	AND NOT EXISTS (
+		SELECT 1 FROM quiz z
+		WHERE z.assignment_id = a.id AND z.user_id = $1 AND z.completed_at IS NOT NULL
+	)
+	ORDER BY a.due_at NULLS LAST, a.created_at
+	`
+	return g.queryAssignments(ctx, query, userId, now)
+}
+
+func (g *groupDaoImpl) queryAssignments(ctx context.Context, query string, args ...any) ([]models.Assignment, error) {
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	assignments := []models.Assignment{}
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+// GetAssignmentReport reports on every member of the assignment's group. A
+// member's attempt is their latest completed quiz for the assignment, or
+// their latest unfinished one when none is complete.
+func (g *groupDaoImpl) GetAssignmentReport(ctx context.Context, assignment models.Assignment, missedLimit int) (models.AssignmentReport, error) {
+	report := models.AssignmentReport{
+		Assignment:     assignment,
+		Members:        []models.AssignmentMemberReport{},
+		CommonlyMissed: []models.MissedCity{},
+	}
+
+	membersQuery := `
+	SELECT u.id, u.username, z.id, z.completed_at, COALESCE(z.score, 0),
+		(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = z.id),
+		EXTRACT(EPOCH FROM (z.completed_at - z.created_at))::float8,
+		ARRAY(
+			SELECT q.city
+			FROM quiz_questions qq
+			JOIN questions q ON q.id = qq.question_id
+			WHERE qq.quiz_id = z.id AND NOT qq.is_correct
+			ORDER BY qq.order_number
+		)
+	FROM group_members gm
+	JOIN users u ON u.id = gm.user_id
+	LEFT JOIN LATERAL (
+		SELECT z.id, z.score, z.completed_at, z.created_at
+		FROM quiz z
+		WHERE z.user_id = gm.user_id AND z.assignment_id = $2
+		ORDER BY z.completed_at IS NULL, z.completed_at DESC, z.created_at DESC
+		LIMIT 1
+	) z ON TRUE
+	WHERE gm.group_id = $1
+	ORDER BY u.username
+	`
+
+	rows, err := g.db.QueryContext(ctx, membersQuery, assignment.GroupId, assignment.Id)
+	if err != nil {
+		return models.AssignmentReport{}, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.AssignmentMemberReport
+		err := rows.Scan(&member.UserId, &member.UserName, &member.QuizId, &member.CompletedAt, &member.Score,
+			&member.TotalQuestions, &member.TimeSeconds, pq.Array(&member.MissedCities))
+		if err != nil {
+			return models.AssignmentReport{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		member.Completed = member.CompletedAt != nil
+		report.Members = append(report.Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AssignmentReport{}, err
+	}
+
+	missedQuery := `
+	SELECT q.city, q.country, COUNT(*) AS misses
+	FROM quiz_questions qq
+	JOIN quiz z ON z.id = qq.quiz_id
+	JOIN group_members gm ON gm.user_id = z.user_id AND gm.group_id = $1
+	JOIN questions q ON q.id = qq.question_id
+	WHERE z.assignment_id = $2 AND NOT qq.is_correct
+	GROUP BY q.id, q.city, q.country
+	ORDER BY misses DESC, q.city
+	LIMIT $3
+	`
+
+	missedRows, err := g.db.QueryContext(ctx, missedQuery, assignment.GroupId, assignment.Id, missedLimit)
+	if err != nil {
+		return models.AssignmentReport{}, fmt.Errorf("query execution error: %v", err)
+	}
+	defer missedRows.Close()
+
+	for missedRows.Next() {
+		var missed models.MissedCity
+		if err := missedRows.Scan(&missed.City, &missed.Country, &missed.Misses); err != nil {
+			return models.AssignmentReport{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		report.CommonlyMissed = append(report.CommonlyMissed, missed)
+	}
+
+	return report, missedRows.Err()
+}
@@ -0,0 +1,36 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type HealthDao interface {
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (int64, error)
+}
+
+type healthDaoImpl struct {
+	db *sql.DB
+}
+
+func NewHealthDao(db *sql.DB) HealthDao {
+	return &healthDaoImpl{
+		db: db,
+	}
+}
+
+func (h *healthDaoImpl) Ping(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+// GetMigrationVersion returns the newest migration goose has applied.
+func (h *healthDaoImpl) GetMigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := h.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("query execution error: %v", err)
+	}
+	return version, nil
+}
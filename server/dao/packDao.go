@@ -0,0 +1,250 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PackDao interface {
+	CreatePack(ctx context.Context, pack models.Pack) (models.Pack, error)
+	UpdatePack(ctx context.Context, pack models.Pack) (models.Pack, error)
+	DeletePack(ctx context.Context, packId uuid.UUID) error
+	GetPackById(ctx context.Context, packId uuid.UUID) (models.Pack, error)
+	ListPacks(ctx context.Context) ([]models.Pack, error)
+	FindUnplayableQuestionIds(ctx context.Context, questionIds []uuid.UUID) (missing []uuid.UUID, unpublished []uuid.UUID, err error)
+	GetPackHighScores(ctx context.Context, packId uuid.UUID, limit int) ([]models.PackHighScore, error)
+}
+
+type packDaoImpl struct {
+	db *sql.DB
+}
+
+func NewPackDao(db *sql.DB) PackDao {
+	return &packDaoImpl{
+		db: db,
+	}
+}
+
+// packColumns is the column list scanned by scanPack, selected from the packs
+// table aliased as p. Question ids come back in pack order.
+const packColumns = `p.id, p.title, COALESCE(p.description, ''), COALESCE(p.cover_image_url, ''), COALESCE(p.cover_color, ''),
+	ARRAY(SELECT pq.question_id FROM pack_questions pq WHERE pq.pack_id = p.id ORDER BY pq.position),
+	p.created_at, p.updated_at`
+
+func scanPack(row rowScanner) (models.Pack, error) {
+	var pack models.Pack
+	err := row.Scan(
+		&pack.Id,
+		&pack.Title,
+		&pack.Description,
+		&pack.CoverImageUrl,
+		&pack.CoverColor,
+		pq.Array(&pack.QuestionIds),
+		&pack.CreatedAt,
+		&pack.UpdatedAt,
+	)
+	return pack, err
+}
+
+func (p *packDaoImpl) CreatePack(ctx context.Context, pack models.Pack) (models.Pack, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Pack{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var packId uuid.UUID
+	err = tx.QueryRowContext(ctx, "INSERT INTO packs (title, description, cover_image_url, cover_color) VALUES ($1, $2, $3, $4) RETURNING id",
+		pack.Title, pack.Description, pack.CoverImageUrl, pack.CoverColor).Scan(&packId)
+	if err != nil {
+		return models.Pack{}, fmt.Errorf("error inserting pack: %v", err)
+	}
+
+	if err := insertPackQuestions(ctx, tx, packId, pack.QuestionIds); err != nil {
+		return models.Pack{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Pack{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return p.GetPackById(ctx, packId)
+}
+
+func (p *packDaoImpl) UpdatePack(ctx context.Context, pack models.Pack) (models.Pack, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Pack{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE packs SET title = $2, description = $3, cover_image_url = $4, cover_color = $5, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		pack.Id, pack.Title, pack.Description, pack.CoverImageUrl, pack.CoverColor)
+	if err != nil {
+		return models.Pack{}, fmt.Errorf("error updating pack: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.Pack{}, sql.ErrNoRows
+	}
+
+	// The question list is replaced wholesale so positions stay contiguous.
+	_, err = tx.ExecContext(ctx, "DELETE FROM pack_questions WHERE pack_id = $1", pack.Id)
+	if err != nil {
+		return models.Pack{}, fmt.Errorf("error clearing pack questions: %v", err)
+	}
+
+	if err := insertPackQuestions(ctx, tx, *pack.Id, pack.QuestionIds); err != nil {
+		return models.Pack{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Pack{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return p.GetPackById(ctx, *pack.Id)
+}
+
+func insertPackQuestions(ctx context.Context, tx *sql.Tx, packId uuid.UUID, questionIds []uuid.UUID) error {
+	query := `
+	INSERT INTO pack_questions (pack_id, question_id, position)
+	SELECT $1, ids.question_id, ids.position
+	FROM unnest($2::uuid[]) WITH ORDINALITY AS ids(question_id, position)
+	`
+
+	_, err := tx.ExecContext(ctx, query, packId, pq.Array(questionIds))
+	if err != nil {
+		return fmt.Errorf("error inserting pack questions: %v", err)
+	}
+	return nil
+}
+
+func (p *packDaoImpl) DeletePack(ctx context.Context, packId uuid.UUID) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM packs WHERE id = $1", packId)
+	if err != nil {
+		return fmt.Errorf("query execution error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (p *packDaoImpl) GetPackById(ctx context.Context, packId uuid.UUID) (models.Pack, error) {
+	query := `
+	SELECT ` + packColumns + `
+	FROM packs p
+	WHERE p.id = $1
+	`
+
+	pack, err := scanPack(p.db.QueryRowContext(ctx, query, packId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Pack{}, err
+		}
+		return models.Pack{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return pack, nil
+}
+
+func (p *packDaoImpl) ListPacks(ctx context.Context) ([]models.Pack, error) {
+	packs := []models.Pack{}
+	query := `
+	SELECT ` + packColumns + `
+	FROM packs p
+	ORDER BY p.created_at
+	`
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pack, err := scanPack(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		packs = append(packs, pack)
+	}
+
+	return packs, rows.Err()
+}
+
+// FindUnplayableQuestionIds returns the ids among questionIds that no
+// question has, and those of questions that exist but are not published or
+// have been deleted, which pack quizzes never serve.
+func (p *packDaoImpl) FindUnplayableQuestionIds(ctx context.Context, questionIds []uuid.UUID) ([]uuid.UUID, []uuid.UUID, error) {
+	var missing, unpublished []uuid.UUID
+	query := `
+	SELECT ids.id, q.id IS NULL
+	FROM unnest($1::uuid[]) AS ids(id)
+	LEFT JOIN questions q ON q.id = ids.id
+	WHERE q.id IS NULL OR NOT (` + publishedClause + `)
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, pq.Array(questionIds))
+	if err != nil {
+		return nil, nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var isMissing bool
+		if err := rows.Scan(&id, &isMissing); err != nil {
+			return nil, nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		if isMissing {
+			missing = append(missing, id)
+		} else {
+			unpublished = append(unpublished, id)
+		}
+	}
+
+	return missing, unpublished, rows.Err()
+}
+
+// GetPackHighScores returns each player's best quiz on the pack, highest
+// score first. Ties go to whoever got there first. Quizzes flagged for
+// cheating are left out until an admin clears them.
+func (p *packDaoImpl) GetPackHighScores(ctx context.Context, packId uuid.UUID, limit int) ([]models.PackHighScore, error) {
+	scores := []models.PackHighScore{}
+	query := `
+	SELECT best.username, best.quiz_id, best.score, best.total_questions, best.achieved_at
+	FROM (
+		SELECT u.username, q.id AS quiz_id, q.score, q.updated_at AS achieved_at,
+			(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = q.id) AS total_questions,
+			ROW_NUMBER() OVER (PARTITION BY q.user_id ORDER BY q.score DESC, q.updated_at ASC) AS rank
+		FROM quiz q
+		JOIN users u ON q.user_id = u.id
+		WHERE q.pack_id = $1 AND NOT ` + quizFlagged("q.id") + `
+	) best
+	WHERE best.rank = 1
+	ORDER BY best.score DESC, best.achieved_at ASC
+	LIMIT $2
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, packId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score models.PackHighScore
+		err := rows.Scan(&score.UserName, &score.QuizId, &score.Score, &score.TotalQuestions, &score.AchievedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		scores = append(scores, score)
+	}
+
+	return scores, rows.Err()
+}
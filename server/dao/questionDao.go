@@ -0,0 +1,479 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type QuestionDao interface {
+	CreateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error)
+	UpdateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error)
+	SetQuestionStatus(ctx context.Context, questionId uuid.UUID, from string, to string, actor string) (models.AdminQuestion, error)
+	DeleteQuestion(ctx context.Context, questionId uuid.UUID, actor string) error
+	GetAdminQuestionById(ctx context.Context, questionId uuid.UUID) (models.AdminQuestion, error)
+	ListQuestions(ctx context.Context, filter models.QuestionListFilter) ([]models.AdminQuestion, error)
+	ListQuestionRevisions(ctx context.Context, questionId uuid.UUID) ([]models.QuestionRevision, error)
+	ListQuestionTranslations(ctx context.Context, questionId uuid.UUID) ([]models.QuestionTranslation, error)
+	GetQuestionTranslation(ctx context.Context, revisionId uuid.UUID, locales []string) (models.QuestionTranslation, error)
+	UpsertQuestionTranslation(ctx context.Context, translation models.QuestionTranslation, actor string) (models.QuestionTranslation, error)
+	DeleteQuestionTranslation(ctx context.Context, questionId uuid.UUID, locale string, actor string) error
+}
+
+type questionDaoImpl struct {
+	db *sql.DB
+}
+
+func NewQuestionDao(db *sql.DB) QuestionDao {
+	return &questionDaoImpl{
+		db: db,
+	}
+}
+
+const adminQuestionColumns = questionColumns + `,
+	q.status, COALESCE(q.created_by, ''), COALESCE(q.updated_by, ''), COALESCE(q.reviewed_by, ''), q.reviewed_at, q.deleted_at,
+	q.rating, q.rated_games`
+
+func scanAdminQuestion(row rowScanner) (models.AdminQuestion, error) {
+	var question models.AdminQuestion
+	dest := append(questionScanDest(&question.Question),
+		&question.Status,
+		&question.CreatedBy,
+		&question.UpdatedBy,
+		&question.ReviewedBy,
+		&question.ReviewedAt,
+		&question.DeletedAt,
+		&question.Rating,
+		&question.RatedGames,
+	)
+	err := row.Scan(dest...)
+	return question, err
+}
+
+func (d *questionDaoImpl) CreateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO questions (city, country, continent, region, clues, fun_fact, trivia, options, correct_answer, created_by, updated_by)
+	VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $10)
+	RETURNING id
+	`
+
+	var questionId uuid.UUID
+	err = tx.QueryRowContext(ctx, query, question.City, question.Country, question.Continent, question.Region,
+		pq.Array(question.Clues), pq.Array(question.FunFact), pq.Array(question.Trivia), pq.Array(question.Options),
+		question.CorrectAnswer, actor).Scan(&questionId)
+	if err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error inserting question: %v", err)
+	}
+
+	if err := replaceQuestionTags(ctx, tx, questionId, question.Tags); err != nil {
+		return models.AdminQuestion{}, err
+	}
+
+	if err := snapshotQuestion(ctx, tx, questionId, actor); err != nil {
+		return models.AdminQuestion{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return d.GetAdminQuestionById(ctx, questionId)
+}
+
+// UpdateQuestion saves a question's content as a new revision. A published
+// question whose player-facing content changes goes back to in_review, so
+// the change is not served until it has been reviewed and published again.
+func (d *questionDaoImpl) UpdateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE questions
+	SET city = $2, country = $3, continent = NULLIF($4, ''), region = NULLIF($5, ''),
+		clues = $6, fun_fact = $7, trivia = $8, options = $9, correct_answer = $10,
+		updated_by = $11, updated_at = CURRENT_TIMESTAMP,
+		status = CASE
+			WHEN status = 'published' AND (city, country, clues, fun_fact, trivia, options, correct_answer)
+				IS DISTINCT FROM ($2::varchar, $3::varchar, $6::text[], $7::text[], $8::text[], $9::text[], $10::int)
+			THEN 'in_review' ELSE status END
+	WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	res, err := tx.ExecContext(ctx, query, question.Id, question.City, question.Country, question.Continent, question.Region,
+		pq.Array(question.Clues), pq.Array(question.FunFact), pq.Array(question.Trivia), pq.Array(question.Options),
+		question.CorrectAnswer, actor)
+	if err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error updating question: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.AdminQuestion{}, sql.ErrNoRows
+	}
+
+	if err := replaceQuestionTags(ctx, tx, *question.Id, question.Tags); err != nil {
+		return models.AdminQuestion{}, err
+	}
+
+	if err := snapshotQuestion(ctx, tx, *question.Id, actor); err != nil {
+		return models.AdminQuestion{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return d.GetAdminQuestionById(ctx, *question.Id)
+}
+
+// snapshotQuestion stores the question's current content and translations as
+// its next revision and makes that revision current.
+func snapshotQuestion(ctx context.Context, tx *sql.Tx, questionId uuid.UUID, actor string) error {
+	query := `
+	INSERT INTO question_revisions (question_id, revision_number, city, country, clues, fun_fact, trivia, options, correct_answer, created_by)
+	SELECT q.id,
+		COALESCE((SELECT MAX(r.revision_number) FROM question_revisions r WHERE r.question_id = q.id), 0) + 1,
+		q.city, q.country, q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, $2
+	FROM questions q
+	WHERE q.id = $1
+	RETURNING id
+	`
+
+	var revisionId uuid.UUID
+	if err := tx.QueryRowContext(ctx, query, questionId, actor).Scan(&revisionId); err != nil {
+		return fmt.Errorf("error inserting question revision: %v", err)
+	}
+
+	translationQuery := `
+	INSERT INTO question_revision_translations (revision_id, question_id, locale, city, country, clues, fun_fact, trivia, options, created_at, updated_at)
+	SELECT $2, t.question_id, t.locale, t.city, t.country, t.clues, t.fun_fact, t.trivia, t.options, t.created_at, t.updated_at
+	FROM question_translations t
+	WHERE t.question_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, translationQuery, questionId, revisionId); err != nil {
+		return fmt.Errorf("error inserting revision translations: %v", err)
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE questions SET current_revision_id = $2 WHERE id = $1", questionId, revisionId)
+	if err != nil {
+		return fmt.Errorf("error updating current revision: %v", err)
+	}
+	return nil
+}
+
+// returnToReview moves a published question back to in_review after its
+// translations change, so the change is reviewed before it is served.
+func returnToReview(ctx context.Context, tx *sql.Tx, questionId uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, "UPDATE questions SET status = 'in_review' WHERE id = $1 AND status = 'published'", questionId)
+	if err != nil {
+		return fmt.Errorf("error returning question to review: %v", err)
+	}
+	return nil
+}
+
+// SetQuestionStatus moves a question from one lifecycle state to another. It
+// returns sql.ErrNoRows when the question is gone or no longer in from, so a
+// concurrent transition cannot be overwritten. Review decisions record the
+// reviewer.
+func (d *questionDaoImpl) SetQuestionStatus(ctx context.Context, questionId uuid.UUID, from string, to string, actor string) (models.AdminQuestion, error) {
+	query := `
+	UPDATE questions
+	SET status = $3, updated_by = $4, updated_at = CURRENT_TIMESTAMP,
+		reviewed_by = CASE WHEN $2 = 'in_review' THEN $4 ELSE reviewed_by END,
+		reviewed_at = CASE WHEN $2 = 'in_review' THEN CURRENT_TIMESTAMP ELSE reviewed_at END
+	WHERE id = $1 AND status = $2 AND deleted_at IS NULL
+	`
+
+	res, err := d.db.ExecContext(ctx, query, questionId, from, to, actor)
+	if err != nil {
+		return models.AdminQuestion{}, fmt.Errorf("error updating question status: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.AdminQuestion{}, sql.ErrNoRows
+	}
+
+	return d.GetAdminQuestionById(ctx, questionId)
+}
+
+func (d *questionDaoImpl) DeleteQuestion(ctx context.Context, questionId uuid.UUID, actor string) error {
+	query := `
+	UPDATE questions
+	SET status = 'retired', deleted_at = CURRENT_TIMESTAMP, updated_by = $2, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	res, err := d.db.ExecContext(ctx, query, questionId, actor)
+	if err != nil {
+		return fmt.Errorf("query execution error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (d *questionDaoImpl) GetAdminQuestionById(ctx context.Context, questionId uuid.UUID) (models.AdminQuestion, error) {
+	query := `
+	SELECT ` + adminQuestionColumns + `
+	FROM questions q
+	WHERE q.id = $1
+	`
+
+	question, err := scanAdminQuestion(d.db.QueryRowContext(ctx, query, questionId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.AdminQuestion{}, err
+		}
+		return models.AdminQuestion{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return question, nil
+}
+
+func (d *questionDaoImpl) ListQuestions(ctx context.Context, filter models.QuestionListFilter) ([]models.AdminQuestion, error) {
+	questions := []models.AdminQuestion{}
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "q.deleted_at IS NULL")
+	}
+	if filter.Status != "" {
+		addCondition("q.status = $%d", filter.Status)
+	}
+	if filter.Country != "" {
+		addCondition("lower(q.country) = lower($%d)", filter.Country)
+	}
+	if filter.Continent != "" {
+		addCondition("lower(q.continent) = lower($%d)", filter.Continent)
+	}
+	if filter.Tag != "" {
+		addCondition("EXISTS (SELECT 1 FROM question_tags qt JOIN tags t ON qt.tag_id = t.id WHERE qt.question_id = q.id AND lower(t.name) = lower($%d))", filter.Tag)
+	}
+	if filter.Search != "" {
+		addCondition("q.city ILIKE '%%' || $%d || '%%'", filter.Search)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `
+	SELECT ` + adminQuestionColumns + `
+	FROM questions q
+	` + where + `
+	ORDER BY q.updated_at DESC, q.id
+	LIMIT $` + fmt.Sprint(len(args)-1) + ` OFFSET $` + fmt.Sprint(len(args))
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		question, err := scanAdminQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		questions = append(questions, question)
+	}
+
+	return questions, rows.Err()
+}
+
+func (d *questionDaoImpl) ListQuestionRevisions(ctx context.Context, questionId uuid.UUID) ([]models.QuestionRevision, error) {
+	revisions := []models.QuestionRevision{}
+	query := `
+	SELECT r.id, r.question_id, r.revision_number, r.city, r.country, r.clues, r.fun_fact, r.trivia, r.options,
+		r.correct_answer, COALESCE(r.created_by, ''), r.created_at
+	FROM question_revisions r
+	WHERE r.question_id = $1
+	ORDER BY r.revision_number
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, questionId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.QuestionRevision
+		err := rows.Scan(
+			&revision.Id,
+			&revision.QuestionId,
+			&revision.RevisionNumber,
+			&revision.City,
+			&revision.Country,
+			pq.Array(&revision.Clues),
+			pq.Array(&revision.FunFact),
+			pq.Array(&revision.Trivia),
+			pq.Array(&revision.Options),
+			&revision.CorrectAnswer,
+			&revision.CreatedBy,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+const translationColumns = `t.question_id, t.locale, COALESCE(t.city, ''), COALESCE(t.country, ''),
+	COALESCE(t.clues, '{}'), COALESCE(t.fun_fact, '{}'), COALESCE(t.trivia, '{}'), COALESCE(t.options, '{}'),
+	t.created_at, t.updated_at`
+
+func scanTranslation(row rowScanner) (models.QuestionTranslation, error) {
+	var translation models.QuestionTranslation
+	err := row.Scan(
+		&translation.QuestionId,
+		&translation.Locale,
+		&translation.City,
+		&translation.Country,
+		pq.Array(&translation.Clues),
+		pq.Array(&translation.FunFact),
+		pq.Array(&translation.Trivia),
+		pq.Array(&translation.Options),
+		&translation.CreatedAt,
+		&translation.UpdatedAt,
+	)
+	return translation, err
+}
+
+func (d *questionDaoImpl) ListQuestionTranslations(ctx context.Context, questionId uuid.UUID) ([]models.QuestionTranslation, error) {
+	translations := []models.QuestionTranslation{}
+	query := `
+	SELECT ` + translationColumns + `
+	FROM question_translations t
+	WHERE t.question_id = $1
+	ORDER BY t.locale
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, questionId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+// GetQuestionTranslation returns the revision's translation for the first of
+// locales that has one. Locales are matched case-insensitively and should be
+// given lower-cased.
+func (d *questionDaoImpl) GetQuestionTranslation(ctx context.Context, revisionId uuid.UUID, locales []string) (models.QuestionTranslation, error) {
+	query := `
+	SELECT ` + translationColumns + `
+	FROM question_revision_translations t
+	WHERE t.revision_id = $1 AND lower(t.locale) = ANY($2::text[])
+	ORDER BY array_position($2::text[], lower(t.locale)::text)
+	LIMIT 1
+	`
+
+	translation, err := scanTranslation(d.db.QueryRowContext(ctx, query, revisionId, pq.Array(locales)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.QuestionTranslation{}, err
+		}
+		return models.QuestionTranslation{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return translation, nil
+}
+
+// UpsertQuestionTranslation saves a translation and, like a content edit,
+// snapshots the question as a new revision, since revisions are never
+// changed once served. A published question goes back to in_review.
+func (d *questionDaoImpl) UpsertQuestionTranslation(ctx context.Context, translation models.QuestionTranslation, actor string) (models.QuestionTranslation, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.QuestionTranslation{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO question_translations AS t (question_id, locale, city, country, clues, fun_fact, trivia, options)
+	VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8)
+	ON CONFLICT (question_id, locale) DO UPDATE
+	SET city = EXCLUDED.city, country = EXCLUDED.country, clues = EXCLUDED.clues, fun_fact = EXCLUDED.fun_fact,
+		trivia = EXCLUDED.trivia, options = EXCLUDED.options, updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + translationColumns
+
+	res, err := scanTranslation(tx.QueryRowContext(ctx, query, translation.QuestionId, translation.Locale, translation.City, translation.Country,
+		pq.Array(translation.Clues), pq.Array(translation.FunFact), pq.Array(translation.Trivia), pq.Array(translation.Options)))
+	if err != nil {
+		return models.QuestionTranslation{}, fmt.Errorf("error saving translation: %v", err)
+	}
+
+	if err := returnToReview(ctx, tx, translation.QuestionId); err != nil {
+		return models.QuestionTranslation{}, err
+	}
+	if err := snapshotQuestion(ctx, tx, translation.QuestionId, actor); err != nil {
+		return models.QuestionTranslation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.QuestionTranslation{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return res, nil
+}
+
+func (d *questionDaoImpl) DeleteQuestionTranslation(ctx context.Context, questionId uuid.UUID, locale string, actor string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM question_translations WHERE question_id = $1 AND lower(locale) = lower($2)", questionId, locale)
+	if err != nil {
+		return fmt.Errorf("query execution error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := returnToReview(ctx, tx, questionId); err != nil {
+		return err
+	}
+	if err := snapshotQuestion(ctx, tx, questionId, actor); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
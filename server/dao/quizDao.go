@@ -1,24 +1,90 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lib/pq" // Make sure to import this package
 
 	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/rating"
 	"github.com/google/uuid"
 )
 
+// ErrQuestionAnswered is returned when an answer claims an issued question
+// that was never issued at that position or has already been answered.
+var ErrQuestionAnswered = errors.New("question has already been answered")
+
 type QuizDao interface {
-	GetQuizQuestion(quizId uuid.UUID) (models.Question, error)
-	GetQuizQuestionByOrder(quizId uuid.UUID, orderNumber int) (models.Question, error)
-	CreateQuiz(quiz models.User) (models.Quiz, error)
-	SaveQuizAnswer(input models.QuizAnswerInput) (models.QuizAnswerResponse, error)
-	GetQuestionById(questionId uuid.UUID) (models.Question, error)
-	ListQuizByUserName(userName string) ([]models.Quiz, error)
-	GetQuizById(quizId uuid.UUID) (models.Quiz, error)
-	GetAllQuestionsByQuizId(quizId uuid.UUID) ([]models.Question, error)
+	GetQuizQuestion(ctx context.Context, quizId uuid.UUID, includeTags []string, excludeTags []string, band rating.Band) (models.Question, error)
+	GetQuizQuestionByOrder(ctx context.Context, quizId uuid.UUID, orderNumber int) (models.Question, error)
+	GetPackQuizQuestion(ctx context.Context, quizId uuid.UUID, packId uuid.UUID, shuffle bool) (models.Question, error)
+	CreateQuiz(ctx context.Context, quiz models.Quiz) (models.Quiz, error)
+	SaveQuizAnswer(ctx context.Context, input models.QuizAnswerInput, position int) (models.QuizAnswerResponse, error)
+	GetQuestionById(ctx context.Context, questionId uuid.UUID) (models.Question, error)
+	RecordIssuedQuestion(ctx context.Context, quizId uuid.UUID, question models.Question) (int, time.Time, error)
+	ListQuizByUsernameKey(ctx context.Context, usernameKey string) ([]models.Quiz, error)
+	GetQuizById(ctx context.Context, quizId uuid.UUID) (models.Quiz, error)
+	GetAllQuestionsByQuizId(ctx context.Context, quizId uuid.UUID) ([]models.Question, error)
+	SetQuizChallenge(ctx context.Context, quizId uuid.UUID, challengeQuizId uuid.UUID) error
+	MarkQuizCompleted(ctx context.Context, quizId uuid.UUID) (bool, error)
+}
+
+// questionTagColumns are the continent, region and tags of the question
+// aliased as q.
+const questionTagColumns = `COALESCE(q.continent, ''), COALESCE(q.region, ''),
+	ARRAY(SELECT t.name FROM question_tags qt JOIN tags t ON qt.tag_id = t.id WHERE qt.question_id = q.id ORDER BY t.name)`
+
+// questionColumns is the column list scanned by scanQuestion, selected from
+// the questions table aliased as q.
+const questionColumns = `q.id, q.city, q.country, ` + questionTagColumns + `,
+	q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, q.current_revision_id, q.created_at, q.updated_at`
+
+// revisionQuestionColumns is scanned by scanQuestion like questionColumns, but
+// takes the player-facing content from the revision aliased as r, which is
+// left joined: questions inserted outside the API may not have one yet, and
+// then their own columns are used.
+var revisionQuestionColumns = `q.id, ` + revisionColumn("city") + `, ` + revisionColumn("country") + `, ` + questionTagColumns + `,
+	` + revisionColumn("clues") + `, ` + revisionColumn("fun_fact") + `, ` + revisionColumn("trivia") + `,
+	` + revisionColumn("options") + `, ` + revisionColumn("correct_answer") + `, r.id, q.created_at, COALESCE(r.created_at, q.updated_at)`
+
+// revisionColumn is col of the revision aliased as r, or of the question
+// aliased as q when there is no revision. It does not COALESCE, since a
+// revision's NULL clues or fun facts are content too.
+func revisionColumn(col string) string {
+	return "CASE WHEN r.id IS NULL THEN q." + col + " ELSE r." + col + " END"
+}
+
+// questionTermsExpr lists everything a tag filter can match on for the
+// question aliased as q: its continent, its region and its free-form tags,
+// all lower-cased.
+const questionTermsExpr = `(ARRAY[lower(COALESCE(q.continent, '')), lower(COALESCE(q.region, ''))] ||
+	ARRAY(SELECT lower(t.name) FROM question_tags qt JOIN tags t ON qt.tag_id = t.id WHERE qt.question_id = q.id))`
+
+// publishedClause restricts the questions table aliased as q to questions
+// that may be handed out to players.
+const publishedClause = `q.status = 'published' AND q.deleted_at IS NULL`
+
+// quizColumns is the column list scanned by quizScanDest, selected from the
+// quiz table aliased as q.
+const quizColumns = `q.id, q.user_id, q.mode, q.pack_id, COALESCE(q.shuffle, FALSE), COALESCE(q.include_tags, '{}'), COALESCE(q.exclude_tags, '{}'),
+	q.score, q.challenge_quiz_id, q.assignment_id, q.question_limit, COALESCE(q.difficulty, ''), q.timer_seconds, q.completed_at, q.created_at, q.updated_at`
+
+func quizScanDest(quiz *models.Quiz) []any {
+	return []any{
+		&quiz.Id, &quiz.UserId, &quiz.Mode, &quiz.PackId, &quiz.Shuffle, pq.Array(&quiz.IncludeTags), pq.Array(&quiz.ExcludeTags),
+		&quiz.Score, &quiz.ChallengeQuizId, &quiz.AssignmentId, &quiz.QuestionLimit, &quiz.Difficulty, &quiz.TimerSeconds,
+		&quiz.CompletedAt, &quiz.CreatedAt, &quiz.UpdatedAt,
+	}
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
 type quizDaoImpl struct {
@@ -31,33 +97,27 @@ func NewQuizDao(db *sql.DB) QuizDao {
 	}
 }
 
-func (u *quizDaoImpl) GetQuizQuestion(quizId uuid.UUID) (models.Question, error) {
-	var question models.Question
+// GetQuizQuestion picks a random unanswered question rated within band. When
+// includeTags is non-empty the question must match at least one of them; it
+// must match none of excludeTags. Tags are compared case-insensitively.
+func (u *quizDaoImpl) GetQuizQuestion(ctx context.Context, quizId uuid.UUID, includeTags []string, excludeTags []string, band rating.Band) (models.Question, error) {
 	query := `
-	SELECT q.id, q.city, q.country, q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, q.created_at, q.updated_at
+	SELECT ` + questionColumns + `
 	FROM questions q
 	WHERE q.id NOT IN (
 		SELECT qq.question_id
 		FROM quiz_questions qq
 		WHERE qq.quiz_id = $1
 	)
+	AND (cardinality($2::text[]) = 0 OR ` + questionTermsExpr + ` && $2::text[])
+	AND NOT (` + questionTermsExpr + ` && $3::text[])
+	AND q.rating >= $4 AND q.rating < $5
+	AND ` + publishedClause + `
 	ORDER BY RANDOM()
 	LIMIT 1
 	`
 
-	// Use pq.Array to scan directly into string slices
-	err := u.db.QueryRow(query, quizId).Scan(
-		&question.Id,
-		&question.City,
-		&question.Country,
-		pq.Array(&question.Clues),
-		pq.Array(&question.FunFact),
-		pq.Array(&question.Trivia),
-		pq.Array(&question.Options),
-		&question.CorrectAnswer,
-		&question.CreatedAt,
-		&question.UpdatedAt,
-	)
+	question, err := scanQuestion(u.db.QueryRowContext(ctx, query, quizId, pq.Array(lowerAll(includeTags)), pq.Array(lowerAll(excludeTags)), band.Min, band.Max))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Question{}, nil
@@ -68,29 +128,48 @@ func (u *quizDaoImpl) GetQuizQuestion(quizId uuid.UUID) (models.Question, error)
 	return question, nil
 }
 
-func (u *quizDaoImpl) GetQuizQuestionByOrder(quizId uuid.UUID, orderNumber int) (models.Question, error) {
-	var question models.Question
+// GetQuizQuestionByOrder returns the question answered at orderNumber in the
+// quiz, exactly as that player saw it.
+func (u *quizDaoImpl) GetQuizQuestionByOrder(ctx context.Context, quizId uuid.UUID, orderNumber int) (models.Question, error) {
 	query := `
-	SELECT q.id, q.city, q.country, q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, q.created_at, q.updated_at
+	SELECT ` + revisionQuestionColumns + `
 	FROM questions q
 	JOIN quiz_questions qq ON q.id = qq.question_id
+	LEFT JOIN question_revisions r ON r.id = COALESCE(qq.revision_id, q.current_revision_id)
 	WHERE qq.quiz_id = $1 AND qq.order_number = $2
 	`
 
-	// Use pq.Array to scan directly into string slices
-	err := u.db.QueryRow(query, quizId, orderNumber).Scan(
-		&question.Id,
-		&question.City,
-		&question.Country,
-		pq.Array(&question.Clues),
-		pq.Array(&question.FunFact),
-		pq.Array(&question.Trivia),
-		pq.Array(&question.Options),
-		&question.CorrectAnswer,
-		&question.CreatedAt,
-		&question.UpdatedAt,
+	question, err := scanQuestion(u.db.QueryRowContext(ctx, query, quizId, orderNumber))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Question{}, nil
+		}
+		return models.Question{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return question, nil
+}
+
+func (u *quizDaoImpl) GetPackQuizQuestion(ctx context.Context, quizId uuid.UUID, packId uuid.UUID, shuffle bool) (models.Question, error) {
+	orderBy := "pq.position"
+	if shuffle {
+		orderBy = "RANDOM()"
+	}
+
+	query := `
+	SELECT ` + questionColumns + `
+	FROM questions q
+	JOIN pack_questions pq ON q.id = pq.question_id
+	WHERE pq.pack_id = $2 AND ` + publishedClause + ` AND q.id NOT IN (
+		SELECT qq.question_id
+		FROM quiz_questions qq
+		WHERE qq.quiz_id = $1
 	)
+	ORDER BY ` + orderBy + `
+	LIMIT 1
+	`
 
+	question, err := scanQuestion(u.db.QueryRowContext(ctx, query, quizId, packId))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Question{}, nil
@@ -101,27 +180,14 @@ func (u *quizDaoImpl) GetQuizQuestionByOrder(quizId uuid.UUID, orderNumber int)
 	return question, nil
 }
 
-func (u *quizDaoImpl) GetQuestionById(questionId uuid.UUID) (models.Question, error) {
-	var question models.Question
+func (u *quizDaoImpl) GetQuestionById(ctx context.Context, questionId uuid.UUID) (models.Question, error) {
 	query := `
-	SELECT q.id, q.city, q.country, q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, q.created_at, q.updated_at
+	SELECT ` + questionColumns + `
 	FROM questions q
 	WHERE q.id = $1
 	`
 
-	// Use pq.Array to scan directly into string slices
-	err := u.db.QueryRow(query, questionId).Scan(
-		&question.Id,
-		&question.City,
-		&question.Country,
-		pq.Array(&question.Clues),
-		pq.Array(&question.FunFact),
-		pq.Array(&question.Trivia),
-		pq.Array(&question.Options),
-		&question.CorrectAnswer,
-		&question.CreatedAt,
-		&question.UpdatedAt,
-	)
+	question, err := scanQuestion(u.db.QueryRowContext(ctx, query, questionId))
 	if err != nil {
 		return models.Question{}, fmt.Errorf("query execution error: %v", err)
 	}
@@ -129,10 +195,15 @@ func (u *quizDaoImpl) GetQuestionById(questionId uuid.UUID) (models.Question, er
 	return question, nil
 }
 
-func (u *quizDaoImpl) CreateQuiz(user models.User) (models.Quiz, error) {
-	var quiz models.Quiz
+func (u *quizDaoImpl) CreateQuiz(ctx context.Context, input models.Quiz) (models.Quiz, error) {
+	query := `
+	INSERT INTO quiz AS q (user_id, mode, pack_id, shuffle, include_tags, exclude_tags, challenge_quiz_id, assignment_id, question_limit, difficulty, timer_seconds, client_ip, device_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, NULLIF($12, ''), NULLIF($13, ''))
+	RETURNING ` + quizColumns
 
-	err := u.db.QueryRow("INSERT INTO quiz (user_id) VALUES ($1) RETURNING id, user_id, created_at, updated_at", user.Id).Scan(&quiz.Id, &quiz.UserId, &quiz.CreatedAt, &quiz.UpdatedAt)
+	var quiz models.Quiz
+	err := u.db.QueryRowContext(ctx, query, input.UserId, input.Mode, input.PackId, input.Shuffle, pq.Array(input.IncludeTags), pq.Array(input.ExcludeTags),
+		input.ChallengeQuizId, input.AssignmentId, input.QuestionLimit, input.Difficulty, input.TimerSeconds, input.ClientIP, input.DeviceId).Scan(quizScanDest(&quiz)...)
 	if err != nil {
 		return models.Quiz{}, fmt.Errorf("query execution error: %v", err)
 	}
@@ -140,130 +211,259 @@ func (u *quizDaoImpl) CreateQuiz(user models.User) (models.Quiz, error) {
 	return quiz, nil
 }
 
-func (u *quizDaoImpl) SaveQuizAnswer(input models.QuizAnswerInput) (models.QuizAnswerResponse, error) {
-	question, err := u.GetQuestionById(input.QuestionId)
+// RecordIssuedQuestion remembers which revision of a question was handed out
+// in a quiz and returns the position and time it was issued at. The first
+// issue wins, so a reload cannot swap the revision or the position, or
+// restart the clock.
+func (u *quizDaoImpl) RecordIssuedQuestion(ctx context.Context, quizId uuid.UUID, question models.Question) (int, time.Time, error) {
+	query := `
+	INSERT INTO issued_questions (quiz_id, question_id, revision_id, position)
+	VALUES ($1, $2, $3, (SELECT COUNT(*) + 1 FROM quiz_questions WHERE quiz_id = $1))
+	ON CONFLICT (quiz_id, question_id) DO UPDATE SET position = COALESCE(issued_questions.position, EXCLUDED.position)
+	RETURNING position, COALESCE(issued_at, CURRENT_TIMESTAMP)::timestamptz
+	`
+
+	var position int
+	var issuedAt time.Time
+	if err := u.db.QueryRowContext(ctx, query, quizId, question.Id, question.RevisionId).Scan(&position, &issuedAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("error recording issued question: %v", err)
+	}
+	return position, issuedAt, nil
+}
+
+// claimIssuedQuestion marks the question issued at position as answered. It
+// returns ErrQuestionAnswered if the quiz was never issued that question
+// there or it has already been answered.
+func claimIssuedQuestion(ctx context.Context, tx *sql.Tx, quizId uuid.UUID, questionId uuid.UUID, position int) error {
+	res, err := tx.ExecContext(ctx, "UPDATE issued_questions SET answered_at = CURRENT_TIMESTAMP WHERE quiz_id = $1 AND question_id = $2 AND position = $3 AND answered_at IS NULL",
+		quizId, questionId, position)
+	if err != nil {
+		return fmt.Errorf("error claiming issued question: %v", err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error claiming issued question: %v", err)
+	}
+	if claimed == 0 {
+		return ErrQuestionAnswered
+	}
+	return nil
+}
+
+// getIssuedQuestion returns the question as it was issued in the quiz, falling
+// back to its current revision when it was never issued.
+func (u *quizDaoImpl) getIssuedQuestion(ctx context.Context, quizId uuid.UUID, questionId uuid.UUID) (models.Question, error) {
+	query := `
+	SELECT ` + revisionQuestionColumns + `
+	FROM questions q
+	LEFT JOIN issued_questions iq ON iq.quiz_id = $1 AND iq.question_id = q.id
+	LEFT JOIN question_revisions r ON r.id = COALESCE(iq.revision_id, q.current_revision_id)
+	WHERE q.id = $2
+	`
+
+	question, err := scanQuestion(u.db.QueryRowContext(ctx, query, quizId, questionId))
+	if err != nil {
+		return models.Question{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return question, nil
+}
+
+// SaveQuizAnswer grades the answer and records it in one transaction. The
+// question issued at position is claimed in the same transaction, so an
+// answer that fails to save can be retried with the same token; see
+// claimIssuedQuestion. Outside practice, and unless the quiz has
+// been flagged for cheating, the answer also updates the player and question
+// ratings in that transaction; see applyRating.
+func (u *quizDaoImpl) SaveQuizAnswer(ctx context.Context, input models.QuizAnswerInput, position int) (models.QuizAnswerResponse, error) {
+	question, err := u.getIssuedQuestion(ctx, input.QuizId, input.QuestionId)
 	if err != nil {
 		return models.QuizAnswerResponse{}, fmt.Errorf("error getting question: %v", err)
 	}
 
-	isCorrect := question.City == input.Answer
+	// Players may answer with the city's name in any locale the issued
+	// revision was translated to.
+	var localizedCities []string
+	err = u.db.QueryRowContext(ctx, "SELECT COALESCE(array_agg(city), '{}') FROM question_revision_translations WHERE revision_id = $1 AND city IS NOT NULL", question.RevisionId).Scan(pq.Array(&localizedCities))
+	if err != nil {
+		return models.QuizAnswerResponse{}, fmt.Errorf("error getting localized city names: %v", err)
+	}
+
+	// Answers given after the quiz's timer ran out count as wrong. Questions
+	// that were never issued have no start time and are not timed.
+	var timedOut bool
+	timerQuery := `
+	SELECT COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - iq.issued_at)) > q.timer_seconds, FALSE)
+	FROM quiz q
+	LEFT JOIN issued_questions iq ON iq.quiz_id = q.id AND iq.question_id = $2
+	WHERE q.id = $1
+	`
+	err = u.db.QueryRowContext(ctx, timerQuery, input.QuizId, input.QuestionId).Scan(&timedOut)
+	if err != nil {
+		return models.QuizAnswerResponse{}, fmt.Errorf("error checking answer timer: %v", err)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.QuizAnswerResponse{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := claimIssuedQuestion(ctx, tx, input.QuizId, input.QuestionId, position); err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+
+	var userId uuid.UUID
+	var rated bool
+	err = tx.QueryRowContext(ctx, "SELECT q.user_id, q.mode <> $2 AND NOT "+quizFlagged("q.id")+" FROM quiz q WHERE q.id = $1", input.QuizId, models.QuizModePractice).Scan(&userId, &rated)
+	if err != nil {
+		return models.QuizAnswerResponse{}, fmt.Errorf("error getting quiz: %v", err)
+	}
+
+	isCorrect := !timedOut && matchesCity(input.Answer, question.City, localizedCities)
 	if isCorrect {
 		//  add 1+ to score in quiz table
-		_, err = u.db.Exec("UPDATE quiz SET score = score + 1 WHERE id = $1", input.QuizId)
+		_, err = tx.ExecContext(ctx, "UPDATE quiz SET score = score + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1", input.QuizId)
 		if err != nil {
 			return models.QuizAnswerResponse{}, fmt.Errorf("error updating quiz score: %v", err)
 		}
 	}
 
 	// insert into quiz_questions table
-	_, err = u.db.Exec("INSERT INTO quiz_questions (quiz_id, question_id, is_correct, user_answer, order_number) VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(order_number), 0) + 1 FROM quiz_questions WHERE quiz_id = $1))", input.QuizId, input.QuestionId, isCorrect, input.Answer)
+	// Response time is measured from when the question was issued, if it was.
+	insertQuery := `
+	INSERT INTO quiz_questions (quiz_id, question_id, revision_id, is_correct, user_answer, order_number, response_time_ms)
+	VALUES ($1, $2, $3, $4, $5,
+		(SELECT COALESCE(MAX(order_number), 0) + 1 FROM quiz_questions WHERE quiz_id = $1),
+		(SELECT (EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - iq.issued_at)) * 1000)::int FROM issued_questions iq WHERE iq.quiz_id = $1 AND iq.question_id = $2))
+	`
+	_, err = tx.ExecContext(ctx, insertQuery, input.QuizId, input.QuestionId, question.RevisionId, isCorrect, input.Answer)
 	if err != nil {
 		return models.QuizAnswerResponse{}, fmt.Errorf("error inserting quiz question: %v", err)
 	}
 
+	if rated {
+		if err := applyRating(ctx, tx, userId, input.QuestionId, isCorrect); err != nil {
+			return models.QuizAnswerResponse{}, err
+		}
+	}
+
 	var score int
-	err = u.db.QueryRow("SELECT score FROM quiz WHERE id = $1", input.QuizId).Scan(&score)
+	err = tx.QueryRowContext(ctx, "SELECT score FROM quiz WHERE id = $1", input.QuizId).Scan(&score)
 	if err != nil {
 		return models.QuizAnswerResponse{}, fmt.Errorf("error getting quiz score: %v", err)
 	}
 
 	var totalQuestions int
-	err = u.db.QueryRow("SELECT COUNT(*) FROM quiz_questions WHERE quiz_id = $1", input.QuizId).Scan(&totalQuestions)
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM quiz_questions WHERE quiz_id = $1", input.QuizId).Scan(&totalQuestions)
 	if err != nil {
 		return models.QuizAnswerResponse{}, fmt.Errorf("error getting total questions: %v", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return models.QuizAnswerResponse{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
 	return models.QuizAnswerResponse{
 		IsCorrect:      isCorrect,
+		TimedOut:       timedOut,
 		Score:          score,
 		TotalQuestions: totalQuestions,
 	}, nil
 }
-func (u *quizDaoImpl) ListQuizByUserName(userName string) ([]models.Quiz, error) {
+func (u *quizDaoImpl) ListQuizByUsernameKey(ctx context.Context, usernameKey string) ([]models.Quiz, error) {
 	var quizzes []models.Quiz
 	query := `
-	SELECT q.id, q.user_id, q.score, q.created_at, q.updated_at
+	SELECT ` + quizColumns + `,
+		COUNT(qq.id)
 	FROM quiz q
 	JOIN users u ON q.user_id = u.id
-	WHERE u.username = $1
+	LEFT JOIN quiz_questions qq ON qq.quiz_id = q.id
+	WHERE u.username_key = $1
+	GROUP BY q.id
+	ORDER BY q.created_at
 	`
 
-	rows, err := u.db.Query(query, userName)
+	rows, err := u.db.QueryContext(ctx, query, usernameKey)
 	if err != nil {
-		// if err == sql.ErrNoRows {
-		// 	return []models.Quiz{}, nil
-		// }
 		return nil, fmt.Errorf("query execution error: %v", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var quiz models.Quiz
-		err := rows.Scan(&quiz.Id, &quiz.UserId, &quiz.Score, &quiz.CreatedAt, &quiz.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning row: %v", err)
-		}
-
-		// Get total number of questions for each quiz
 		var totalQuestions int
-		err = u.db.QueryRow("SELECT COUNT(*) FROM quiz_questions WHERE quiz_id = $1", quiz.Id).Scan(&totalQuestions)
+		err := rows.Scan(append(quizScanDest(&quiz), &totalQuestions)...)
 		if err != nil {
-			return nil, fmt.Errorf("error getting total questions: %v", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
 		}
 		quiz.TotalQuestions = &totalQuestions
 
 		quizzes = append(quizzes, quiz)
 	}
 
-	return quizzes, nil
+	return quizzes, rows.Err()
 }
 
-func (u *quizDaoImpl) GetQuizById(quizId uuid.UUID) (models.Quiz, error) {
+func (u *quizDaoImpl) GetQuizById(ctx context.Context, quizId uuid.UUID) (models.Quiz, error) {
 	var quiz models.Quiz
 	query := `
-	SELECT q.id, q.user_id, q.score, q.created_at, q.updated_at
+	SELECT ` + quizColumns + `
 	FROM quiz q
 	WHERE q.id = $1
 	`
 
-	err := u.db.QueryRow(query, quizId).Scan(&quiz.Id, &quiz.UserId, &quiz.Score, &quiz.CreatedAt, &quiz.UpdatedAt)
+	err := u.db.QueryRowContext(ctx, query, quizId).Scan(quizScanDest(&quiz)...)
 	if err != nil {
-		return models.Quiz{}, fmt.Errorf("query execution error: %v", err)
+		return models.Quiz{}, fmt.Errorf("query execution error: %w", err)
 	}
 
 	return quiz, nil
 }
 
-func (u *quizDaoImpl) GetAllQuestionsByQuizId(quizId uuid.UUID) ([]models.Question, error) {
+// SetQuizChallenge records which quiz this one is replaying. A quiz keeps the
+// first challenge it was started against.
+func (u *quizDaoImpl) SetQuizChallenge(ctx context.Context, quizId uuid.UUID, challengeQuizId uuid.UUID) error {
+	_, err := u.db.ExecContext(ctx, "UPDATE quiz SET challenge_quiz_id = $2 WHERE id = $1 AND challenge_quiz_id IS NULL AND id <> $2", quizId, challengeQuizId)
+	if err != nil {
+		return fmt.Errorf("error setting quiz challenge: %v", err)
+	}
+	return nil
+}
+
+// MarkQuizCompleted stamps the quiz as finished. It reports false when the
+// quiz was already completed.
+func (u *quizDaoImpl) MarkQuizCompleted(ctx context.Context, quizId uuid.UUID) (bool, error) {
+	res, err := u.db.ExecContext(ctx, "UPDATE quiz SET completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND completed_at IS NULL", quizId)
+	if err != nil {
+		return false, fmt.Errorf("error completing quiz: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error completing quiz: %v", err)
+	}
+	return n > 0, nil
+}
+
+func (u *quizDaoImpl) GetAllQuestionsByQuizId(ctx context.Context, quizId uuid.UUID) ([]models.Question, error) {
 	var questions []models.Question
 	query := `
-	SELECT q.id, q.city, q.country, q.clues, q.fun_fact, q.trivia, q.options, q.correct_answer, q.created_at, q.updated_at
+	SELECT ` + revisionQuestionColumns + `
 	FROM questions q
 	JOIN quiz_questions qq ON q.id = qq.question_id
+	LEFT JOIN question_revisions r ON r.id = COALESCE(qq.revision_id, q.current_revision_id)
 	WHERE qq.quiz_id = $1
 	ORDER BY qq.order_number
 	`
 
-	rows, err := u.db.Query(query, quizId)
+	rows, err := u.db.QueryContext(ctx, query, quizId)
 	if err != nil {
 		return nil, fmt.Errorf("query execution error: %v", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var question models.Question
-		err := rows.Scan(
-			&question.Id,
-			&question.City,
-			&question.Country,
-			pq.Array(&question.Clues),
-			pq.Array(&question.FunFact),
-			pq.Array(&question.Trivia),
-			pq.Array(&question.Options),
-			&question.CorrectAnswer,
-			&question.CreatedAt,
-			&question.UpdatedAt,
-		)
+		question, err := scanQuestion(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %v", err)
 		}
@@ -272,3 +472,53 @@ func (u *quizDaoImpl) GetAllQuestionsByQuizId(quizId uuid.UUID) ([]models.Questi
 
 	return questions, nil
 }
+
+func scanQuestion(row rowScanner) (models.Question, error) {
+	var question models.Question
+	err := row.Scan(questionScanDest(&question)...)
+	return question, err
+}
+
+// questionScanDest returns the scan destinations matching questionColumns.
+func questionScanDest(question *models.Question) []any {
+	// Use pq.Array to scan directly into string slices
+	return []any{
+		&question.Id,
+		&question.City,
+		&question.Country,
+		&question.Continent,
+		&question.Region,
+		pq.Array(&question.Tags),
+		pq.Array(&question.Clues),
+		pq.Array(&question.FunFact),
+		pq.Array(&question.Trivia),
+		pq.Array(&question.Options),
+		&question.CorrectAnswer,
+		&question.RevisionId,
+		&question.CreatedAt,
+		&question.UpdatedAt,
+	}
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, v := range values {
+		lowered = append(lowered, strings.ToLower(v))
+	}
+	return lowered
+}
+
+// matchesCity reports whether answer names the city, ignoring surrounding
+// whitespace and case.
+func matchesCity(answer string, city string, localizedCities []string) bool {
+	answer = strings.TrimSpace(answer)
+	if strings.EqualFold(answer, city) {
+		return true
+	}
+	for _, name := range localizedCities {
+		if strings.EqualFold(answer, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/rating"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RatingDao interface {
+	GetLeaderboard(ctx context.Context, minGames int, limit int) ([]models.RatedPlayer, error)
+	RecomputeRatings(ctx context.Context) (models.RatingRecomputeResult, error)
+}
+
+type ratingDaoImpl struct {
+	db *sql.DB
+}
+
+func NewRatingDao(db *sql.DB) RatingDao {
+	return &ratingDaoImpl{
+		db: db,
+	}
+}
+
+// applyRating scores one answer as a match between the player and the
+// question, as part of the transaction that saves the answer. Both rows are
+// locked, player first, so concurrent answers to the same question apply one
+// after the other.
+func applyRating(ctx context.Context, tx *sql.Tx, userId uuid.UUID, questionId uuid.UUID, correct bool) error {
+	var player, question rating.Rating
+	err := tx.QueryRowContext(ctx, "SELECT rating, rated_games FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&player.Value, &player.Games)
+	if err != nil {
+		return fmt.Errorf("error getting player rating: %v", err)
+	}
+	err = tx.QueryRowContext(ctx, "SELECT rating, rated_games FROM questions WHERE id = $1 FOR UPDATE", questionId).Scan(&question.Value, &question.Games)
+	if err != nil {
+		return fmt.Errorf("error getting question rating: %v", err)
+	}
+
+	player, question = rating.Match(player, question, correct)
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET rating = $2, rated_games = $3 WHERE id = $1", userId, player.Value, player.Games)
+	if err != nil {
+		return fmt.Errorf("error updating player rating: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE questions SET rating = $2, rated_games = $3 WHERE id = $1", questionId, question.Value, question.Games)
+	if err != nil {
+		return fmt.Errorf("error updating question rating: %v", err)
+	}
+	return nil
+}
+
+// GetLeaderboard ranks players by rating, leaving out anyone with a quiz
+// flagged for cheating that has not been cleared.
+func (r *ratingDaoImpl) GetLeaderboard(ctx context.Context, minGames int, limit int) ([]models.RatedPlayer, error) {
+	query := `
+	SELECT RANK() OVER (ORDER BY u.rating DESC), u.id, u.username, u.rating, u.rated_games
+	FROM users u
+	WHERE u.rated_games >= $1 AND NOT ` + playerFlagged("u.id") + `
+	ORDER BY u.rating DESC, u.username
+	LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, minGames, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	players := []models.RatedPlayer{}
+	for rows.Next() {
+		var player models.RatedPlayer
+		if err := rows.Scan(&player.Rank, &player.UserId, &player.UserName, &player.Rating, &player.RatedGames); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		players = append(players, player)
+	}
+
+	return players, rows.Err()
+}
+
+// RecomputeRatings replays every rated answer, oldest first, from fresh
+// ratings. Practice answers are never rated, and answers in quizzes flagged
+// for cheating are dropped until the flag is cleared. The users and questions
+// tables are locked against rating updates for the duration, so answers
+// saved meanwhile wait and then apply on top.
+func (r *ratingDaoImpl) RecomputeRatings(ctx context.Context) (models.RatingRecomputeResult, error) {
+	var result models.RatingRecomputeResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE users, questions IN EXCLUSIVE MODE"); err != nil {
+		return result, fmt.Errorf("error locking ratings: %v", err)
+	}
+
+	query := `
+	SELECT q.user_id, qq.question_id, qq.is_correct
+	FROM quiz_questions qq
+	JOIN quiz q ON q.id = qq.quiz_id
+	WHERE q.mode <> 'practice' AND NOT ` + quizFlagged("q.id") + `
+	ORDER BY qq.created_at, qq.quiz_id, qq.order_number
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return result, fmt.Errorf("query execution error: %v", err)
+	}
+
+	players := map[uuid.UUID]rating.Rating{}
+	questions := map[uuid.UUID]rating.Rating{}
+	for rows.Next() {
+		var userId, questionId uuid.UUID
+		var correct bool
+		if err := rows.Scan(&userId, &questionId, &correct); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("error scanning row: %v", err)
+		}
+
+		player, ok := players[userId]
+		if !ok {
+			player = rating.NewRating()
+		}
+		question, ok := questions[questionId]
+		if !ok {
+			question = rating.NewRating()
+		}
+		players[userId], questions[questionId] = rating.Match(player, question, correct)
+		result.AnswersReplayed++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("error reading answers: %v", err)
+	}
+
+	for _, table := range []string{"users", "questions"} {
+		_, err := tx.ExecContext(ctx, "UPDATE "+table+" SET rating = $1, rated_games = 0", rating.Initial)
+		if err != nil {
+			return result, fmt.Errorf("error resetting %s ratings: %v", table, err)
+		}
+	}
+	if err := writeRatings(ctx, tx, "users", players); err != nil {
+		return result, err
+	}
+	if err := writeRatings(ctx, tx, "questions", questions); err != nil {
+		return result, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	result.PlayersRated = len(players)
+	result.QuestionsRated = len(questions)
+	return result, nil
+}
+
+// writeRatings stores ratings in table with one statement.
+func writeRatings(ctx context.Context, tx *sql.Tx, table string, ratings map[uuid.UUID]rating.Rating) error {
+	ids := make([]string, 0, len(ratings))
+	values := make([]float64, 0, len(ratings))
+	games := make([]int64, 0, len(ratings))
+	for id, r := range ratings {
+		ids = append(ids, id.String())
+		values = append(values, r.Value)
+		games = append(games, int64(r.Games))
+	}
+
+	query := `
+	UPDATE ` + table + ` t
+	SET rating = r.rating, rated_games = r.rated_games
+	FROM unnest($1::uuid[], $2::float8[], $3::int[]) AS r(id, rating, rated_games)
+	WHERE t.id = r.id
+	`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids), pq.Array(values), pq.Array(games)); err != nil {
+		return fmt.Errorf("error writing %s ratings: %v", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/srs"
+	"github.com/google/uuid"
+)
+
+type ReviewDao interface {
+	GetDueReviewQuestion(ctx context.Context, quizId uuid.UUID, userId uuid.UUID, now time.Time) (models.Question, error)
+	RecordReview(ctx context.Context, userId uuid.UUID, questionId uuid.UUID, correct bool, practice bool, now time.Time) error
+	GetReviewQueue(ctx context.Context, userId uuid.UUID, dueBy time.Time) (models.ReviewQueue, error)
+}
+
+type reviewDaoImpl struct {
+	db *sql.DB
+}
+
+func NewReviewDao(db *sql.DB) ReviewDao {
+	return &reviewDaoImpl{
+		db: db,
+	}
+}
+
+// GetDueReviewQuestion returns the user's most overdue card that has not been
+// asked in the quiz yet. An empty question means nothing is due.
+func (r *reviewDaoImpl) GetDueReviewQuestion(ctx context.Context, quizId uuid.UUID, userId uuid.UUID, now time.Time) (models.Question, error) {
+	query := `
+	SELECT ` + questionColumns + `
+	FROM review_cards rc
+	JOIN questions q ON q.id = rc.question_id
+	WHERE rc.user_id = $2 AND rc.due_at <= $3 AND ` + publishedClause + ` AND q.id NOT IN (
+		SELECT qq.question_id
+		FROM quiz_questions qq
+		WHERE qq.quiz_id = $1
+	)
+	ORDER BY rc.due_at
+	LIMIT 1
+	`
+
+	question, err := scanQuestion(r.db.QueryRowContext(ctx, query, quizId, userId, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Question{}, nil
+		}
+		return models.Question{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return question, nil
+}
+
+// RecordReview updates the user's card for the question after an answer.
+// Missed cities get a card if they have none. Practice answers review the
+// card either way; in other quizzes only a miss does, sending the card back
+// to the start.
+func (r *reviewDaoImpl) RecordReview(ctx context.Context, userId uuid.UUID, questionId uuid.UUID, correct bool, practice bool, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var card srs.Card
+	err = tx.QueryRowContext(ctx, "SELECT ease, interval_days, repetitions, due_at FROM review_cards WHERE user_id = $1 AND question_id = $2 FOR UPDATE",
+		userId, questionId).Scan(&card.Ease, &card.IntervalDays, &card.Repetitions, &card.DueAt)
+	switch {
+	case err == sql.ErrNoRows:
+		if correct {
+			return nil
+		}
+		card = srs.NewCard(now)
+		_, err = tx.ExecContext(ctx, "INSERT INTO review_cards (user_id, question_id, ease, interval_days, repetitions, due_at) VALUES ($1, $2, $3, $4, $5, $6)",
+			userId, questionId, card.Ease, card.IntervalDays, card.Repetitions, card.DueAt)
+		if err != nil {
+			return fmt.Errorf("error creating review card: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("error getting review card: %v", err)
+	case practice || !correct:
+		card = srs.Review(card, srs.Quality(correct), now)
+		_, err = tx.ExecContext(ctx, `UPDATE review_cards SET ease = $3, interval_days = $4, repetitions = $5, due_at = $6, last_reviewed_at = $7, updated_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND question_id = $2`,
+			userId, questionId, card.Ease, card.IntervalDays, card.Repetitions, card.DueAt, now)
+		if err != nil {
+			return fmt.Errorf("error updating review card: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// GetReviewQueue lists all of the user's cards, soonest due first, and counts
+// the ones due by dueBy. It returns sql.ErrNoRows when the user does not
+// exist.
+func (r *reviewDaoImpl) GetReviewQueue(ctx context.Context, userId uuid.UUID, dueBy time.Time) (models.ReviewQueue, error) {
+	queue := models.ReviewQueue{UserId: userId, Cards: []models.ReviewCard{}}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", userId).Scan(&exists); err != nil {
+		return models.ReviewQueue{}, fmt.Errorf("query execution error: %v", err)
+	}
+	if !exists {
+		return models.ReviewQueue{}, sql.ErrNoRows
+	}
+
+	query := `
+	SELECT rc.question_id, q.city, q.country, rc.ease, rc.interval_days, rc.repetitions, rc.due_at, rc.last_reviewed_at
+	FROM review_cards rc
+	JOIN questions q ON q.id = rc.question_id
+	WHERE rc.user_id = $1 AND q.deleted_at IS NULL
+	ORDER BY rc.due_at, q.city
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return models.ReviewQueue{}, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var card models.ReviewCard
+		err := rows.Scan(&card.QuestionId, &card.City, &card.Country, &card.Ease, &card.IntervalDays, &card.Repetitions, &card.DueAt, &card.LastReviewedAt)
+		if err != nil {
+			return models.ReviewQueue{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		if !card.DueAt.After(dueBy) {
+			queue.DueToday++
+		}
+		queue.Cards = append(queue.Cards, card)
+	}
+	queue.TotalCards = len(queue.Cards)
+
+	return queue, rows.Err()
+}
@@ -0,0 +1,118 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+// ErrLastAdmin is returned when a role change would leave no admin.
+var ErrLastAdmin = errors.New("cannot remove the last admin")
+
+type StaffDao interface {
+	GetActorByKeyHash(ctx context.Context, keyHash []byte) (models.Actor, error)
+	ListStaff(ctx context.Context) ([]models.Actor, error)
+	SetRole(ctx context.Context, userId uuid.UUID, role string, keyHash []byte) (models.Actor, error)
+}
+
+type staffDaoImpl struct {
+	db *sql.DB
+}
+
+func NewStaffDao(db *sql.DB) StaffDao {
+	return &staffDaoImpl{db: db}
+}
+
+// GetActorByKeyHash returns the staff member whose key hashes to keyHash.
+func (s *staffDaoImpl) GetActorByKeyHash(ctx context.Context, keyHash []byte) (models.Actor, error) {
+	var actor models.Actor
+	err := s.db.QueryRowContext(ctx, "SELECT id, username, role FROM users WHERE staff_key_hash = $1 AND role <> 'player' AND deleted_at IS NULL", keyHash).
+		Scan(&actor.UserId, &actor.UserName, &actor.Role)
+	if err == sql.ErrNoRows {
+		return models.Actor{}, err
+	}
+	if err != nil {
+		return models.Actor{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return actor, nil
+}
+
+// ListStaff returns moderators and admins, admins first.
+func (s *staffDaoImpl) ListStaff(ctx context.Context) ([]models.Actor, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, username, role FROM users WHERE role <> 'player' AND deleted_at IS NULL ORDER BY role = 'admin' DESC, username")
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	staff := []models.Actor{}
+	for rows.Next() {
+		var actor models.Actor
+		if err := rows.Scan(&actor.UserId, &actor.UserName, &actor.Role); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		staff = append(staff, actor)
+	}
+
+	return staff, rows.Err()
+}
+
+// SetRole gives the user role and replaces their staff key hash; a nil hash
+// keeps the current key. It returns sql.ErrNoRows if the user does not exist
+// and ErrLastAdmin if it would demote the only admin.
+func (s *staffDaoImpl) SetRole(ctx context.Context, userId uuid.UUID, role string, keyHash []byte) (models.Actor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Actor{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Locking every admin serialises concurrent demotions, so two admins
+	// cannot demote each other at once.
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE role = 'admin' AND deleted_at IS NULL FOR UPDATE")
+	if err != nil {
+		return models.Actor{}, fmt.Errorf("error locking admins: %v", err)
+	}
+	var admins []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return models.Actor{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		admins = append(admins, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.Actor{}, fmt.Errorf("error locking admins: %v", err)
+	}
+	if role != models.RoleAdmin && len(admins) == 1 && admins[0] == userId {
+		return models.Actor{}, ErrLastAdmin
+	}
+
+	query := `
+	UPDATE users
+	SET role = $2,
+		staff_key_hash = CASE WHEN $2 = 'player' THEN NULL ELSE COALESCE($3, staff_key_hash) END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING id, username, role
+	`
+	var actor models.Actor
+	err = tx.QueryRowContext(ctx, query, userId, role, keyHash).Scan(&actor.UserId, &actor.UserName, &actor.Role)
+	if err == sql.ErrNoRows {
+		return models.Actor{}, err
+	}
+	if err != nil {
+		return models.Actor{}, fmt.Errorf("error setting role: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Actor{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return actor, nil
+}
@@ -0,0 +1,158 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+type StatsDao interface {
+	GetUserStats(ctx context.Context, userId uuid.UUID, missedLimit int) (models.UserStats, error)
+}
+
+type statsDaoImpl struct {
+	db *sql.DB
+}
+
+func NewStatsDao(db *sql.DB) StatsDao {
+	return &statsDaoImpl{
+		db: db,
+	}
+}
+
+// userAnswersCTE numbers every answer a user has given, oldest first.
+const userAnswersCTE = `
+	answers AS (
+		SELECT qq.question_id, qq.is_correct, qq.response_time_ms,
+			ROW_NUMBER() OVER (ORDER BY qq.created_at, qq.quiz_id, qq.order_number) AS seq
+		FROM quiz_questions qq
+		JOIN quiz q ON q.id = qq.quiz_id
+		WHERE q.user_id = $1
+	)`
+
+// userStreaksCTE has one row per run of consecutive correct answers in
+// userAnswersCTE. Consecutive correct answers share the same
+// seq - ROW_NUMBER() value, so grouping by it yields one row per streak.
+const userStreaksCTE = `
+	streaks AS (
+		SELECT COUNT(*) AS length, MAX(seq) AS last_seq
+		FROM (
+			SELECT seq, seq - ROW_NUMBER() OVER (ORDER BY seq) AS run
+			FROM answers
+			WHERE is_correct
+		) correct
+		GROUP BY run
+	)`
+
+// GetUserStats aggregates all of a user's answers. It returns sql.ErrNoRows
+// when the user does not exist.
+func (s *statsDaoImpl) GetUserStats(ctx context.Context, userId uuid.UUID, missedLimit int) (models.UserStats, error) {
+	stats := models.UserStats{
+		UserId:      userId,
+		ByCountry:   []models.AccuracyBreakdown{},
+		ByContinent: []models.AccuracyBreakdown{},
+		MostMissed:  []models.MissedCity{},
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", userId).Scan(&exists); err != nil {
+		return models.UserStats{}, fmt.Errorf("query execution error: %v", err)
+	}
+	if !exists {
+		return models.UserStats{}, sql.ErrNoRows
+	}
+
+	summaryQuery := `
+	WITH ` + userAnswersCTE + `, ` + userStreaksCTE + `
+	SELECT
+		(SELECT COUNT(*) FROM answers),
+		(SELECT COUNT(*) FROM answers WHERE is_correct),
+		(SELECT AVG(response_time_ms)::float8 FROM answers),
+		COALESCE((SELECT MAX(length) FROM streaks), 0),
+		COALESCE((SELECT length FROM streaks WHERE last_seq = (SELECT MAX(seq) FROM answers)), 0)
+	`
+
+	err := s.db.QueryRowContext(ctx, summaryQuery, userId).Scan(
+		&stats.TotalAnswers,
+		&stats.CorrectAnswers,
+		&stats.AverageResponseMs,
+		&stats.BestStreak,
+		&stats.CurrentStreak,
+	)
+	if err != nil {
+		return models.UserStats{}, fmt.Errorf("error getting answer summary: %v", err)
+	}
+	stats.Accuracy = accuracy(stats.CorrectAnswers, stats.TotalAnswers)
+
+	breakdownQuery := `
+	WITH ` + userAnswersCTE + `
+	SELECT GROUPING(qs.country) = 0 AS by_country,
+		CASE WHEN GROUPING(qs.country) = 0 THEN qs.country ELSE COALESCE(qs.continent, '') END,
+		COUNT(*),
+		COUNT(*) FILTER (WHERE a.is_correct)
+	FROM answers a
+	JOIN questions qs ON qs.id = a.question_id
+	GROUP BY GROUPING SETS ((qs.country), (qs.continent))
+	ORDER BY 1, 3 DESC, 2
+	`
+
+	rows, err := s.db.QueryContext(ctx, breakdownQuery, userId)
+	if err != nil {
+		return models.UserStats{}, fmt.Errorf("error getting accuracy breakdown: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var byCountry bool
+		var breakdown models.AccuracyBreakdown
+		if err := rows.Scan(&byCountry, &breakdown.Name, &breakdown.Answers, &breakdown.Correct); err != nil {
+			return models.UserStats{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		breakdown.Accuracy = accuracy(breakdown.Correct, breakdown.Answers)
+		if byCountry {
+			stats.ByCountry = append(stats.ByCountry, breakdown)
+		} else {
+			stats.ByContinent = append(stats.ByContinent, breakdown)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.UserStats{}, err
+	}
+
+	missedQuery := `
+	WITH ` + userAnswersCTE + `
+	SELECT qs.city, qs.country, COUNT(*)
+	FROM answers a
+	JOIN questions qs ON qs.id = a.question_id
+	WHERE NOT a.is_correct
+	GROUP BY qs.city, qs.country
+	ORDER BY 3 DESC, 1
+	LIMIT $2
+	`
+
+	missedRows, err := s.db.QueryContext(ctx, missedQuery, userId, missedLimit)
+	if err != nil {
+		return models.UserStats{}, fmt.Errorf("error getting missed cities: %v", err)
+	}
+	defer missedRows.Close()
+
+	for missedRows.Next() {
+		var missed models.MissedCity
+		if err := missedRows.Scan(&missed.City, &missed.Country, &missed.Misses); err != nil {
+			return models.UserStats{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		stats.MostMissed = append(stats.MostMissed, missed)
+	}
+
+	return stats, missedRows.Err()
+}
+
+func accuracy(correct int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total)
+}
@@ -0,0 +1,123 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TagDao interface {
+	GetTagCoverage(ctx context.Context) (models.TagCoverage, error)
+	SetQuestionTags(ctx context.Context, questionId uuid.UUID, input models.QuestionTagsInput) error
+}
+
+type tagDaoImpl struct {
+	db *sql.DB
+}
+
+func NewTagDao(db *sql.DB) TagDao {
+	return &tagDaoImpl{
+		db: db,
+	}
+}
+
+func (t *tagDaoImpl) GetTagCoverage(ctx context.Context) (models.TagCoverage, error) {
+	coverage := models.TagCoverage{Tags: []models.TagCount{}}
+
+	err := t.db.QueryRowContext(ctx, `
+	SELECT COUNT(*),
+		COUNT(*) FILTER (WHERE q.continent IS NULL OR q.continent = ''),
+		COUNT(*) FILTER (WHERE q.region IS NULL OR q.region = ''),
+		COUNT(*) FILTER (WHERE NOT EXISTS (SELECT 1 FROM question_tags qt WHERE qt.question_id = q.id))
+	FROM questions q
+	`).Scan(&coverage.TotalQuestions, &coverage.MissingContinent, &coverage.MissingRegion, &coverage.Untagged)
+	if err != nil {
+		return models.TagCoverage{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	// Tags with no questions are kept so coverage gaps show up as zeros.
+	query := `
+	SELECT 'continent', q.continent, COUNT(*)
+	FROM questions q
+	WHERE q.continent <> ''
+	GROUP BY q.continent
+	UNION ALL
+	SELECT 'region', q.region, COUNT(*)
+	FROM questions q
+	WHERE q.region <> ''
+	GROUP BY q.region
+	UNION ALL
+	SELECT 'tag', t.name, COUNT(qt.question_id)
+	FROM tags t
+	LEFT JOIN question_tags qt ON qt.tag_id = t.id
+	GROUP BY t.name
+	ORDER BY 1, 3 DESC, 2
+	`
+
+	rows, err := t.db.QueryContext(ctx, query)
+	if err != nil {
+		return models.TagCoverage{}, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Kind, &tag.Name, &tag.QuestionCount); err != nil {
+			return models.TagCoverage{}, fmt.Errorf("error scanning row: %v", err)
+		}
+		coverage.Tags = append(coverage.Tags, tag)
+	}
+
+	return coverage, rows.Err()
+}
+
+func (t *tagDaoImpl) SetQuestionTags(ctx context.Context, questionId uuid.UUID, input models.QuestionTagsInput) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE questions SET continent = NULLIF($2, ''), region = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		questionId, input.Continent, input.Region)
+	if err != nil {
+		return fmt.Errorf("error updating question: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := replaceQuestionTags(ctx, tx, questionId, input.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// replaceQuestionTags swaps the question's tag set for tags, creating any tag
+// names that do not exist yet.
+func replaceQuestionTags(ctx context.Context, tx *sql.Tx, questionId uuid.UUID, tags []string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING", pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("error inserting tags: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id = $1", questionId)
+	if err != nil {
+		return fmt.Errorf("error clearing question tags: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO question_tags (question_id, tag_id) SELECT $1, t.id FROM tags t WHERE lower(t.name) = ANY($2::text[])",
+		questionId, pq.Array(lowerAll(tags)))
+	if err != nil {
+		return fmt.Errorf("error inserting question tags: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/tournament"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TournamentDao interface {
+	CreateTournament(ctx context.Context, t models.Tournament, actor string) (models.Tournament, error)
+	GetTournament(ctx context.Context, tournamentId uuid.UUID) (models.Tournament, error)
+	ListTournaments(ctx context.Context) ([]models.Tournament, error)
+	RegisterParticipant(ctx context.Context, tournamentId uuid.UUID, userId uuid.UUID) error
+	IsActiveParticipant(ctx context.Context, tournamentId uuid.UUID, userId uuid.UUID) (bool, error)
+	GetRound(ctx context.Context, tournamentId uuid.UUID, roundNumber int) (models.TournamentRound, error)
+	GetEntryQuizId(ctx context.Context, roundId uuid.UUID, userId uuid.UUID) (*uuid.UUID, error)
+	CreateEntry(ctx context.Context, roundId uuid.UUID, userId uuid.UUID, quizId uuid.UUID) (bool, error)
+	ListDueRounds(ctx context.Context, now time.Time) ([]models.TournamentRound, error)
+	ListSeededParticipants(ctx context.Context, tournamentId uuid.UUID, previousRoundId *uuid.UUID) ([]uuid.UUID, error)
+	OpenRound(ctx context.Context, round models.TournamentRound, pairings []tournament.Pairing) error
+	GetRoundResults(ctx context.Context, round models.TournamentRound) ([]uuid.UUID, map[uuid.UUID]tournament.Result, error)
+	ListRoundMatches(ctx context.Context, roundId uuid.UUID) ([]tournament.Match, error)
+	CloseRound(ctx context.Context, round models.TournamentRound, results map[uuid.UUID]tournament.Result, eliminated []uuid.UUID, winners map[uuid.UUID]uuid.UUID, finished bool) error
+	GetStandings(ctx context.Context, tournamentId uuid.UUID) ([]models.TournamentStanding, error)
+	ListMatches(ctx context.Context, tournamentId uuid.UUID) ([]models.TournamentMatch, error)
+}
+
+type tournamentDaoImpl struct {
+	db *sql.DB
+}
+
+func NewTournamentDao(db *sql.DB) TournamentDao {
+	return &tournamentDaoImpl{
+		db: db,
+	}
+}
+
+const tournamentColumns = `t.id, t.name, t.status, t.registration_opens_at, t.registration_closes_at,
+	(SELECT COUNT(*) FROM tournament_participants tp WHERE tp.tournament_id = t.id),
+	COALESCE(t.created_by, ''), t.created_at, t.updated_at`
+
+func scanTournament(row rowScanner) (models.Tournament, error) {
+	tournament := models.Tournament{Rounds: []models.TournamentRound{}}
+	err := row.Scan(&tournament.Id, &tournament.Name, &tournament.Status, &tournament.RegistrationOpensAt, &tournament.RegistrationClosesAt,
+		&tournament.ParticipantCount, &tournament.CreatedBy, &tournament.CreatedAt, &tournament.UpdatedAt)
+	return tournament, err
+}
+
+const roundColumns = `r.id, r.tournament_id, r.round_number, r.format, r.advance_count, r.pack_id, r.starts_at, r.ends_at, r.status`
+
+func scanRound(row rowScanner) (models.TournamentRound, error) {
+	var round models.TournamentRound
+	err := row.Scan(&round.Id, &round.TournamentId, &round.RoundNumber, &round.Format, &round.AdvanceCount,
+		&round.PackId, &round.StartsAt, &round.EndsAt, &round.Status)
+	return round, err
+}
+
+func (d *tournamentDaoImpl) CreateTournament(ctx context.Context, t models.Tournament, actor string) (models.Tournament, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Tournament{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var tournamentId uuid.UUID
+	err = tx.QueryRowContext(ctx, "INSERT INTO tournaments (name, registration_opens_at, registration_closes_at, created_by) VALUES ($1, $2, $3, $4) RETURNING id",
+		t.Name, t.RegistrationOpensAt, t.RegistrationClosesAt, actor).Scan(&tournamentId)
+	if err != nil {
+		return models.Tournament{}, fmt.Errorf("error creating tournament: %v", err)
+	}
+
+	for _, round := range t.Rounds {
+		_, err := tx.ExecContext(ctx, `INSERT INTO tournament_rounds (tournament_id, round_number, format, advance_count, pack_id, starts_at, ends_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			tournamentId, round.RoundNumber, round.Format, round.AdvanceCount, round.PackId, round.StartsAt, round.EndsAt)
+		if err != nil {
+			return models.Tournament{}, fmt.Errorf("error creating round %d: %v", round.RoundNumber, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Tournament{}, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return d.GetTournament(ctx, tournamentId)
+}
+
+func (d *tournamentDaoImpl) GetTournament(ctx context.Context, tournamentId uuid.UUID) (models.Tournament, error) {
+	t, err := scanTournament(d.db.QueryRowContext(ctx, "SELECT "+tournamentColumns+" FROM tournaments t WHERE t.id = $1", tournamentId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Tournament{}, err
+		}
+		return models.Tournament{}, fmt.Errorf("query execution error: %v", err)
+	}
+
+	rounds, err := d.listRounds(ctx, "r.tournament_id = $1", roundOrder, tournamentId)
+	if err != nil {
+		return models.Tournament{}, err
+	}
+	t.Rounds = append(t.Rounds, rounds...)
+
+	return t, nil
+}
+
+func (d *tournamentDaoImpl) ListTournaments(ctx context.Context) ([]models.Tournament, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+tournamentColumns+" FROM tournaments t ORDER BY t.registration_opens_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	tournaments := []models.Tournament{}
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		t, err := scanTournament(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		index[*t.Id] = len(tournaments)
+		tournaments = append(tournaments, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rounds, err := d.listRounds(ctx, "TRUE", roundOrder)
+	if err != nil {
+		return nil, err
+	}
+	for _, round := range rounds {
+		if i, ok := index[round.TournamentId]; ok {
+			tournaments[i].Rounds = append(tournaments[i].Rounds, round)
+		}
+	}
+
+	return tournaments, nil
+}
+
+const roundOrder = "r.tournament_id, r.round_number"
+
+func (d *tournamentDaoImpl) listRounds(ctx context.Context, where string, orderBy string, args ...any) ([]models.TournamentRound, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+roundColumns+" FROM tournament_rounds r WHERE "+where+" ORDER BY "+orderBy, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var rounds []models.TournamentRound
+	for rows.Next() {
+		round, err := scanRound(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		rounds = append(rounds, round)
+	}
+
+	return rounds, rows.Err()
+}
+
+func (d *tournamentDaoImpl) RegisterParticipant(ctx context.Context, tournamentId uuid.UUID, userId uuid.UUID) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO tournament_participants (tournament_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", tournamentId, userId)
+	if err != nil {
+		return fmt.Errorf("error registering participant: %v", err)
+	}
+	return nil
+}
+
+// IsActiveParticipant reports whether the user registered and has not been
+// eliminated.
+func (d *tournamentDaoImpl) IsActiveParticipant(ctx context.Context, tournamentId uuid.UUID, userId uuid.UUID) (bool, error) {
+	var active bool
+	err := d.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM tournament_participants
+		WHERE tournament_id = $1 AND user_id = $2 AND eliminated_in_round IS NULL
+	)`, tournamentId, userId).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("query execution error: %v", err)
+	}
+	return active, nil
+}
+
+func (d *tournamentDaoImpl) GetRound(ctx context.Context, tournamentId uuid.UUID, roundNumber int) (models.TournamentRound, error) {
+	round, err := scanRound(d.db.QueryRowContext(ctx, "SELECT "+roundColumns+" FROM tournament_rounds r WHERE r.tournament_id = $1 AND r.round_number = $2", tournamentId, roundNumber))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.TournamentRound{}, err
+		}
+		return models.TournamentRound{}, fmt.Errorf("query execution error: %v", err)
+	}
+	return round, nil
+}
+
+func (d *tournamentDaoImpl) GetEntryQuizId(ctx context.Context, roundId uuid.UUID, userId uuid.UUID) (*uuid.UUID, error) {
+	var quizId uuid.UUID
+	err := d.db.QueryRowContext(ctx, "SELECT quiz_id FROM tournament_entries WHERE round_id = $1 AND user_id = $2", roundId, userId).Scan(&quizId)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	return &quizId, nil
+}
+
+// CreateEntry records the player's quiz for the round. It reports false when
+// the player already has one.
+func (d *tournamentDaoImpl) CreateEntry(ctx context.Context, roundId uuid.UUID, userId uuid.UUID, quizId uuid.UUID) (bool, error) {
+	res, err := d.db.ExecContext(ctx, "INSERT INTO tournament_entries (round_id, user_id, quiz_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", roundId, userId, quizId)
+	if err != nil {
+		return false, fmt.Errorf("error creating tournament entry: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error creating tournament entry: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ListDueRounds returns open rounds that should close and pending rounds
+// that should open, closings first. A round only opens once every earlier
+// round of its tournament has closed.
+func (d *tournamentDaoImpl) ListDueRounds(ctx context.Context, now time.Time) ([]models.TournamentRound, error) {
+	return d.listRounds(ctx, `(r.status = 'open' AND r.ends_at <= $1)
+		OR (r.status = 'pending' AND r.starts_at <= $1 AND NOT EXISTS (
+			SELECT 1 FROM tournament_rounds p
+			WHERE p.tournament_id = r.tournament_id AND p.round_number < r.round_number AND p.status <> 'closed'
+		))`, "r.status = 'pending', r.starts_at, r.round_number", now)
+}
+
+// ListSeededParticipants returns the players still in the tournament, best
+// seed first: by their score in the previous round, then by rating.
+func (d *tournamentDaoImpl) ListSeededParticipants(ctx context.Context, tournamentId uuid.UUID, previousRoundId *uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+	SELECT p.user_id
+	FROM tournament_participants p
+	JOIN users u ON u.id = p.user_id
+	LEFT JOIN tournament_entries e ON e.round_id = $2 AND e.user_id = p.user_id
+	WHERE p.tournament_id = $1 AND p.eliminated_in_round IS NULL
+	ORDER BY COALESCE(e.score, 0) DESC, u.rating DESC, u.username
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, tournamentId, previousRoundId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var userIds []uuid.UUID
+	for rows.Next() {
+		var userId uuid.UUID
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
+
+// OpenRound opens the round and stores its bracket pairings, if any. Opening
+// a round that is no longer pending does nothing.
+func (d *tournamentDaoImpl) OpenRound(ctx context.Context, round models.TournamentRound, pairings []tournament.Pairing) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE tournament_rounds SET status = 'open' WHERE id = $1 AND status = 'pending'", round.Id)
+	if err != nil {
+		return fmt.Errorf("error opening round: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	for _, pairing := range pairings {
+		_, err := tx.ExecContext(ctx, "INSERT INTO tournament_matches (round_id, player_one_id, player_two_id) VALUES ($1, $2, $3)",
+			round.Id, pairing.PlayerOne, pairing.PlayerTwo)
+		if err != nil {
+			return fmt.Errorf("error creating match: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE tournaments SET status = 'running', updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'scheduled'", round.TournamentId)
+	if err != nil {
+		return fmt.Errorf("error starting tournament: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// GetRoundResults returns the players still in the tournament and the
+// results of those who played the round. A quiz flagged for cheating scores
+// nothing until an admin clears it.
+func (d *tournamentDaoImpl) GetRoundResults(ctx context.Context, round models.TournamentRound) ([]uuid.UUID, map[uuid.UUID]tournament.Result, error) {
+	query := `
+	SELECT p.user_id, e.user_id IS NOT NULL,
+		CASE WHEN ` + quizFlagged("z.id") + ` THEN 0 ELSE COALESCE(z.score, 0) END, z.completed_at
+	FROM tournament_participants p
+	LEFT JOIN tournament_entries e ON e.round_id = $2 AND e.user_id = p.user_id
+	LEFT JOIN quiz z ON z.id = e.quiz_id
+	WHERE p.tournament_id = $1 AND p.eliminated_in_round IS NULL
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, round.TournamentId, round.Id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var players []uuid.UUID
+	results := map[uuid.UUID]tournament.Result{}
+	for rows.Next() {
+		var result tournament.Result
+		var played bool
+		if err := rows.Scan(&result.UserId, &played, &result.Score, &result.CompletedAt); err != nil {
+			return nil, nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		players = append(players, result.UserId)
+		if played {
+			results[result.UserId] = result
+		}
+	}
+
+	return players, results, rows.Err()
+}
+
+func (d *tournamentDaoImpl) ListRoundMatches(ctx context.Context, roundId uuid.UUID) ([]tournament.Match, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT id, player_one_id, player_two_id FROM tournament_matches WHERE round_id = $1", roundId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	var matches []tournament.Match
+	for rows.Next() {
+		var match tournament.Match
+		if err := rows.Scan(&match.Id, &match.PlayerOne, &match.PlayerTwo); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, rows.Err()
+}
+
+// CloseRound freezes the round's scores, knocks out eliminated players and
+// records match winners. Closing a round that is not open does nothing.
+func (d *tournamentDaoImpl) CloseRound(ctx context.Context, round models.TournamentRound, results map[uuid.UUID]tournament.Result, eliminated []uuid.UUID, winners map[uuid.UUID]uuid.UUID, finished bool) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE tournament_rounds SET status = 'closed' WHERE id = $1 AND status = 'open'", round.Id)
+	if err != nil {
+		return fmt.Errorf("error closing round: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	userIds := make([]string, 0, len(results))
+	scores := make([]int64, 0, len(results))
+	for userId, result := range results {
+		userIds = append(userIds, userId.String())
+		scores = append(scores, int64(result.Score))
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE tournament_entries e SET score = s.score
+		FROM unnest($2::uuid[], $3::int[]) AS s(user_id, score)
+		WHERE e.round_id = $1 AND e.user_id = s.user_id`, round.Id, pq.Array(userIds), pq.Array(scores))
+	if err != nil {
+		return fmt.Errorf("error freezing scores: %v", err)
+	}
+
+	eliminatedIds := make([]string, len(eliminated))
+	for i, userId := range eliminated {
+		eliminatedIds[i] = userId.String()
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE tournament_participants SET eliminated_in_round = $2 WHERE tournament_id = $1 AND user_id = ANY($3::uuid[])",
+		round.TournamentId, round.RoundNumber, pq.Array(eliminatedIds))
+	if err != nil {
+		return fmt.Errorf("error eliminating participants: %v", err)
+	}
+
+	for matchId, winnerId := range winners {
+		if _, err := tx.ExecContext(ctx, "UPDATE tournament_matches SET winner_id = $2 WHERE id = $1", matchId, winnerId); err != nil {
+			return fmt.Errorf("error recording match winner: %v", err)
+		}
+	}
+
+	if finished {
+		_, err = tx.ExecContext(ctx, "UPDATE tournaments SET status = 'finished', updated_at = CURRENT_TIMESTAMP WHERE id = $1", round.TournamentId)
+		if err != nil {
+			return fmt.Errorf("error finishing tournament: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// GetStandings ranks participants by how far they got, then by their total
+// frozen score. Scores of flagged quizzes are left out of the total.
+func (d *tournamentDaoImpl) GetStandings(ctx context.Context, tournamentId uuid.UUID) ([]models.TournamentStanding, error) {
+	query := `
+	SELECT RANK() OVER (ORDER BY p.eliminated_in_round DESC NULLS FIRST, COALESCE(SUM(e.score) FILTER (WHERE NOT ` + quizFlagged("e.quiz_id") + `), 0) DESC),
+		p.user_id, u.username, p.eliminated_in_round, COUNT(e.round_id),
+		COALESCE(SUM(e.score) FILTER (WHERE NOT ` + quizFlagged("e.quiz_id") + `), 0)
+	FROM tournament_participants p
+	JOIN users u ON u.id = p.user_id
+	LEFT JOIN tournament_rounds r ON r.tournament_id = p.tournament_id
+	LEFT JOIN tournament_entries e ON e.round_id = r.id AND e.user_id = p.user_id
+	WHERE p.tournament_id = $1
+	GROUP BY p.user_id, u.username, p.eliminated_in_round
+	ORDER BY 1, u.username
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	standings := []models.TournamentStanding{}
+	for rows.Next() {
+		var standing models.TournamentStanding
+		err := rows.Scan(&standing.Rank, &standing.UserId, &standing.UserName, &standing.EliminatedInRound, &standing.RoundsPlayed, &standing.TotalScore)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		standings = append(standings, standing)
+	}
+
+	return standings, rows.Err()
+}
+
+func (d *tournamentDaoImpl) ListMatches(ctx context.Context, tournamentId uuid.UUID) ([]models.TournamentMatch, error) {
+	query := `
+	SELECT m.id, r.round_number, m.player_one_id, u1.username, m.player_two_id, u2.username, m.winner_id
+	FROM tournament_matches m
+	JOIN tournament_rounds r ON r.id = m.round_id
+	JOIN users u1 ON u1.id = m.player_one_id
+	LEFT JOIN users u2 ON u2.id = m.player_two_id
+	WHERE r.tournament_id = $1
+	ORDER BY r.round_number, u1.username
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+	defer rows.Close()
+
+	matches := []models.TournamentMatch{}
+	for rows.Next() {
+		var match models.TournamentMatch
+		err := rows.Scan(&match.Id, &match.RoundNumber, &match.PlayerOneId, &match.PlayerOneName, &match.PlayerTwoId, &match.PlayerTwoName, &match.WinnerId)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, rows.Err()
+}
@@ -1,15 +1,39 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// ErrStaffAccount is returned when deleting a user who still holds a staff
+// role.
+var ErrStaffAccount = errors.New("user holds a staff role")
+
+// uniqueViolation is the Postgres error code for a unique constraint failure.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err is Postgres rejecting a duplicate key.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
 type UserDao interface {
-	GetUser(id int) (models.User, error)
-	CreateUser(user models.User) (models.User, error)
-	GetUserByName(name string) (models.User, error)
+	CreateUser(ctx context.Context, user models.User, usernameKey string, secretHash []byte) (models.User, error)
+	GetUserByUsernameKey(ctx context.Context, usernameKey string) (models.User, error)
+	ListTakenUsernameKeys(ctx context.Context, usernameKeys []string) ([]string, error)
+	GetUserLocale(ctx context.Context, userId uuid.UUID) (string, error)
+	GetUserById(ctx context.Context, userId uuid.UUID) (models.User, error)
+	GetUserSecretHash(ctx context.Context, userId uuid.UUID) ([]byte, error)
+	SetUserSecretHash(ctx context.Context, userId uuid.UUID, secretHash []byte) error
+	UpdateUserProfile(ctx context.Context, user models.User) (models.User, error)
+	DeleteUser(ctx context.Context, userId uuid.UUID, deletedPrefix string) error
 }
 
 type userDaoImpl struct {
@@ -22,48 +46,157 @@ func NewUserDao(db *sql.DB) UserDao {
 	}
 }
 
-func (u *userDaoImpl) GetUser(id int) (models.User, error) {
+const userColumns = `id, username, COALESCE(display_name, ''), COALESCE(avatar_url, ''), COALESCE(home_country, ''), score, COALESCE(locale, ''),
+	quiz_length, COALESCE(quiz_difficulty, ''), quiz_timer_seconds, rating, rated_games, deleted_at, created_at, updated_at`
+
+// userScanDest returns the scan destinations matching userColumns.
+func userScanDest(user *models.User) []any {
+	return []any{&user.Id, &user.Name, &user.DisplayName, &user.AvatarUrl, &user.HomeCountry, &user.Score, &user.Locale,
+		&user.QuizPreferences.Length, &user.QuizPreferences.Difficulty, &user.QuizPreferences.TimerSeconds,
+		&user.Rating, &user.RatedGames, &user.DeletedAt, &user.CreatedAt, &user.UpdatedAt}
+}
+
+// CreateUser inserts the user under usernameKey. A key that is already taken
+// fails with a unique violation; see IsUniqueViolation.
+func (u *userDaoImpl) CreateUser(ctx context.Context, user models.User, usernameKey string, secretHash []byte) (models.User, error) {
+	var newUser models.User
+	err := u.db.QueryRowContext(ctx, "INSERT INTO users (username, username_key, locale, secret_hash) VALUES ($1, $2, NULLIF($3, ''), $4) RETURNING "+userColumns,
+		user.Name, usernameKey, user.Locale, secretHash).Scan(userScanDest(&newUser)...)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return newUser, nil
+}
+
+// GetUserByUsernameKey returns sql.ErrNoRows when no live user has the key.
+func (u *userDaoImpl) GetUserByUsernameKey(ctx context.Context, usernameKey string) (models.User, error) {
 	var user models.User
-	res, err := u.db.Query("SELECT * FROM users WHERE id = $1", id)
+	err := u.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username_key = $1 AND deleted_at IS NULL", usernameKey).Scan(userScanDest(&user)...)
 	if err != nil {
 		return models.User{}, err
 	}
-	defer res.Close()
+	return user, nil
+}
 
-	if res.Next() {
-		err = res.Scan(&user.Id, &user.Name, &user.Score, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			return models.User{}, err
-		}
+// ListTakenUsernameKeys returns the keys in usernameKeys that belong to a
+// user, deleted or not.
+func (u *userDaoImpl) ListTakenUsernameKeys(ctx context.Context, usernameKeys []string) ([]string, error) {
+	var taken []string
+	err := u.db.QueryRowContext(ctx, "SELECT COALESCE(array_agg(username_key), '{}') FROM users WHERE username_key = ANY($1)", pq.Array(usernameKeys)).Scan(pq.Array(&taken))
+	if err != nil {
+		return nil, fmt.Errorf("query execution error: %v", err)
 	}
+	return taken, nil
+}
 
-	return user, nil
+func (u *userDaoImpl) GetUserLocale(ctx context.Context, userId uuid.UUID) (string, error) {
+	var locale string
+	err := u.db.QueryRowContext(ctx, "SELECT COALESCE(locale, '') FROM users WHERE id = $1", userId).Scan(&locale)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return locale, nil
 }
 
-func (u *userDaoImpl) CreateUser(user models.User) (models.User, error) {
-	var newUser models.User
-	err := u.db.QueryRow("INSERT INTO users (username) VALUES ($1) RETURNING id, username, score, created_at, updated_at", user.Name).Scan(&newUser.Id, &newUser.Name, &newUser.Score, &newUser.CreatedAt, &newUser.UpdatedAt)
+func (u *userDaoImpl) GetUserById(ctx context.Context, userId uuid.UUID) (models.User, error) {
+	var user models.User
+	err := u.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userId).Scan(userScanDest(&user)...)
 	if err != nil {
 		return models.User{}, err
 	}
+	return user, nil
+}
 
-	return newUser, nil
+// GetUserSecretHash returns the hash of the user's sign-in secret, or nil if
+// they have none.
+func (u *userDaoImpl) GetUserSecretHash(ctx context.Context, userId uuid.UUID) ([]byte, error) {
+	var secretHash []byte
+	err := u.db.QueryRowContext(ctx, "SELECT secret_hash FROM users WHERE id = $1 AND deleted_at IS NULL", userId).Scan(&secretHash)
+	if err != nil {
+		return nil, err
+	}
+	return secretHash, nil
 }
 
-func (u *userDaoImpl) GetUserByName(name string) (models.User, error) {
-	var user models.User
-	res, err := u.db.Query("SELECT * FROM users WHERE username = $1", name)
+// SetUserSecretHash replaces the user's sign-in secret. Deleted users come
+// back as sql.ErrNoRows.
+func (u *userDaoImpl) SetUserSecretHash(ctx context.Context, userId uuid.UUID, secretHash []byte) error {
+	res, err := u.db.ExecContext(ctx, "UPDATE users SET secret_hash = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", userId, secretHash)
+	if err != nil {
+		return fmt.Errorf("error updating secret: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("error updating secret: %v", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateUserProfile saves the user's profile fields and quiz preferences.
+// Deleted users cannot be updated and come back as sql.ErrNoRows.
+func (u *userDaoImpl) UpdateUserProfile(ctx context.Context, user models.User) (models.User, error) {
+	query := `
+	UPDATE users
+	SET display_name = NULLIF($2, ''), avatar_url = NULLIF($3, ''), home_country = NULLIF($4, ''), locale = NULLIF($5, ''),
+		quiz_length = $6, quiz_difficulty = NULLIF($7, ''), quiz_timer_seconds = $8, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING ` + userColumns
+
+	var updated models.User
+	err := u.db.QueryRowContext(ctx, query, user.Id, user.DisplayName, user.AvatarUrl, user.HomeCountry, user.Locale,
+		user.QuizPreferences.Length, user.QuizPreferences.Difficulty, user.QuizPreferences.TimerSeconds).Scan(userScanDest(&updated)...)
 	if err != nil {
 		return models.User{}, err
 	}
-	defer res.Close()
+	return updated, nil
+}
+
+// DeleteUser anonymizes the user in place, renaming them to deletedPrefix
+// followed by their id. The row stays so quizzes, ratings
+// and tournament results keep pointing at it, but everything that identifies
+// the player is cleared and their social ties are removed. Staff must be
+// demoted first, so that roles only change through SetRole; they get
+// ErrStaffAccount.
+func (u *userDaoImpl) DeleteUser(ctx context.Context, userId uuid.UUID, deletedPrefix string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var role string
+	err = tx.QueryRowContext(ctx, "SELECT role FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userId).Scan(&role)
+	if err != nil {
+		return err
+	}
+	if role != models.RolePlayer {
+		return ErrStaffAccount
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE users
+	SET username = $2 || id::text, username_key = $2 || id::text, display_name = NULL, avatar_url = NULL, home_country = NULL, locale = NULL,
+		quiz_length = NULL, quiz_difficulty = NULL, quiz_timer_seconds = NULL, secret_hash = NULL,
+		deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	`, userId, deletedPrefix)
+	if err != nil {
+		return fmt.Errorf("error anonymizing user: %v", err)
+	}
 
-	if res.Next() {
-		err = res.Scan(&user.Id, &user.Name, &user.Score, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			return models.User{}, err
+	for _, query := range []string{
+		"DELETE FROM friendships WHERE user_id = $1 OR friend_id = $1",
+		"DELETE FROM friend_requests WHERE sender_id = $1 OR recipient_id = $1",
+		"DELETE FROM user_blocks WHERE blocker_id = $1 OR blocked_id = $1",
+		"DELETE FROM group_members WHERE user_id = $1",
+		"UPDATE quiz SET client_ip = NULL, device_id = NULL WHERE user_id = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+			return fmt.Errorf("error removing user links: %v", err)
 		}
 	}
 
-	return user, nil
+	return tx.Commit()
 }
@@ -1,28 +1,72 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/axitdhola/globetrotter/server/config"
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/lib/pq"
 )
 
+// maxConnectBackoff caps the wait between connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
 type Database struct {
 	db *sql.DB
 }
 
-func NewDatabase() (*Database, error) {
-	dbString := os.Getenv("DATABASE_URL")
-	fmt.Println(dbString)
-	db, err := sql.Open("postgres", dbString)
+// NewDatabase opens a connection pool. Every statement it runs is reported
+// to observers; see LogQueries.
+func NewDatabase(cfg config.Database, observers ...Observer) (*Database, error) {
+	pqConnector, err := pq.NewConnector(cfg.URL)
 	if err != nil {
-		return nil, err
+		// Parse errors quote the URL, password included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid database url: %v", err)
 	}
+	db := sql.OpenDB(&connector{Connector: pqConnector, observers: observers})
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
 
 	return &Database{db: db}, nil
 }
 
+// Connect pings the database until it answers, doubling the wait between
+// attempts from backoff. sql.Open does not connect, so this is the first
+// time the server learns whether the database is reachable.
+func (d *Database) Connect(ctx context.Context, attempts int, backoff time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = d.db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		logging.FromContext(ctx).Warn("database not ready", "attempt", attempt, "attempts", attempts, "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+	return fmt.Errorf("database unreachable after %d attempts: %v", attempts, err)
+}
+
 func (d *Database) Close() {
 	d.db.Close()
 }
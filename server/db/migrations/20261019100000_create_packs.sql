@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS packs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    cover_image_url TEXT,
+    cover_color VARCHAR(32),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pack_questions (
+    pack_id UUID REFERENCES packs(id) ON DELETE CASCADE,
+    question_id UUID REFERENCES questions(id),
+    position INT NOT NULL,
+    PRIMARY KEY (pack_id, question_id)
+);
+
+ALTER TABLE quiz
+    ADD COLUMN IF NOT EXISTS pack_id UUID REFERENCES packs(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS shuffle BOOLEAN DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_pack_id ON quiz(pack_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_quiz_pack_id;
+ALTER TABLE quiz
+    DROP COLUMN IF EXISTS shuffle,
+    DROP COLUMN IF EXISTS pack_id;
+DROP TABLE pack_questions;
+DROP TABLE packs;
+-- +goose StatementEnd
@@ -0,0 +1,94 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS continent VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS region VARCHAR(128);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (lower(name));
+
+CREATE TABLE IF NOT EXISTS question_tags (
+    question_id UUID REFERENCES questions(id) ON DELETE CASCADE,
+    tag_id UUID REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (question_id, tag_id)
+);
+
+ALTER TABLE quiz
+    ADD COLUMN IF NOT EXISTS include_tags TEXT[] DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS exclude_tags TEXT[] DEFAULT '{}';
+
+UPDATE questions q SET continent = c.continent, region = c.region
+FROM (VALUES
+    ('France', 'Europe', 'Western Europe'),
+    ('Netherlands', 'Europe', 'Western Europe'),
+    ('Austria', 'Europe', 'Western Europe'),
+    ('Germany', 'Europe', 'Western Europe'),
+    ('Switzerland', 'Europe', 'Western Europe'),
+    ('Sweden', 'Europe', 'Northern Europe'),
+    ('Ireland', 'Europe', 'Northern Europe'),
+    ('Scotland', 'Europe', 'Northern Europe'),
+    ('Portugal', 'Europe', 'Southern Europe'),
+    ('Greece', 'Europe', 'Southern Europe'),
+    ('Spain', 'Europe', 'Southern Europe'),
+    ('Italy', 'Europe', 'Southern Europe'),
+    ('Russia', 'Europe', 'Eastern Europe'),
+    ('Czech Republic', 'Europe', 'Eastern Europe'),
+    ('Japan', 'Asia', 'Eastern Asia'),
+    ('South Korea', 'Asia', 'Eastern Asia'),
+    ('China', 'Asia', 'Eastern Asia'),
+    ('Thailand', 'Asia', 'South-eastern Asia'),
+    ('Singapore', 'Asia', 'South-eastern Asia'),
+    ('India', 'Asia', 'Southern Asia'),
+    ('Turkey', 'Asia', 'Western Asia'),
+    ('UAE', 'Asia', 'Western Asia'),
+    ('USA', 'North America', 'Northern America'),
+    ('Canada', 'North America', 'Northern America'),
+    ('Brazil', 'South America', 'South America'),
+    ('Argentina', 'South America', 'South America'),
+    ('South Africa', 'Africa', 'Southern Africa'),
+    ('Egypt', 'Africa', 'Northern Africa'),
+    ('Australia', 'Oceania', 'Australia and New Zealand')
+) AS c(country, continent, region)
+WHERE q.country = c.country;
+
+INSERT INTO tags (name) VALUES ('capital'), ('coastal'), ('UNESCO')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO question_tags (question_id, tag_id)
+SELECT q.id, t.id
+FROM questions q
+JOIN (VALUES
+    ('Paris', 'capital'), ('Tokyo', 'capital'), ('Moscow', 'capital'), ('Seoul', 'capital'),
+    ('Bangkok', 'capital'), ('Buenos Aires', 'capital'), ('Cairo', 'capital'), ('Lisbon', 'capital'),
+    ('Amsterdam', 'capital'), ('Athens', 'capital'), ('Vienna', 'capital'), ('Prague', 'capital'),
+    ('Stockholm', 'capital'), ('Dublin', 'capital'), ('Edinburgh', 'capital'), ('Berlin', 'capital'),
+    ('Singapore', 'capital'),
+    ('New York', 'coastal'), ('Sydney', 'coastal'), ('Rio de Janeiro', 'coastal'), ('Cape Town', 'coastal'),
+    ('Mumbai', 'coastal'), ('Istanbul', 'coastal'), ('Dubai', 'coastal'), ('Lisbon', 'coastal'),
+    ('Barcelona', 'coastal'), ('Venice', 'coastal'), ('San Francisco', 'coastal'), ('Hong Kong', 'coastal'),
+    ('Singapore', 'coastal'), ('Stockholm', 'coastal'), ('Dublin', 'coastal'),
+    ('Paris', 'UNESCO'), ('Rio de Janeiro', 'UNESCO'), ('Istanbul', 'UNESCO'), ('Cairo', 'UNESCO'),
+    ('Prague', 'UNESCO'), ('Vienna', 'UNESCO'), ('Venice', 'UNESCO'), ('Kyoto', 'UNESCO'),
+    ('Florence', 'UNESCO'), ('Edinburgh', 'UNESCO'), ('Amsterdam', 'UNESCO'), ('Barcelona', 'UNESCO'),
+    ('Lisbon', 'UNESCO'), ('Athens', 'UNESCO'), ('Moscow', 'UNESCO'), ('Mumbai', 'UNESCO')
+) AS seed(city, tag) ON q.city = seed.city
+JOIN tags t ON lower(t.name) = lower(seed.tag)
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE quiz
+    DROP COLUMN IF EXISTS exclude_tags,
+    DROP COLUMN IF EXISTS include_tags;
+DROP TABLE question_tags;
+DROP TABLE tags;
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS region,
+    DROP COLUMN IF EXISTS continent;
+-- +goose StatementEnd
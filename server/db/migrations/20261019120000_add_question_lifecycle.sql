@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'in_review', 'published', 'retired')),
+    ADD COLUMN IF NOT EXISTS created_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS updated_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- New questions start as drafts; everything already in the table stays live.
+ALTER TABLE questions ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_questions_status ON questions(status) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_questions_status;
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS question_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    question_id UUID NOT NULL REFERENCES questions(id),
+    revision_number INT NOT NULL,
+    city VARCHAR(255) NOT NULL,
+    country VARCHAR(255) NOT NULL,
+    clues TEXT[],
+    fun_fact TEXT[],
+    trivia TEXT[],
+    options TEXT[],
+    correct_answer INT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (question_id, revision_number)
+);
+
+CREATE OR REPLACE FUNCTION forbid_question_revision_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'question revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER question_revisions_immutable
+    BEFORE UPDATE ON question_revisions
+    FOR EACH ROW EXECUTE FUNCTION forbid_question_revision_update();
+
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS current_revision_id UUID REFERENCES question_revisions(id);
+
+ALTER TABLE quiz_questions
+    ADD COLUMN IF NOT EXISTS revision_id UUID REFERENCES question_revisions(id);
+
+-- issued_questions remembers which revision a player was shown, so the answer
+-- is graded and replayed against exactly that content.
+CREATE TABLE IF NOT EXISTS issued_questions (
+    quiz_id UUID REFERENCES quiz(id) ON DELETE CASCADE,
+    question_id UUID REFERENCES questions(id),
+    revision_id UUID REFERENCES question_revisions(id),
+    issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (quiz_id, question_id)
+);
+
+INSERT INTO question_revisions (question_id, revision_number, city, country, clues, fun_fact, trivia, options, correct_answer, created_by, created_at)
+SELECT id, 1, city, country, clues, fun_fact, trivia, options, correct_answer, created_by, COALESCE(updated_at, created_at)
+FROM questions;
+
+UPDATE questions q SET current_revision_id = r.id
+FROM question_revisions r
+WHERE r.question_id = q.id;
+
+UPDATE quiz_questions qq SET revision_id = q.current_revision_id
+FROM questions q
+WHERE q.id = qq.question_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE issued_questions;
+ALTER TABLE quiz_questions DROP COLUMN IF EXISTS revision_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS current_revision_id;
+DROP TABLE question_revisions;
+DROP FUNCTION IF EXISTS forbid_question_revision_update();
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS question_translations (
+    question_id UUID REFERENCES questions(id) ON DELETE CASCADE,
+    locale VARCHAR(16) NOT NULL,
+    city VARCHAR(255),
+    country VARCHAR(255),
+    clues TEXT[],
+    fun_fact TEXT[],
+    trivia TEXT[],
+    options TEXT[],
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (question_id, locale)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(16);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
+DROP TABLE question_translations;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE quiz_questions ADD COLUMN IF NOT EXISTS response_time_ms INT;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_user_id ON quiz(user_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_questions_quiz_id ON quiz_questions(quiz_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_quiz_questions_quiz_id;
+DROP INDEX IF EXISTS idx_quiz_user_id;
+ALTER TABLE quiz_questions DROP COLUMN IF EXISTS response_time_ms;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE quiz
+    ADD COLUMN IF NOT EXISTS challenge_quiz_id UUID REFERENCES quiz(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    achievement_id VARCHAR(64) NOT NULL,
+    quiz_id UUID REFERENCES quiz(id) ON DELETE SET NULL,
+    earned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, achievement_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_achievements;
+ALTER TABLE quiz
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS challenge_quiz_id;
+-- +goose StatementEnd
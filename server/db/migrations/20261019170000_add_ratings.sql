@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS rating DOUBLE PRECISION NOT NULL DEFAULT 1500,
+    ADD COLUMN IF NOT EXISTS rated_games INT NOT NULL DEFAULT 0;
+
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS rating DOUBLE PRECISION NOT NULL DEFAULT 1500,
+    ADD COLUMN IF NOT EXISTS rated_games INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_users_rating ON users(rating DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_rating;
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS rated_games,
+    DROP COLUMN IF EXISTS rating;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS rated_games,
+    DROP COLUMN IF EXISTS rating;
+-- +goose StatementEnd
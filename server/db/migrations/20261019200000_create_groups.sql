@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code VARCHAR(16) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id UUID REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- An assignment is either a pack or a seeded quiz that members replay.
+CREATE TABLE IF NOT EXISTS assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    pack_id UUID REFERENCES packs(id) ON DELETE CASCADE,
+    seed_quiz_id UUID REFERENCES quiz(id) ON DELETE CASCADE,
+    due_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((pack_id IS NULL) <> (seed_quiz_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignments_group_id ON assignments(group_id);
+
+ALTER TABLE quiz ADD COLUMN IF NOT EXISTS assignment_id UUID REFERENCES assignments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_assignment_id ON quiz(assignment_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_quiz_assignment_id;
+ALTER TABLE quiz DROP COLUMN IF EXISTS assignment_id;
+DROP INDEX IF EXISTS idx_assignments_group_id;
+DROP TABLE assignments;
+DROP TABLE group_members;
+DROP TABLE groups;
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tournaments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'scheduled',
+    registration_opens_at TIMESTAMP NOT NULL,
+    registration_closes_at TIMESTAMP NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (registration_opens_at < registration_closes_at)
+);
+
+-- Each round plays a fixed question set, taken from a pack in order.
+CREATE TABLE IF NOT EXISTS tournament_rounds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tournament_id UUID NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    round_number INT NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    advance_count INT,
+    pack_id UUID NOT NULL REFERENCES packs(id),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    UNIQUE (tournament_id, round_number),
+    CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_rounds_schedule ON tournament_rounds(status, starts_at, ends_at);
+
+CREATE TABLE IF NOT EXISTS tournament_participants (
+    tournament_id UUID REFERENCES tournaments(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    registered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    eliminated_in_round INT,
+    PRIMARY KEY (tournament_id, user_id)
+);
+
+-- score is frozen when the round closes; answers after that do not count.
+CREATE TABLE IF NOT EXISTS tournament_entries (
+    round_id UUID REFERENCES tournament_rounds(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    quiz_id UUID NOT NULL REFERENCES quiz(id) ON DELETE CASCADE,
+    score INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (round_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_matches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    round_id UUID NOT NULL REFERENCES tournament_rounds(id) ON DELETE CASCADE,
+    player_one_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    player_two_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    winner_id UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_matches_round_id ON tournament_matches(round_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tournament_matches_round_id;
+DROP TABLE tournament_matches;
+DROP TABLE tournament_entries;
+DROP TABLE tournament_participants;
+DROP INDEX IF EXISTS idx_tournament_rounds_schedule;
+DROP TABLE tournament_rounds;
+DROP TABLE tournaments;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS avatar_url TEXT,
+    ADD COLUMN IF NOT EXISTS home_country VARCHAR(2),
+    ADD COLUMN IF NOT EXISTS quiz_length INT,
+    ADD COLUMN IF NOT EXISTS quiz_difficulty VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS quiz_timer_seconds INT,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Quiz settings are copied onto each quiz so later profile edits do not
+-- change quizzes already under way.
+ALTER TABLE quiz
+    ADD COLUMN IF NOT EXISTS question_limit INT,
+    ADD COLUMN IF NOT EXISTS difficulty VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS timer_seconds INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE quiz
+    DROP COLUMN IF EXISTS timer_seconds,
+    DROP COLUMN IF EXISTS difficulty,
+    DROP COLUMN IF EXISTS question_limit;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS quiz_timer_seconds,
+    DROP COLUMN IF EXISTS quiz_difficulty,
+    DROP COLUMN IF EXISTS quiz_length,
+    DROP COLUMN IF EXISTS home_country,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS display_name;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- username_key is the case-folded name that must be unique. The server
+-- computes it for new names; existing names are keyed with lower(), which
+-- agrees with case folding for the ASCII names registered so far.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_key VARCHAR(255);
+
+UPDATE users SET username_key = lower(btrim(username));
+
+-- Names that only differed by case keep the oldest registration's key; the
+-- others get a suffix so the unique index can be built.
+UPDATE users u
+SET username_key = u.username_key || '-' || left(u.id::text, 8)
+WHERE EXISTS (
+    SELECT 1 FROM users o
+    WHERE o.username_key = u.username_key AND (COALESCE(o.created_at, 'epoch'), o.id) < (COALESCE(u.created_at, 'epoch'), u.id)
+);
+
+ALTER TABLE users ALTER COLUMN username_key SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_key ON users(username_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_username_key;
+ALTER TABLE users DROP COLUMN IF EXISTS username_key;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Where a quiz was started from, so accounts sharing an address or device
+-- can be spotted. Cleared when the player deletes their account.
+ALTER TABLE quiz
+    ADD COLUMN IF NOT EXISTS client_ip VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS device_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_client_ip ON quiz(client_ip, created_at) WHERE client_ip IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_quiz_device_id ON quiz(device_id, created_at) WHERE device_id IS NOT NULL;
+
+-- Finds quizzes that opened with the same question, to compare sequences.
+CREATE INDEX IF NOT EXISTS idx_quiz_questions_question_order ON quiz_questions(question_id, order_number);
+
+-- A flagged quiz is left out of leaderboards until an admin clears it.
+CREATE TABLE IF NOT EXISTS quiz_flags (
+    quiz_id UUID PRIMARY KEY REFERENCES quiz(id) ON DELETE CASCADE,
+    reasons JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    flagged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by VARCHAR(255),
+    reviewed_at TIMESTAMP,
+    CHECK (status IN ('open', 'cleared', 'confirmed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_flags_status ON quiz_flags(status, flagged_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS quiz_flags;
+DROP INDEX IF EXISTS idx_quiz_questions_question_order;
+DROP INDEX IF EXISTS idx_quiz_device_id;
+DROP INDEX IF EXISTS idx_quiz_client_ip;
+ALTER TABLE quiz
+    DROP COLUMN IF EXISTS device_id,
+    DROP COLUMN IF EXISTS client_ip;
+-- +goose StatementEnd
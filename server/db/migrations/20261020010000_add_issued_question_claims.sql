@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- position is where the question was issued in its quiz, as bound into its
+-- question token. answered_at is set by the first answer carrying that
+-- token, so the token cannot be replayed.
+ALTER TABLE issued_questions
+    ADD COLUMN IF NOT EXISTS position INT,
+    ADD COLUMN IF NOT EXISTS answered_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE issued_questions
+    DROP COLUMN IF EXISTS answered_at,
+    DROP COLUMN IF EXISTS position;
+-- +goose StatementEnd
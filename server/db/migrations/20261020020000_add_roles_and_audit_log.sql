@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Staff reach the /admin routes with a personal key, of which only the
+-- SHA-256 hash is kept.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'player',
+    ADD COLUMN IF NOT EXISTS staff_key_hash BYTEA;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_role_check CHECK (role IN ('player', 'moderator', 'admin'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_staff_key_hash ON users(staff_key_hash) WHERE staff_key_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_staff ON users(role) WHERE role <> 'player';
+
+-- audit_log records every privileged action. actor_id has no foreign key so
+-- that entries outlive the accounts that made them.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id UUID,
+    actor VARCHAR(255) NOT NULL,
+    actor_role VARCHAR(16) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    target JSONB NOT NULL DEFAULT '{}',
+    status INT,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP INDEX IF EXISTS idx_users_staff;
+DROP INDEX IF EXISTS idx_users_staff_key_hash;
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_role_check,
+    DROP COLUMN IF EXISTS staff_key_hash,
+    DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Players sign in with a secret issued once at registration, of which only
+-- the SHA-256 hash is kept. Accounts created before this have none until an
+-- operator issues one.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS secret_hash BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS secret_hash;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- question_translations is the editable copy; every revision keeps the
+-- translations it was published with, so a question is served, graded and
+-- replayed in any locale exactly as that revision had it.
+CREATE TABLE IF NOT EXISTS question_revision_translations (
+    revision_id UUID NOT NULL REFERENCES question_revisions(id),
+    question_id UUID NOT NULL REFERENCES questions(id),
+    locale VARCHAR(16) NOT NULL,
+    city VARCHAR(255),
+    country VARCHAR(255),
+    clues TEXT[],
+    fun_fact TEXT[],
+    trivia TEXT[],
+    options TEXT[],
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    PRIMARY KEY (revision_id, locale)
+);
+
+CREATE TRIGGER question_revision_translations_immutable
+    BEFORE UPDATE ON question_revision_translations
+    FOR EACH ROW EXECUTE FUNCTION forbid_question_revision_update();
+
+INSERT INTO question_revision_translations (revision_id, question_id, locale, city, country, clues, fun_fact, trivia, options, created_at, updated_at)
+SELECT q.current_revision_id, t.question_id, t.locale, t.city, t.country, t.clues, t.fun_fact, t.trivia, t.options, t.created_at, t.updated_at
+FROM question_translations t
+JOIN questions q ON q.id = t.question_id
+WHERE q.current_revision_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE question_revision_translations;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+// Package migrations embeds the goose migrations so the server can tell
+// whether the database schema is up to date.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion is the version of the newest migration, taken from the
+// timestamp that starts its file name.
+func LatestVersion() (int64, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
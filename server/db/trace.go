@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/logging"
+)
+
+// Query describes one round trip to the database.
+type Query struct {
+	// Op is "query", "exec", "prepare", "begin", "commit" or "rollback".
+	Op  string
+	SQL string
+	// Caller names the function that ran the statement, such as
+	// "dao.quizDaoImpl.GetQuizById".
+	Caller string
+	// Start is when the statement was sent; Duration runs until the driver
+	// returned, which for queries is before their rows are read.
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Observer is told about every query once it has finished. ctx is the
+// caller's context, so observers can use its logger or trace.
+type Observer func(ctx context.Context, q Query)
+
+// LogQueries logs every query at debug level and those that take at least
+// slowThreshold as warnings. Arguments are never logged, since they can
+// hold user data.
+func LogQueries(slowThreshold time.Duration) Observer {
+	return func(ctx context.Context, q Query) {
+		level := slog.LevelDebug
+		msg := "database query"
+		if q.Duration >= slowThreshold {
+			level = slog.LevelWarn
+			msg = "slow database query"
+		}
+
+		attrs := []slog.Attr{
+			slog.String("op", q.Op),
+			slog.Duration("duration", q.Duration),
+		}
+		if q.Caller != "" {
+			attrs = append(attrs, slog.String("caller", q.Caller))
+		}
+		if q.SQL != "" {
+			attrs = append(attrs, slog.String("sql", q.SQL))
+		}
+		if q.Err != nil {
+			attrs = append(attrs, slog.String("error", q.Err.Error()))
+		}
+		logging.FromContext(ctx).LogAttrs(ctx, level, msg, attrs...)
+	}
+}
+
+// tracedConn is the subset of a lib/pq connection that database/sql uses.
+type tracedConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// connector wraps another connector so observers see every statement.
+type connector struct {
+	driver.Connector
+	observers []Observer
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	raw, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	traced, ok := raw.(tracedConn)
+	if !ok {
+		raw.Close()
+		return nil, errors.New("database driver does not support contexts")
+	}
+	return &conn{tracedConn: traced, observers: c.observers}, nil
+}
+
+type conn struct {
+	tracedConn
+	observers []Observer
+}
+
+func (c *conn) observe(ctx context.Context, op, query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		// database/sql retries another way, which is observed instead.
+		return
+	}
+	q := Query{Op: op, SQL: query, Caller: caller(), Start: start, Duration: time.Since(start), Err: err}
+	for _, observer := range c.observers {
+		observer(ctx, q)
+	}
+}
+
+// caller returns the name of the first function on the stack outside this
+// package and database/sql.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		name := frame.Function
+		if !strings.HasPrefix(name, "database/sql.") && !strings.HasPrefix(name, thisPackage+".") {
+			return shortFuncName(name)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+var thisPackage = reflect.TypeOf(conn{}).PkgPath()
+
+// shortFuncName turns "github.com/a/b/dao.(*quizDaoImpl).Get.func1" into
+// "dao.quizDaoImpl.Get".
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 || strings.Trim(name[i+len(".func"):], "0123456789.") != "" {
+			return name
+		}
+		name = name[:i]
+	}
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.tracedConn.QueryContext(ctx, query, args)
+	c.observe(ctx, "query", query, start, err)
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.tracedConn.ExecContext(ctx, query, args)
+	c.observe(ctx, "exec", query, start, err)
+	return res, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	stmt, err := c.tracedConn.PrepareContext(ctx, query)
+	c.observe(ctx, "prepare", query, start, err)
+	return stmt, err
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	tx, err := c.tracedConn.BeginTx(ctx, opts)
+	c.observe(ctx, "begin", "", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx, ctx: ctx, conn: c}, nil
+}
+
+// tracedTx reports commits and rollbacks against the context the
+// transaction was started with.
+type tracedTx struct {
+	driver.Tx
+	ctx  context.Context
+	conn *conn
+}
+
+func (t *tracedTx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	t.conn.observe(t.ctx, "commit", "", start, err)
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	t.conn.observe(t.ctx, "rollback", "", start, err)
+	return err
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler interface {
+	BackfillAchievements(c *gin.Context)
+}
+
+type achievementHandler struct {
+	achievementService services.AchievementService
+}
+
+func NewAchievementHandler(achievementService services.AchievementService) AchievementHandler {
+	return &achievementHandler{achievementService: achievementService}
+}
+
+func (a *achievementHandler) BackfillAchievements(c *gin.Context) {
+	res, err := a.achievementService.BackfillAchievements(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AntiCheatHandler interface {
+	ListQuizFlags(c *gin.Context)
+	ReviewQuizFlag(c *gin.Context)
+}
+
+type antiCheatHandler struct {
+	antiCheatService services.AntiCheatService
+}
+
+func NewAntiCheatHandler(antiCheatService services.AntiCheatService) AntiCheatHandler {
+	return &antiCheatHandler{antiCheatService: antiCheatService}
+}
+
+// ListQuizFlags lists flagged quizzes, open ones unless ?status= asks for
+// cleared, confirmed or all of them.
+func (a *antiCheatHandler) ListQuizFlags(c *gin.Context) {
+	status := c.DefaultQuery("status", models.QuizFlagOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	res, err := a.antiCheatService.ListFlags(c.Request.Context(), status)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *antiCheatHandler) ReviewQuizFlag(c *gin.Context) {
+	quizId, err := uuid.Parse(c.Param("quiz_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input models.QuizFlagReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	middleware.AuditDetail(c, "status", input.Status)
+
+	res, err := a.antiCheatService.ReviewFlag(c.Request.Context(), quizId, input.Status, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// respondError answers with the status and body for err's kind. Errors the
+// services did not classify are internal server errors; those are attached
+// to the request so the access log records what went wrong.
+func respondError(c *gin.Context, err error) {
+	if apperrors.KindOf(err) == apperrors.Internal {
+		c.Error(err)
+	}
+	c.JSON(apperrors.HTTPStatus(err), apperrors.ResponseOf(err))
+}
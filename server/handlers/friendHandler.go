@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FriendHandler interface {
+	ListFriends(c *gin.Context)
+	RemoveFriend(c *gin.Context)
+	ListFriendRequests(c *gin.Context)
+	SendFriendRequest(c *gin.Context)
+	AcceptFriendRequest(c *gin.Context)
+	DeclineFriendRequest(c *gin.Context)
+	BlockUser(c *gin.Context)
+	UnblockUser(c *gin.Context)
+	GetFriendsLeaderboard(c *gin.Context)
+	GetFriendActivity(c *gin.Context)
+}
+
+type friendHandler struct {
+	friendService services.FriendService
+}
+
+func NewFriendHandler(friendService services.FriendService) FriendHandler {
+	return &friendHandler{friendService: friendService}
+}
+
+func (f *friendHandler) ListFriends(c *gin.Context) {
+	res, err := f.friendService.ListFriends(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (f *friendHandler) RemoveFriend(c *gin.Context) {
+	friendId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := f.friendService.RemoveFriend(c.Request.Context(), middleware.CurrentUser(c), friendId); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (f *friendHandler) ListFriendRequests(c *gin.Context) {
+	res, err := f.friendService.ListFriendRequests(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (f *friendHandler) SendFriendRequest(c *gin.Context) {
+	var input models.FriendRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := f.friendService.SendFriendRequest(c.Request.Context(), middleware.CurrentUser(c), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (f *friendHandler) AcceptFriendRequest(c *gin.Context) {
+	f.respondToFriendRequest(c, true)
+}
+
+func (f *friendHandler) DeclineFriendRequest(c *gin.Context) {
+	f.respondToFriendRequest(c, false)
+}
+
+func (f *friendHandler) respondToFriendRequest(c *gin.Context, accept bool) {
+	requestId, err := uuid.Parse(c.Param("request_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := f.friendService.RespondToFriendRequest(c.Request.Context(), middleware.CurrentUser(c), requestId, accept); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (f *friendHandler) BlockUser(c *gin.Context) {
+	var input models.FriendRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := f.friendService.BlockUser(c.Request.Context(), middleware.CurrentUser(c), input); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (f *friendHandler) UnblockUser(c *gin.Context) {
+	blockedId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := f.friendService.UnblockUser(c.Request.Context(), middleware.CurrentUser(c), blockedId); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (f *friendHandler) GetFriendsLeaderboard(c *gin.Context) {
+	res, err := f.friendService.GetFriendsLeaderboard(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (f *friendHandler) GetFriendActivity(c *gin.Context) {
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			respondError(c, apperrors.Invalid(err))
+			return
+		}
+	}
+
+	res, err := f.friendService.GetFriendActivity(c.Request.Context(), middleware.CurrentUser(c), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GroupHandler interface {
+	CreateGroup(c *gin.Context)
+	ListGroups(c *gin.Context)
+	JoinGroup(c *gin.Context)
+	ListGroupMembers(c *gin.Context)
+	CreateAssignment(c *gin.Context)
+	ListGroupAssignments(c *gin.Context)
+	ListOpenAssignments(c *gin.Context)
+	StartAssignment(c *gin.Context)
+	GetAssignmentReport(c *gin.Context)
+}
+
+type groupHandler struct {
+	groupService services.GroupService
+}
+
+func NewGroupHandler(groupService services.GroupService) GroupHandler {
+	return &groupHandler{groupService: groupService}
+}
+
+func (g *groupHandler) CreateGroup(c *gin.Context) {
+	var input models.GroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.CreateGroup(c.Request.Context(), middleware.CurrentUser(c), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (g *groupHandler) ListGroups(c *gin.Context) {
+	res, err := g.groupService.ListGroups(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (g *groupHandler) JoinGroup(c *gin.Context) {
+	var input models.JoinGroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.JoinGroup(c.Request.Context(), middleware.CurrentUser(c), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (g *groupHandler) ListGroupMembers(c *gin.Context) {
+	groupId, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.ListGroupMembers(c.Request.Context(), middleware.CurrentUser(c), groupId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (g *groupHandler) CreateAssignment(c *gin.Context) {
+	groupId, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input models.Assignment
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.CreateAssignment(c.Request.Context(), middleware.CurrentUser(c), groupId, input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (g *groupHandler) ListGroupAssignments(c *gin.Context) {
+	groupId, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.ListGroupAssignments(c.Request.Context(), middleware.CurrentUser(c), groupId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (g *groupHandler) ListOpenAssignments(c *gin.Context) {
+	res, err := g.groupService.ListOpenAssignments(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (g *groupHandler) StartAssignment(c *gin.Context) {
+	assignmentId, err := uuid.Parse(c.Param("assignment_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.StartAssignment(c.Request.Context(), middleware.CurrentUser(c), assignmentId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// GetAssignmentReport returns the report as JSON, or as a CSV download with
+// ?format=csv.
+func (g *groupHandler) GetAssignmentReport(c *gin.Context) {
+	groupId, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	assignmentId, err := uuid.Parse(c.Param("assignment_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := g.groupService.GetAssignmentReport(c.Request.Context(), middleware.CurrentUser(c), groupId, assignmentId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="assignment-%s.csv"`, assignmentId))
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+	if err := writeAssignmentReportCSV(c.Writer, res); err != nil {
+		c.Error(err)
+	}
+}
+
+func writeAssignmentReportCSV(w http.ResponseWriter, report models.AssignmentReport) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"user_name", "completed", "score", "total_questions", "time_seconds", "completed_at", "missed_cities"})
+	for _, member := range report.Members {
+		timeSeconds := ""
+		if member.TimeSeconds != nil {
+			timeSeconds = strconv.FormatFloat(*member.TimeSeconds, 'f', 0, 64)
+		}
+		completedAt := ""
+		if member.CompletedAt != nil {
+			completedAt = member.CompletedAt.Format(time.RFC3339)
+		}
+		out.Write([]string{
+			member.UserName,
+			strconv.FormatBool(member.Completed),
+			strconv.Itoa(member.Score),
+			strconv.Itoa(member.TotalQuestions),
+			timeSeconds,
+			completedAt,
+			strings.Join(member.MissedCities, "; "),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler interface {
+	Healthz(c *gin.Context)
+	Readyz(c *gin.Context)
+}
+
+type healthHandler struct {
+	healthService services.HealthService
+}
+
+func NewHealthHandler(healthService services.HealthService) HealthHandler {
+	return &healthHandler{healthService: healthService}
+}
+
+// Healthz answers as long as the process is serving requests.
+func (h *healthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusOK})
+}
+
+// Readyz answers 503 unless the database is reachable and fully migrated.
+func (h *healthHandler) Readyz(c *gin.Context) {
+	res := h.healthService.CheckReadiness(c.Request.Context())
+	status := http.StatusOK
+	if res.Status != models.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, res)
+}
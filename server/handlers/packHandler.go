@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PackHandler interface {
+	CreatePack(c *gin.Context)
+	UpdatePack(c *gin.Context)
+	DeletePack(c *gin.Context)
+	GetPack(c *gin.Context)
+	ListPacks(c *gin.Context)
+	GetPackHighScores(c *gin.Context)
+}
+
+type packHandler struct {
+	packService services.PackService
+}
+
+func NewPackHandler(packService services.PackService) PackHandler {
+	return &packHandler{packService: packService}
+}
+
+func (p *packHandler) CreatePack(c *gin.Context) {
+	var pack models.Pack
+
+	if err := c.ShouldBindJSON(&pack); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := p.packService.CreatePack(c.Request.Context(), pack)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (p *packHandler) UpdatePack(c *gin.Context) {
+	packId, err := uuid.Parse(c.Param("pack_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var pack models.Pack
+	if err := c.ShouldBindJSON(&pack); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	pack.Id = &packId
+
+	res, err := p.packService.UpdatePack(c.Request.Context(), pack)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (p *packHandler) DeletePack(c *gin.Context) {
+	packId, err := uuid.Parse(c.Param("pack_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := p.packService.DeletePack(c.Request.Context(), packId); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (p *packHandler) GetPack(c *gin.Context) {
+	packId, err := uuid.Parse(c.Param("pack_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := p.packService.GetPack(c.Request.Context(), packId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (p *packHandler) ListPacks(c *gin.Context) {
+	res, err := p.packService.ListPacks(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (p *packHandler) GetPackHighScores(c *gin.Context) {
+	packId, err := uuid.Parse(c.Param("pack_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			respondError(c, apperrors.Invalid(err))
+			return
+		}
+	}
+
+	res, err := p.packService.GetPackHighScores(c.Request.Context(), packId, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type QuestionHandler interface {
+	CreateQuestion(c *gin.Context)
+	UpdateQuestion(c *gin.Context)
+	SetQuestionStatus(c *gin.Context)
+	DeleteQuestion(c *gin.Context)
+	GetQuestion(c *gin.Context)
+	ListQuestions(c *gin.Context)
+	ListQuestionRevisions(c *gin.Context)
+	DiffQuestionRevisions(c *gin.Context)
+	ListQuestionTranslations(c *gin.Context)
+	SaveQuestionTranslation(c *gin.Context)
+	DeleteQuestionTranslation(c *gin.Context)
+}
+
+type questionHandler struct {
+	questionService services.QuestionService
+}
+
+func NewQuestionHandler(questionService services.QuestionService) QuestionHandler {
+	return &questionHandler{questionService: questionService}
+}
+
+func (q *questionHandler) CreateQuestion(c *gin.Context) {
+	var question models.Question
+
+	if err := c.ShouldBindJSON(&question); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.CreateQuestion(c.Request.Context(), question, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (q *questionHandler) UpdateQuestion(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var question models.Question
+	if err := c.ShouldBindJSON(&question); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	question.Id = &questionId
+
+	res, err := q.questionService.UpdateQuestion(c.Request.Context(), question, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) SetQuestionStatus(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input models.QuestionStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	middleware.AuditDetail(c, "status", input.Status)
+
+	res, err := q.questionService.SetQuestionStatus(c.Request.Context(), questionId, input.Status, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) DeleteQuestion(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := q.questionService.DeleteQuestion(c.Request.Context(), questionId, middleware.AdminUser(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (q *questionHandler) GetQuestion(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.GetQuestion(c.Request.Context(), questionId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) ListQuestions(c *gin.Context) {
+	var filter models.QuestionListFilter
+
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.ListQuestions(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) ListQuestionRevisions(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.ListQuestionRevisions(c.Request.Context(), questionId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) DiffQuestionRevisions(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input struct {
+		From int `form:"from"`
+		To   int `form:"to"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.DiffQuestionRevisions(c.Request.Context(), questionId, input.From, input.To)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) ListQuestionTranslations(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := q.questionService.ListQuestionTranslations(c.Request.Context(), questionId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) SaveQuestionTranslation(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var translation models.QuestionTranslation
+	if err := c.ShouldBindJSON(&translation); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	translation.QuestionId = questionId
+	translation.Locale = c.Param("locale")
+
+	res, err := q.questionService.SaveQuestionTranslation(c.Request.Context(), translation, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (q *questionHandler) DeleteQuestionTranslation(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := q.questionService.DeleteQuestionTranslation(c.Request.Context(), questionId, c.Param("locale"), middleware.AdminUser(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -3,12 +3,20 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/axitdhola/globetrotter/server/apperrors"
 	"github.com/axitdhola/globetrotter/server/models"
 	"github.com/axitdhola/globetrotter/server/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// DeviceIdHeader carries the id the client generated for its device, which
+// the anti-cheat rules use to spot accounts sharing one.
+const DeviceIdHeader = "X-Device-ID"
+
+// maxDeviceIdLength is the width of quiz.device_id; longer ids are dropped.
+const maxDeviceIdLength = 64
+
 type QuizHandler interface {
 	GetQuizQuestion(c *gin.Context)
 	SaveQuizAnswer(c *gin.Context)
@@ -29,7 +37,7 @@ func (f *quizHandler) GetQuizQuestion(c *gin.Context) {
 	id := c.Param("quiz_id")
 	quizId, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperrors.Invalid(err))
 		return
 	}
 
@@ -38,15 +46,15 @@ func (f *quizHandler) GetQuizQuestion(c *gin.Context) {
 	if invitedId != "" {
 		parsedInvitedId, err := uuid.Parse(invitedId)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, apperrors.Invalid(err))
 			return
 		}
 		invitedQuizId = &parsedInvitedId
 	}
 
-	res, err := f.quizService.GetQuizQuestion(quizId, invitedQuizId)
+	res, err := f.quizService.GetQuizQuestion(c.Request.Context(), quizId, invitedQuizId, c.GetHeader("Accept-Language"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -56,27 +64,33 @@ func (f *quizHandler) SaveQuizAnswer(c *gin.Context) {
 	var input models.QuizAnswerInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperrors.Invalid(err))
+		return
 	}
 
-	res, err := f.quizService.SaveQuizAnswer(input)
+	res, err := f.quizService.SaveQuizAnswer(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
 
 func (f *quizHandler) CreateQuiz(c *gin.Context) {
-	var user models.User
+	var input models.CreateQuizInput
 
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	input.ClientIP = c.ClientIP()
+	if deviceId := c.GetHeader(DeviceIdHeader); len(deviceId) <= maxDeviceIdLength {
+		input.DeviceId = deviceId
 	}
 
-	res, err := f.quizService.CreateQuiz(user)
+	res, err := f.quizService.CreateQuiz(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -87,13 +101,13 @@ func (f *quizHandler) GetQuizScore(c *gin.Context) {
 	id := c.Param("quiz_id")
 	quizId, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperrors.Invalid(err))
 		return
 	}
 
-	res, err := f.quizService.GetQuizScoreById(quizId)
+	res, err := f.quizService.GetQuizScoreById(c.Request.Context(), quizId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -102,9 +116,9 @@ func (f *quizHandler) GetQuizScore(c *gin.Context) {
 func (f *quizHandler) ListQuizByUserName(c *gin.Context) {
 	userName := c.Param("username")
 
-	res, err := f.quizService.ListQuizByUserName(userName)
+	res, err := f.quizService.ListQuizByUserName(c.Request.Context(), userName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type RatingHandler interface {
+	GetLeaderboard(c *gin.Context)
+	RecomputeRatings(c *gin.Context)
+}
+
+type ratingHandler struct {
+	ratingService services.RatingService
+}
+
+func NewRatingHandler(ratingService services.RatingService) RatingHandler {
+	return &ratingHandler{ratingService: ratingService}
+}
+
+func (r *ratingHandler) GetLeaderboard(c *gin.Context) {
+	var filter models.LeaderboardFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := r.ratingService.GetLeaderboard(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (r *ratingHandler) RecomputeRatings(c *gin.Context) {
+	res, err := r.ratingService.RecomputeRatings(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StaffHandler interface {
+	ListStaff(c *gin.Context)
+	SetUserRole(c *gin.Context)
+	ListAuditLog(c *gin.Context)
+}
+
+type staffHandler struct {
+	staffService services.StaffService
+	auditService services.AuditService
+}
+
+func NewStaffHandler(staffService services.StaffService, auditService services.AuditService) StaffHandler {
+	return &staffHandler{staffService: staffService, auditService: auditService}
+}
+
+func (s *staffHandler) ListStaff(c *gin.Context) {
+	res, err := s.staffService.ListStaff(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// SetUserRole changes a user's role. A player promoted to staff gets a key,
+// which is in the response and nowhere else.
+func (s *staffHandler) SetUserRole(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	middleware.AuditDetail(c, "role", input.Role)
+
+	res, err := s.staffService.SetRole(c.Request.Context(), userId, input.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (s *staffHandler) ListAuditLog(c *gin.Context) {
+	var filter models.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := s.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TagHandler interface {
+	GetTagCoverage(c *gin.Context)
+	SetQuestionTags(c *gin.Context)
+}
+
+type tagHandler struct {
+	tagService services.TagService
+}
+
+func NewTagHandler(tagService services.TagService) TagHandler {
+	return &tagHandler{tagService: tagService}
+}
+
+func (t *tagHandler) GetTagCoverage(c *gin.Context) {
+	res, err := t.tagService.GetTagCoverage(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (t *tagHandler) SetQuestionTags(c *gin.Context) {
+	questionId, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	var input models.QuestionTagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := t.tagService.SetQuestionTags(c.Request.Context(), questionId, input); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/axitdhola/globetrotter/server/tournament"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TournamentHandler interface {
+	CreateTournament(c *gin.Context)
+	ListTournaments(c *gin.Context)
+	GetTournament(c *gin.Context)
+	Register(c *gin.Context)
+	StartRound(c *gin.Context)
+	GetStandings(c *gin.Context)
+	AdvanceRounds(c *gin.Context)
+}
+
+type tournamentHandler struct {
+	tournamentService services.TournamentService
+	clock             tournament.Clock
+}
+
+func NewTournamentHandler(tournamentService services.TournamentService, clock tournament.Clock) TournamentHandler {
+	return &tournamentHandler{tournamentService: tournamentService, clock: clock}
+}
+
+func (t *tournamentHandler) CreateTournament(c *gin.Context) {
+	var input models.Tournament
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := t.tournamentService.CreateTournament(c.Request.Context(), input, middleware.AdminUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (t *tournamentHandler) ListTournaments(c *gin.Context) {
+	res, err := t.tournamentService.ListTournaments(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (t *tournamentHandler) GetTournament(c *gin.Context) {
+	tournamentId, err := uuid.Parse(c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := t.tournamentService.GetTournament(c.Request.Context(), tournamentId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (t *tournamentHandler) Register(c *gin.Context) {
+	tournamentId, err := uuid.Parse(c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	if err := t.tournamentService.Register(c.Request.Context(), middleware.CurrentUser(c), tournamentId); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (t *tournamentHandler) StartRound(c *gin.Context) {
+	tournamentId, err := uuid.Parse(c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+	roundNumber, err := strconv.Atoi(c.Param("round_number"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := t.tournamentService.StartRound(c.Request.Context(), middleware.CurrentUser(c), tournamentId, roundNumber)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (t *tournamentHandler) GetStandings(c *gin.Context) {
+	tournamentId, err := uuid.Parse(c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := t.tournamentService.GetStandings(c.Request.Context(), tournamentId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// AdvanceRounds runs the scheduler's work immediately instead of waiting for
+// its next tick.
+func (t *tournamentHandler) AdvanceRounds(c *gin.Context) {
+	if err := t.tournamentService.AdvanceRounds(c.Request.Context(), t.clock.Now()); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
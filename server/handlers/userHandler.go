@@ -1,18 +1,28 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"strconv"
 
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/middleware"
 	"github.com/axitdhola/globetrotter/server/models"
 	"github.com/axitdhola/globetrotter/server/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type UserHandler interface {
 	GetUser(c *gin.Context)
 	RegisterUser(c *gin.Context)
+	GetUserStats(c *gin.Context)
+	GetUserAchievements(c *gin.Context)
+	GetReviewQueue(c *gin.Context)
+	CreateSession(c *gin.Context)
+	GetProfile(c *gin.Context)
+	UpdateProfile(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	ResetSecret(c *gin.Context)
 }
 
 type userHandler struct {
@@ -24,16 +34,15 @@ func NewUserHandler(userServices services.UserService) UserHandler {
 }
 
 func (u *userHandler) GetUser(c *gin.Context) {
-	id := c.Param("id")
-	userId, err := strconv.Atoi(id)
+	userId, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperrors.Invalid(err))
 		return
 	}
 
-	user, err := u.userService.GetUser(userId)
+	user, err := u.userService.GetUser(c.Request.Context(), userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -44,17 +53,138 @@ func (u *userHandler) RegisterUser(c *gin.Context) {
 	var user models.User
 
 	if err := c.ShouldBindJSON(&user); err != nil {
-		fmt.Println("Error in binding JSON")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperrors.Invalid(err))
 		return
 	}
 
-	res, err := u.userService.RegisterUser(user)
+	res, err := u.userService.RegisterUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var taken *services.UsernameTakenError
+		if errors.As(err, &taken) {
+			res := apperrors.ResponseOf(err)
+			c.JSON(apperrors.HTTPStatus(err), gin.H{"error": res.Error, "code": res.Code, "suggestions": taken.Suggestions})
+			return
+		}
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, res)
 }
 
+func (u *userHandler) GetUserStats(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	stats, err := u.userService.GetUserStats(c.Request.Context(), userId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (u *userHandler) GetUserAchievements(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := u.userService.GetUserAchievements(c.Request.Context(), userId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (u *userHandler) GetReviewQueue(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := u.userService.GetReviewQueue(c.Request.Context(), userId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (u *userHandler) CreateSession(c *gin.Context) {
+	var input models.SessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := u.userService.CreateSession(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (u *userHandler) GetProfile(c *gin.Context) {
+	res, err := u.userService.GetProfile(c.Request.Context(), middleware.CurrentUser(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (u *userHandler) UpdateProfile(c *gin.Context) {
+	var input models.UserProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := u.userService.UpdateProfile(c.Request.Context(), middleware.CurrentUser(c), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (u *userHandler) DeleteUser(c *gin.Context) {
+	if err := u.userService.DeleteUser(c.Request.Context(), middleware.CurrentUser(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetSecret issues a player a new sign-in secret, which is in the response
+// and nowhere else.
+func (u *userHandler) ResetSecret(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, apperrors.Invalid(err))
+		return
+	}
+
+	res, err := u.userService.ResetSecret(c.Request.Context(), userId)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
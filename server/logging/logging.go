@@ -0,0 +1,79 @@
+// Package logging builds the server's structured logger and carries a
+// per-request logger through contexts, down to the database layer.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+const redacted = "[redacted]"
+
+// sensitiveKeys are attribute keys whose values are never written out.
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"api_key":       true,
+	"database_url":  true,
+	"dsn":           true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
+// New returns a logger writing to w in the given format ("text" or "json")
+// at the given level ("debug", "info", "warn" or "error").
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// ParseLevel reads a level name such as "info" or "warn".
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", level)
+	}
+	return lvl, nil
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx that carries logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -1,39 +1,321 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/axitdhola/globetrotter/server/auth"
+	"github.com/axitdhola/globetrotter/server/config"
 	"github.com/axitdhola/globetrotter/server/dao"
 	"github.com/axitdhola/globetrotter/server/db"
+	"github.com/axitdhola/globetrotter/server/db/migrations"
 	"github.com/axitdhola/globetrotter/server/handlers"
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/axitdhola/globetrotter/server/metrics"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/questiontoken"
+	"github.com/axitdhola/globetrotter/server/ratelimit"
 	"github.com/axitdhola/globetrotter/server/router"
 	"github.com/axitdhola/globetrotter/server/services"
+	"github.com/axitdhola/globetrotter/server/tournament"
+	"github.com/axitdhola/globetrotter/server/tracing"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
+	// A .env file is optional, and variables already set in the environment
+	// take precedence over it.
+	_ = godotenv.Load()
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfigCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		runRoleCommand(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		log.Fatal(err)
 	}
 
-	dbConn, err := db.NewDatabase()
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Also routes the standard log package, gin's included, through logger.
+	slog.SetDefault(logger)
+
+	// ctx is cancelled on SIGINT or SIGTERM, which starts a graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	tracingEnabled := cfg.Tracing.Exporter != config.TraceExporterNone
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
 	if err != nil {
-		panic(err)
+		fatal("cannot set up tracing", err)
+	}
+
+	observers := []db.Observer{db.LogQueries(cfg.Database.SlowQueryThreshold.Duration)}
+	if tracingEnabled {
+		observers = append(observers, tracing.ObserveQuery)
+	}
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		observers = append(observers, m.ObserveQuery)
+	}
+
+	dbConn, err := db.NewDatabase(cfg.Database, observers...)
+	if err != nil {
+		fatal("cannot open database", err)
+	}
+	defer dbConn.Close()
+	quizObserver := services.NopQuizObserver
+	if m != nil {
+		m.WatchDB("globetrotter", dbConn.GetDB())
+		quizObserver = m
 	}
+	if err := dbConn.Connect(ctx, cfg.Database.ConnectAttempts, cfg.Database.ConnectBackoff.Duration); err != nil {
+		fatal("cannot connect to database", err)
+	}
+	logger.Info("database connected", "database", cfg.Redacted().Database.URL)
 
-	fmt.Println("Database connected successfully", dbConn.GetDB().Driver())
+	migrationVersion, err := migrations.LatestVersion()
+	if err != nil {
+		fatal("cannot read migrations", err)
+	}
 	userDAO := dao.NewUserDao(dbConn.GetDB())
 	quizDAO := dao.NewQuizDao(dbConn.GetDB())
+	packDAO := dao.NewPackDao(dbConn.GetDB())
+	tagDAO := dao.NewTagDao(dbConn.GetDB())
+	questionDAO := dao.NewQuestionDao(dbConn.GetDB())
+	statsDAO := dao.NewStatsDao(dbConn.GetDB())
+	achievementDAO := dao.NewAchievementDao(dbConn.GetDB())
+	ratingDAO := dao.NewRatingDao(dbConn.GetDB())
+	reviewDAO := dao.NewReviewDao(dbConn.GetDB())
+	friendDAO := dao.NewFriendDao(dbConn.GetDB())
+	groupDAO := dao.NewGroupDao(dbConn.GetDB())
+	tournamentDAO := dao.NewTournamentDao(dbConn.GetDB())
+	antiCheatDAO := dao.NewAntiCheatDao(dbConn.GetDB())
+	staffDAO := dao.NewStaffDao(dbConn.GetDB())
+	auditDAO := dao.NewAuditDao(dbConn.GetDB())
+	healthDAO := dao.NewHealthDao(dbConn.GetDB())
+
+	signer := auth.NewSigner(cfg.Auth.Secret, cfg.Auth.SessionTTL.Duration)
+	questionTokenKeys := cfg.QuestionToken.Keyring()
+	if len(questionTokenKeys) == 0 {
+		key, err := questiontoken.GenerateKey("ephemeral")
+		if err != nil {
+			fatal("could not generate a question token key", err)
+		}
+		questionTokenKeys = append(questionTokenKeys, key)
+		logger.Warn("no question token keys are configured; signing with a random key, so tokens do not survive a restart or work across instances")
+	}
+	questionTokens := questiontoken.NewKeyring(questionTokenKeys, cfg.QuestionToken.TTL.Duration)
 
-	userService := services.NewUserService(userDAO)
-	quizService := services.NewQuizService(quizDAO, userDAO)
+	achievementService := services.NewAchievementService(achievementDAO)
+	ratingService := services.NewRatingService(ratingDAO)
+	reviewService := services.NewReviewService(reviewDAO)
+	friendService := services.NewFriendService(friendDAO, userDAO)
+	groupService := services.NewGroupService(groupDAO, quizDAO, packDAO, quizObserver)
+	tournamentService := services.NewTournamentService(tournamentDAO, quizDAO, packDAO, quizObserver, tournament.SystemClock)
+	antiCheatEvery := cfg.AntiCheat.CheckEvery
+	if !cfg.AntiCheat.Enabled {
+		antiCheatEvery = 0
+	}
+	antiCheatService := services.NewAntiCheatService(antiCheatDAO, cfg.AntiCheat.Rules(), antiCheatEvery, cfg.AntiCheat.AccountWindow.Duration)
+	userService := services.NewUserService(userDAO, statsDAO, achievementService, reviewService, signer)
+	quizService := services.NewQuizService(quizDAO, userDAO, packDAO, questionDAO, achievementService, reviewService, friendService, antiCheatService, questionTokens, cfg.Quiz.Preferences(), quizObserver)
+	packService := services.NewPackService(packDAO)
+	tagService := services.NewTagService(tagDAO)
+	questionService := services.NewQuestionService(questionDAO)
+	healthService := services.NewHealthService(healthDAO, migrationVersion)
+	staffService := services.NewStaffService(staffDAO, userDAO)
+	auditService := services.NewAuditService(auditDAO)
 
 	userHandler := handlers.NewUserHandler(userService)
 	quizHandler := handlers.NewQuizHandler(quizService)
+	packHandler := handlers.NewPackHandler(packService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	questionHandler := handlers.NewQuestionHandler(questionService)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+	ratingHandler := handlers.NewRatingHandler(ratingService)
+	friendHandler := handlers.NewFriendHandler(friendService)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	tournamentHandler := handlers.NewTournamentHandler(tournamentService, tournament.SystemClock)
+	antiCheatHandler := handlers.NewAntiCheatHandler(antiCheatService)
+	staffHandler := handlers.NewStaffHandler(staffService, auditService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	var rateLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		rateLimiter = &middleware.RateLimiter{Store: ratelimit.NewMemoryStore(time.Now), Signer: signer, QuestionTokens: questionTokens,
+			QuizOwner: func(ctx context.Context, quizId uuid.UUID) (uuid.UUID, error) {
+				quiz, err := quizDAO.GetQuizById(ctx, quizId)
+				return quiz.UserId, err
+			}}
+		if m != nil {
+			rateLimiter.Rejected = m.RateLimited
+		}
+	}
 
-	r := router.InitRouter(userHandler, quizHandler)
+	r := router.InitRouter(router.Handlers{
+		User:        userHandler,
+		Quiz:        quizHandler,
+		Pack:        packHandler,
+		Tag:         tagHandler,
+		Question:    questionHandler,
+		Achievement: achievementHandler,
+		Rating:      ratingHandler,
+		Friend:      friendHandler,
+		Group:       groupHandler,
+		Tournament:  tournamentHandler,
+		AntiCheat:   antiCheatHandler,
+		Staff:       staffHandler,
+		Health:      healthHandler,
+	}, router.Options{
+		Staff:          staffService,
+		Audit:          auditService,
+		CORSOrigins:    cfg.Server.CORSOrigins,
+		Features:       cfg.Features,
+		Signer:         signer,
+		Logger:         logger,
+		Metrics:        m,
+		Tracing:        tracingEnabled,
+		TrustedProxies: cfg.Server.TrustedProxies,
+		RateLimiter:    rateLimiter,
+		RateLimits:     cfg.RateLimit,
+		RequestTimeout: cfg.Server.RequestTimeout.Duration,
+		RouteTimeouts:  cfg.Server.RouteTimeoutDurations(),
+	})
+
+	var background sync.WaitGroup
+	if cfg.Features.Tournaments {
+		scheduler := tournament.NewScheduler(tournamentService, tournament.SystemClock, cfg.Tournament.TickInterval.Duration)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			scheduler.Run(ctx)
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout.Duration,
+		WriteTimeout: cfg.Server.WriteTimeout.Duration,
+		IdleTimeout:  cfg.Server.IdleTimeout.Duration,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", cfg.Server.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		fatal("server failed", err)
+	case <-ctx.Done():
+	}
+	stop()
 
-	r.Run(":8080")
+	// Fail readiness first and give load balancers time to notice, then stop
+	// accepting connections and let in-flight requests finish.
+	logger.Info("shutting down", "drain_delay", cfg.Server.DrainDelay.Duration)
+	healthService.SetDraining()
+	time.Sleep(cfg.Server.DrainDelay.Duration)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("shutdown did not finish cleanly", "error", err)
+	}
+	background.Wait()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("could not flush traces", "error", err)
+	}
+	logger.Info("server stopped")
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// runConfigCommand handles "config print", which shows the configuration the
+// server would start with, secrets redacted.
+func runConfigCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: server config print [flags]")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(os.Args[0]+" config print", args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runRoleCommand handles "role grant <username> <role>", which sets a user's
+// role without going through the admin API, to appoint the first admin or
+// replace a lost staff key. Staff roles get a new key, printed once.
+func runRoleCommand(args []string) {
+	if len(args) < 3 || args[0] != "grant" {
+		fmt.Fprintln(os.Stderr, "usage: server role grant <username> <player|moderator|admin> [flags]")
+		os.Exit(2)
+	}
+	userName, role := args[1], args[2]
+
+	cfg, err := config.Load(os.Args[0]+" role grant", args[3:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	dbConn, err := db.NewDatabase(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Connect(ctx, cfg.Database.ConnectAttempts, cfg.Database.ConnectBackoff.Duration); err != nil {
+		log.Fatal(err)
+	}
+
+	staffService := services.NewStaffService(dao.NewStaffDao(dbConn.GetDB()), dao.NewUserDao(dbConn.GetDB()))
+	auditService := services.NewAuditService(dao.NewAuditDao(dbConn.GetDB()))
+
+	grant, err := staffService.GrantRole(ctx, userName, role)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = auditService.Record(ctx, models.AuditEntry{
+		Actor:  "cli",
+		Action: "role grant",
+		Target: map[string]string{"user_id": grant.UserId.String(), "username": grant.UserName, "role": grant.Role},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s is now %s\n", grant.UserName, grant.Role)
+	if grant.StaffKey != "" {
+		fmt.Printf("staff key (send as %s; it is not shown again): %s\n", middleware.AdminKeyHeader, grant.StaffKey)
+	}
 }
@@ -0,0 +1,147 @@
+// Package metrics collects the server's Prometheus metrics: HTTP traffic,
+// the database pool and queries, quiz activity and rate limiting.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "globetrotter"
+
+// UnmatchedRoute labels requests that matched no route, so that unknown
+// paths cannot create new series.
+const UnmatchedRoute = "unmatched"
+
+const (
+	AnswerCorrect   = "correct"
+	AnswerIncorrect = "incorrect"
+	AnswerTimedOut  = "timed_out"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	quizzesCreated  *prometheus.CounterVec
+	quizzesFinished *prometheus.CounterVec
+	answers         *prometheus.CounterVec
+	rateLimited     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time to handle HTTP requests, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time for database round trips, by operation and whether they failed.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"op", "status"}),
+		quizzesCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "quizzes_created_total",
+			Help:      "Quizzes created, by mode.",
+		}, []string{"mode"}),
+		quizzesFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "quizzes_finished_total",
+			Help:      "Quizzes answered to the end, by mode.",
+		}, []string{"mode"}),
+		answers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "answers_graded_total",
+			Help:      "Answers graded, by quiz mode and result (correct, incorrect or timed_out).",
+		}, []string{"mode", "result"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_requests_total",
+			Help:      "Requests refused with 429, by route group and whether the IP or the user was over its limit.",
+		}, []string{"group", "scope"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.quizzesCreated,
+		m.quizzesFinished,
+		m.answers,
+		m.rateLimited,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// WatchDB exports the pool statistics of sqlDB, read from sql.DB.Stats on
+// every scrape.
+func (m *Metrics) WatchDB(name string, sqlDB *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, name))
+}
+
+// ObserveRequest records one handled HTTP request. route is the matched
+// pattern, such as "/quiz/:quiz_id/score", or UnmatchedRoute.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery is a db.Observer.
+func (m *Metrics) ObserveQuery(_ context.Context, q db.Query) {
+	status := "ok"
+	if q.Err != nil {
+		status = "error"
+	}
+	m.queryDuration.WithLabelValues(q.Op, status).Observe(q.Duration.Seconds())
+}
+
+func (m *Metrics) QuizCreated(mode string) {
+	m.quizzesCreated.WithLabelValues(mode).Inc()
+}
+
+func (m *Metrics) QuizFinished(mode string) {
+	m.quizzesFinished.WithLabelValues(mode).Inc()
+}
+
+func (m *Metrics) AnswerGraded(mode string, correct, timedOut bool) {
+	result := AnswerIncorrect
+	switch {
+	case timedOut:
+		result = AnswerTimedOut
+	case correct:
+		result = AnswerCorrect
+	}
+	m.answers.WithLabelValues(mode, result).Inc()
+}
+
+func (m *Metrics) RateLimited(group, scope string) {
+	m.rateLimited.WithLabelValues(group, scope).Inc()
+}
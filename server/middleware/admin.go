@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AdminKeyHeader carries the personal key of the staff member making an
+	// /admin request.
+	AdminKeyHeader = "X-Admin-Key"
+
+	actorKey       = "actor"
+	auditTargetKey = "audit_target"
+)
+
+// StaffAuthenticator resolves a staff key to the staff member it belongs to.
+type StaffAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (models.Actor, error)
+}
+
+// AuditRecorder appends entries to the audit log.
+type AuditRecorder interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// RequireStaff rejects requests that do not carry a valid staff key and
+// records who is acting; see AdminUser. A nil staff disables the admin API
+// entirely.
+func RequireStaff(staff StaffAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if staff == nil {
+			abortError(c, errAdminDisabled)
+			return
+		}
+
+		actor, err := staff.Authenticate(c.Request.Context(), strings.TrimSpace(c.GetHeader(AdminKeyHeader)))
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		c.Set(actorKey, actor)
+
+		c.Next()
+	}
+}
+
+// RequirePermission rejects staff whose role does not grant p. It goes
+// behind RequireStaff.
+func RequirePermission(p rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rbac.Can(CurrentActor(c).Role, p) {
+			abortError(c, errRoleNotAllowed)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Audit records every request that changes something, once it has been
+// answered, whether or not it was allowed. Reads are not recorded. It goes
+// behind RequireStaff. A failure to record is logged, since the action has
+// already happened.
+func Audit(recorder AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		actor, ok := c.Get(actorKey)
+		if !ok {
+			return
+		}
+
+		target := map[string]string{}
+		for _, param := range c.Params {
+			target[param.Key] = param.Value
+		}
+		if extra, ok := c.Get(auditTargetKey); ok {
+			for key, value := range extra.(map[string]string) {
+				target[key] = value
+			}
+		}
+
+		a := actor.(models.Actor)
+		status := c.Writer.Status()
+		entry := models.AuditEntry{
+			ActorId:   &a.UserId,
+			Actor:     a.UserName,
+			ActorRole: a.Role,
+			Action:    c.Request.Method + " " + c.FullPath(),
+			Target:    target,
+			Status:    &status,
+			RequestId: CurrentRequestID(c),
+		}
+		// The request's own deadline may be what ended it.
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := recorder.Record(ctx, entry); err != nil {
+			logging.FromContext(ctx).Error("could not record audit entry", "action", entry.Action, "error", err)
+		}
+	}
+}
+
+// AuditDetail adds a value to the request's audit entry, beside the route
+// parameters that are recorded anyway.
+func AuditDetail(c *gin.Context, key, value string) {
+	extra, ok := c.Get(auditTargetKey)
+	if !ok {
+		extra = map[string]string{}
+		c.Set(auditTargetKey, extra)
+	}
+	extra.(map[string]string)[key] = value
+}
+
+// CurrentActor returns the staff member making the request. It is only set
+// behind RequireStaff.
+func CurrentActor(c *gin.Context) models.Actor {
+	actor, _ := c.Get(actorKey)
+	a, _ := actor.(models.Actor)
+	return a
+}
+
+// AdminUser returns the name of the staff member making the request.
+func AdminUser(c *gin.Context) string {
+	return CurrentActor(c).UserName
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errSessionsDisabled    = apperrors.New(apperrors.Unavailable, "sessions are disabled")
+	errMissingSessionToken = apperrors.New(apperrors.Unauthorized, "missing session token")
+	errAdminDisabled       = apperrors.New(apperrors.Unavailable, "admin API is disabled")
+	errRoleNotAllowed      = apperrors.New(apperrors.Forbidden, "your role does not allow this")
+	errTooManyRequests     = apperrors.New(apperrors.TooManyRequests, "too many requests")
+	errAnswerTooLarge      = apperrors.New(apperrors.Validation, "answer is too large")
+)
+
+// abortError stops the request with the same status and body that handlers
+// answer err with. Internal errors are attached to the request so the access
+// log records what went wrong.
+func abortError(c *gin.Context, err error) {
+	if apperrors.KindOf(err) == apperrors.Internal {
+		c.Error(err)
+	}
+	c.AbortWithStatusJSON(apperrors.HTTPStatus(err), apperrors.ResponseOf(err))
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDKey = "request_id"
+
+	// maxRequestIDLength bounds the IDs accepted from clients.
+	maxRequestIDLength = 128
+)
+
+// RequestID tags each request with the client's X-Request-ID, or a new one
+// when it is missing or unusable, and echoes it in the response. The
+// request's context carries a logger with the ID, and the trace ID when the
+// request is traced, attached; see logging.FromContext.
+func RequestID(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		requestLogger := logger.With("request_id", id)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String())
+		}
+		ctx := logging.WithLogger(c.Request.Context(), requestLogger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrentRequestID returns the request's ID. It is only set behind RequestID.
+func CurrentRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// AccessLog logs each request once it has been handled. The query string is
+// left out since it may carry tokens.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// Recovery turns a panic into a 500 and logs it with the request's logger.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		logging.FromContext(c.Request.Context()).Error("panic while handling request", "error", err, "stack", string(debug.Stack()))
+		abortError(c, fmt.Errorf("panic: %v", err))
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts and times each request by its route pattern.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = metrics.UnmatchedRoute
+		}
+		m.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
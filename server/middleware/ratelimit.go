@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/auth"
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/axitdhola/globetrotter/server/questiontoken"
+	"github.com/axitdhola/globetrotter/server/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RateLimitScopeIP   = "ip"
+	RateLimitScopeUser = "user"
+)
+
+// maxAnswerBytes bounds the answer bodies LimitAnswers reads; real answers
+// are a few hundred bytes.
+const maxAnswerBytes = 16 << 10
+
+// RateLimiter builds rate-limiting middleware for route groups that share
+// one store.
+type RateLimiter struct {
+	Store ratelimit.Store
+	// Signer identifies the user behind a session token, so that users get
+	// a bucket of their own.
+	Signer *auth.Signer
+	// QuestionTokens verifies the question token an answer carries, which
+	// proves the answer belongs to the quiz it names.
+	QuestionTokens *questiontoken.Keyring
+	// QuizOwner finds the user a quiz belongs to, so that LimitAnswers can
+	// count answers against them.
+	QuizOwner func(ctx context.Context, quizId uuid.UUID) (uuid.UUID, error)
+	// Rejected, when set, is told about every request that was turned away.
+	Rejected func(group, scope string)
+}
+
+// Limit lets a request through only while both its client IP and, when it
+// carries a valid session token, its user have tokens left in group's
+// buckets. Others get 429 with Retry-After. If the store fails, requests are
+// let through.
+func (l *RateLimiter) Limit(group string, perIP, perUser ratelimit.Limit) gin.HandlerFunc {
+	return l.limit(group, perIP, perUser, l.sessionUser)
+}
+
+// LimitAnswers is Limit for answers, which carry no session token: the user
+// bucket is that of the owner of the quiz the answer's question token was
+// issued for. Answers without a valid token only count against the IP, so
+// a stranger who knows a quiz id cannot drain its owner's bucket.
+func (l *RateLimiter) LimitAnswers(group string, perIP, perUser ratelimit.Limit) gin.HandlerFunc {
+	return l.limit(group, perIP, perUser, l.quizOwner)
+}
+
+func (l *RateLimiter) limit(group string, perIP, perUser ratelimit.Limit, user func(*gin.Context) (uuid.UUID, bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.allow(c, group, RateLimitScopeIP, c.ClientIP(), perIP) {
+			return
+		}
+		userId, ok, err := user(c)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		if ok {
+			if !l.allow(c, group, RateLimitScopeUser, userId.String(), perUser) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func (l *RateLimiter) sessionUser(c *gin.Context) (uuid.UUID, bool, error) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || !l.Signer.Enabled() {
+		return uuid.UUID{}, false, nil
+	}
+	userId, err := l.Signer.Verify(strings.TrimSpace(token))
+	return userId, err == nil, nil
+}
+
+// quizOwner reads the question token from the JSON body and puts the body
+// back for the handler. Bodies over maxAnswerBytes are refused.
+func (l *RateLimiter) quizOwner(c *gin.Context) (uuid.UUID, bool, error) {
+	if l.QuestionTokens == nil || l.QuizOwner == nil || c.Request.Body == nil {
+		return uuid.UUID{}, false, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAnswerBytes))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return uuid.UUID{}, false, errAnswerTooLarge
+	}
+	if err != nil {
+		return uuid.UUID{}, false, nil
+	}
+
+	var input struct {
+		QuizId     uuid.UUID `json:"quiz_id"`
+		QuestionId uuid.UUID `json:"question_id"`
+		Token      string    `json:"token"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return uuid.UUID{}, false, nil
+	}
+	claims, err := l.QuestionTokens.Verify(input.Token)
+	if err != nil || claims.QuizId != input.QuizId || claims.QuestionId != input.QuestionId {
+		return uuid.UUID{}, false, nil
+	}
+
+	ctx := c.Request.Context()
+	userId, err := l.QuizOwner(ctx, claims.QuizId)
+	if err != nil {
+		logging.FromContext(ctx).Debug("rate limit could not find quiz owner", "quiz_id", claims.QuizId, "error", err)
+		return uuid.UUID{}, false, nil
+	}
+	return userId, true, nil
+}
+
+func (l *RateLimiter) allow(c *gin.Context, group, scope, id string, limit ratelimit.Limit) bool {
+	ctx := c.Request.Context()
+	allowed, retryAfter, err := l.Store.Allow(ctx, group+":"+scope+":"+id, limit)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limit store failed; letting request through", "group", group, "error", err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	if l.Rejected != nil {
+		l.Rejected(group, scope)
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+	abortError(c, errTooManyRequests)
+	return false
+}
+
+// retryAfterSeconds rounds up, since clients that retry early are refused
+// again.
+func retryAfterSeconds(d time.Duration) int {
+	if seconds := int(math.Ceil(d.Seconds())); seconds > 1 {
+		return seconds
+	}
+	return 1
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/questiontoken"
+	"github.com/axitdhola/globetrotter/server/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var testTokens = questiontoken.NewKeyring([]questiontoken.Key{{Id: "test", Secret: []byte("test-secret")}}, time.Hour)
+
+// answerRouter serves POST /quiz/answer behind LimitAnswers with room for
+// many answers per IP but only two per user, and records each body the
+// handler saw.
+func answerRouter(owners map[uuid.UUID]uuid.UUID, bodies *[]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	limiter := &RateLimiter{
+		Store:          ratelimit.NewMemoryStore(time.Now),
+		QuestionTokens: testTokens,
+		QuizOwner: func(ctx context.Context, quizId uuid.UUID) (uuid.UUID, error) {
+			owner, ok := owners[quizId]
+			if !ok {
+				return uuid.UUID{}, errors.New("no such quiz")
+			}
+			return owner, nil
+		},
+	}
+
+	r := gin.New()
+	r.POST("/quiz/answer", limiter.LimitAnswers("answer", ratelimit.Per(100, time.Minute, 100), ratelimit.Per(2, time.Minute, 2)), func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		*bodies = append(*bodies, string(body))
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+// answerFor returns an answer to a fresh question in quiz, with a token
+// issued for it.
+func answerFor(t *testing.T, quizId uuid.UUID) map[string]string {
+	t.Helper()
+	questionId := uuid.New()
+	token, err := testTokens.Sign(questiontoken.Claims{QuizId: quizId, QuestionId: questionId, Position: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"quiz_id": quizId.String(), "question_id": questionId.String(), "answer": "Paris", "token": token}
+}
+
+func postAnswer(t *testing.T, r *gin.Engine, ip string, answer map[string]string) int {
+	t.Helper()
+	body, err := json.Marshal(answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return postBody(r, ip, string(body))
+}
+
+func postBody(r *gin.Engine, ip string, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/quiz/answer", strings.NewReader(body))
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestLimitAnswersCountsAgainstQuizOwner(t *testing.T) {
+	owner := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	var bodies []string
+	r := answerRouter(map[uuid.UUID]uuid.UUID{first: owner, second: owner}, &bodies)
+
+	// Each answer comes from a new address, so only the owner's bucket can
+	// run out.
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, quizId := range []uuid.UUID{first, second, first} {
+		if got := postAnswer(t, r, fmt.Sprintf("10.0.0.%d", i+1), answerFor(t, quizId)); got != want[i] {
+			t.Errorf("answer %d got %d, want %d", i+1, got, want[i])
+		}
+	}
+
+	if len(bodies) != 2 || !strings.Contains(bodies[0], first.String()) {
+		t.Errorf("handler saw bodies %q, want the two allowed answers", bodies)
+	}
+}
+
+func TestLimitAnswersNeedsTokenToChargeOwner(t *testing.T) {
+	owner := uuid.New()
+	quizId := uuid.New()
+	var bodies []string
+	r := answerRouter(map[uuid.UUID]uuid.UUID{quizId: owner}, &bodies)
+
+	// A stranger who knows the quiz id has no token for it, or one issued
+	// for another quiz, and only spends their own IP bucket.
+	other := answerFor(t, uuid.New())
+	for i, answer := range []map[string]string{
+		{"quiz_id": quizId.String(), "question_id": uuid.NewString(), "answer": "Paris"},
+		{"quiz_id": quizId.String(), "question_id": other["question_id"], "answer": "Paris", "token": other["token"]},
+		{"quiz_id": quizId.String(), "question_id": uuid.NewString(), "answer": "Paris", "token": "test.forged.token"},
+	} {
+		if got := postAnswer(t, r, "10.0.0.9", answer); got != http.StatusOK {
+			t.Errorf("stranger's answer %d got %d, want %d", i+1, got, http.StatusOK)
+		}
+	}
+
+	// The owner's bucket is still full.
+	for i := 0; i < 2; i++ {
+		if got := postAnswer(t, r, "10.0.0.1", answerFor(t, quizId)); got != http.StatusOK {
+			t.Errorf("owner's answer %d got %d, want %d", i+1, got, http.StatusOK)
+		}
+	}
+}
+
+func TestLimitAnswersIgnoresUnknownQuizzes(t *testing.T) {
+	var bodies []string
+	r := answerRouter(map[uuid.UUID]uuid.UUID{}, &bodies)
+
+	for i := 0; i < 3; i++ {
+		if got := postAnswer(t, r, "10.0.0.1", answerFor(t, uuid.New())); got != http.StatusOK {
+			t.Errorf("answer %d got %d, want %d", i+1, got, http.StatusOK)
+		}
+	}
+}
+
+func TestLimitAnswersRefusesLargeBodies(t *testing.T) {
+	var bodies []string
+	r := answerRouter(map[uuid.UUID]uuid.UUID{}, &bodies)
+
+	body := `{"answer":"` + strings.Repeat("a", maxAnswerBytes) + `"}`
+	if got := postBody(r, "10.0.0.1", body); got != http.StatusBadRequest {
+		t.Errorf("large answer got %d, want %d", got, http.StatusBadRequest)
+	}
+	if len(bodies) != 0 {
+		t.Error("handler saw a large answer")
+	}
+}
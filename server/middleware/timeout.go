@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives each request a deadline so that its database work is
+// cancelled once the deadline passes or the client goes away. Routes are
+// looked up in routeTimeouts by method and pattern, such as
+// "POST /admin/ratings/recompute"; all others get defaultTimeout.
+func Timeout(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := defaultTimeout
+		if t, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = t
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
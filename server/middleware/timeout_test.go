@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// slowDriver is a database/sql driver whose queries run until their context
+// ends, like a pg_sleep that is never going to finish. It reports when a
+// query starts on started, unless its context ends first.
+type slowDriver struct {
+	started chan struct{}
+}
+
+func (d slowDriver) Open(string) (driver.Conn, error) {
+	return slowConn(d), nil
+}
+
+type slowConn struct {
+	started chan struct{}
+}
+
+func (c slowConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c slowConn) Close() error { return nil }
+
+func (c slowConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c slowConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	select {
+	case c.started <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var slowDriverStarted = make(chan struct{})
+
+func init() {
+	sql.Register("slow", slowDriver{started: slowDriverStarted})
+}
+
+// slowUserDao is a real DAO on top of slowDriver.
+func slowUserDao(t *testing.T) dao.UserDao {
+	t.Helper()
+	db, err := sql.Open("slow", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return dao.NewUserDao(db)
+}
+
+// serveLookup serves req through Timeout and a handler that looks a user up,
+// and returns the DAO's error.
+func serveLookup(t *testing.T, timeout time.Duration, routeTimeouts map[string]time.Duration, req *http.Request) error {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	userDao := slowUserDao(t)
+
+	var queryErr error
+	r := gin.New()
+	r.Use(Timeout(timeout, routeTimeouts))
+	r.GET("/users/:id", func(c *gin.Context) {
+		_, queryErr = userDao.GetUserById(c.Request.Context(), uuid.New())
+		c.Status(http.StatusGatewayTimeout)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("query was not cancelled")
+	}
+	return queryErr
+}
+
+func TestTimeoutCancelsQueryAtDeadline(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	start := time.Now()
+	err := serveLookup(t, 20*time.Millisecond, nil, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("query ran for %v after a 20ms deadline", elapsed)
+	}
+}
+
+func TestTimeoutUsesRouteTimeout(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	start := time.Now()
+	err := serveLookup(t, time.Hour, map[string]time.Duration{"GET /users/:id": 20 * time.Millisecond}, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("query ran for %v after a 20ms route deadline", elapsed)
+	}
+}
+
+func TestTimeoutCancelsQueryWhenClientGoesAway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil).WithContext(ctx)
+
+	go func() {
+		<-slowDriverStarted
+		cancel()
+	}()
+
+	err := serveLookup(t, time.Hour, nil, req)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("query error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const currentUserKey = "current_user"
+
+// RequireUser rejects requests without a valid "Authorization: Bearer"
+// session token and records the signed-in user; see CurrentUser.
+func RequireUser(signer *auth.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !signer.Enabled() {
+			abortError(c, errSessionsDisabled)
+			return
+		}
+
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			abortError(c, errMissingSessionToken)
+			return
+		}
+
+		userId, err := signer.Verify(strings.TrimSpace(token))
+		if err != nil {
+			abortError(c, apperrors.Wrap(apperrors.Unauthorized, err))
+			return
+		}
+		c.Set(currentUserKey, userId)
+
+		c.Next()
+	}
+}
+
+// CurrentUser returns the signed-in user. It is only set behind RequireUser.
+func CurrentUser(c *gin.Context) uuid.UUID {
+	userId, _ := c.MustGet(currentUserKey).(uuid.UUID)
+	return userId
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Achievement struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	QuizId      *uuid.UUID `json:"quiz_id"`
+	EarnedAt    *time.Time `json:"earned_at"`
+}
+
+type AchievementBackfillResult struct {
+	UsersChecked        int `json:"users_checked"`
+	AchievementsAwarded int `json:"achievements_awarded"`
+}
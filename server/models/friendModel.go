@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FriendRequestPending  = "pending"
+	FriendRequestAccepted = "accepted"
+	FriendRequestDeclined = "declined"
+)
+
+type FriendRequest struct {
+	Id            uuid.UUID  `json:"id"`
+	SenderId      uuid.UUID  `json:"sender_id"`
+	SenderName    string     `json:"sender_name"`
+	RecipientId   uuid.UUID  `json:"recipient_id"`
+	RecipientName string     `json:"recipient_name"`
+	Status        string     `json:"status"`
+	CreatedAt     *time.Time `json:"created_at"`
+	RespondedAt   *time.Time `json:"responded_at"`
+}
+
+type FriendRequestInput struct {
+	UserName string `json:"user_name"`
+}
+
+type FriendRequests struct {
+	Incoming []FriendRequest `json:"incoming"`
+	Outgoing []FriendRequest `json:"outgoing"`
+}
+
+type Friend struct {
+	UserId   uuid.UUID  `json:"user_id"`
+	UserName string     `json:"user_name"`
+	Since    *time.Time `json:"since"`
+}
+
+type FriendStanding struct {
+	Rank             int       `json:"rank"`
+	UserId           uuid.UUID `json:"user_id"`
+	UserName         string    `json:"user_name"`
+	Rating           float64   `json:"rating"`
+	QuizzesCompleted int       `json:"quizzes_completed"`
+	BestScore        int       `json:"best_score"`
+}
+
+type FriendActivity struct {
+	UserId         uuid.UUID  `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	QuizId         uuid.UUID  `json:"quiz_id"`
+	PackTitle      string     `json:"pack_title"`
+	Score          int        `json:"score"`
+	TotalQuestions int        `json:"total_questions"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
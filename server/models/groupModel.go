@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Group struct {
+	Id          *uuid.UUID `json:"id"`
+	Name        string     `json:"name"`
+	OwnerId     uuid.UUID  `json:"owner_id"`
+	InviteCode  string     `json:"invite_code,omitempty"`
+	MemberCount int        `json:"member_count"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type GroupInput struct {
+	Name string `json:"name"`
+}
+
+type JoinGroupInput struct {
+	InviteCode string `json:"invite_code"`
+}
+
+type GroupMember struct {
+	UserId   uuid.UUID  `json:"user_id"`
+	UserName string     `json:"user_name"`
+	JoinedAt *time.Time `json:"joined_at"`
+}
+
+type Assignment struct {
+	Id         *uuid.UUID `json:"id"`
+	GroupId    uuid.UUID  `json:"group_id"`
+	GroupName  string     `json:"group_name,omitempty"`
+	Title      string     `json:"title"`
+	PackId     *uuid.UUID `json:"pack_id"`
+	SeedQuizId *uuid.UUID `json:"seed_quiz_id"`
+	DueAt      *time.Time `json:"due_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+type AssignmentMemberReport struct {
+	UserId         uuid.UUID  `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	QuizId         *uuid.UUID `json:"quiz_id"`
+	Completed      bool       `json:"completed"`
+	Score          int        `json:"score"`
+	TotalQuestions int        `json:"total_questions"`
+	TimeSeconds    *float64   `json:"time_seconds"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	MissedCities   []string   `json:"missed_cities"`
+}
+
+type AssignmentReport struct {
+	Assignment     Assignment               `json:"assignment"`
+	Members        []AssignmentMemberReport `json:"members"`
+	CommonlyMissed []MissedCity             `json:"commonly_missed"`
+}
@@ -0,0 +1,13 @@
+package models
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// Readiness reports whether the server can take traffic, with the outcome
+// of each check.
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Pack struct {
+	Id            *uuid.UUID  `json:"id"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description"`
+	CoverImageUrl string      `json:"cover_image_url"`
+	CoverColor    string      `json:"cover_color"`
+	QuestionIds   []uuid.UUID `json:"question_ids"`
+	CreatedAt     *time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time  `json:"updated_at"`
+}
+
+type PackHighScore struct {
+	UserName       string     `json:"user_name"`
+	QuizId         uuid.UUID  `json:"quiz_id"`
+	Score          int        `json:"score"`
+	TotalQuestions int        `json:"total_questions"`
+	AchievedAt     *time.Time `json:"achieved_at"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	QuestionStatusDraft     = "draft"
+	QuestionStatusInReview  = "in_review"
+	QuestionStatusPublished = "published"
+	QuestionStatusRetired   = "retired"
+)
+
+// AdminQuestion is a question together with its authoring metadata. Players
+// only ever see the embedded Question.
+type AdminQuestion struct {
+	Question
+	Status     string     `json:"status"`
+	CreatedBy  string     `json:"created_by"`
+	UpdatedBy  string     `json:"updated_by"`
+	ReviewedBy string     `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+	Rating     float64    `json:"rating"`
+	RatedGames int        `json:"rated_games"`
+}
+
+type QuestionListFilter struct {
+	Status         string `form:"status"`
+	Country        string `form:"country"`
+	Continent      string `form:"continent"`
+	Tag            string `form:"tag"`
+	Search         string `form:"search"`
+	IncludeDeleted bool   `form:"include_deleted"`
+	Limit          int    `form:"limit"`
+	Offset         int    `form:"offset"`
+}
+
+type QuestionStatusInput struct {
+	Status string `json:"status"`
+}
+
+type QuestionRevision struct {
+	Id             uuid.UUID  `json:"id"`
+	QuestionId     uuid.UUID  `json:"question_id"`
+	RevisionNumber int        `json:"revision_number"`
+	City           string     `json:"city"`
+	Country        string     `json:"country"`
+	Clues          []string   `json:"clues"`
+	FunFact        []string   `json:"fun_fact"`
+	Trivia         []string   `json:"trivia"`
+	Options        []string   `json:"options"`
+	CorrectAnswer  int        `json:"correct_answer"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+type RevisionFieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type RevisionDiff struct {
+	QuestionId uuid.UUID             `json:"question_id"`
+	From       int                   `json:"from"`
+	To         int                   `json:"to"`
+	Changes    []RevisionFieldChange `json:"changes"`
+}
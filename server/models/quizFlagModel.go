@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	QuizFlagOpen      = "open"
+	QuizFlagCleared   = "cleared"
+	QuizFlagConfirmed = "confirmed"
+)
+
+// QuizFlag marks a quiz the anti-cheat rules found suspicious. Until an
+// admin clears it, the quiz is left out of leaderboards.
+type QuizFlag struct {
+	QuizId     uuid.UUID        `json:"quiz_id"`
+	UserId     uuid.UUID        `json:"user_id"`
+	UserName   string           `json:"username"`
+	Score      int              `json:"score"`
+	Reasons    []QuizFlagReason `json:"reasons"`
+	Status     string           `json:"status"`
+	FlaggedAt  time.Time        `json:"flagged_at"`
+	ReviewedBy *string          `json:"reviewed_by"`
+	ReviewedAt *time.Time       `json:"reviewed_at"`
+}
+
+type QuizFlagReason struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+}
+
+type QuizFlagReviewInput struct {
+	Status string `json:"status"`
+}
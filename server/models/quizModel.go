@@ -10,28 +10,59 @@ type Question struct {
 	Id            *uuid.UUID `json:"id"`
 	City          string     `json:"city"`
 	Country       string     `json:"country"`
+	Continent     string     `json:"continent"`
+	Region        string     `json:"region"`
+	Tags          []string   `json:"tags"`
 	Clues         []string   `json:"clues"`
 	FunFact       []string   `json:"fun_fact"`
 	Trivia        []string   `json:"trivia"`
 	Options       []string   `json:"options"`
 	CorrectAnswer int        `json:"correct_answer"`
-	CreatedAt     *time.Time `json:"created_at"`
-	UpdatedAt     *time.Time `json:"updated_at"`
+	RevisionId    *uuid.UUID `json:"revision_id"`
+	Locale        string     `json:"locale,omitempty"`
+	// Token is issued with the question in a quiz and must be sent back
+	// with its answer.
+	Token     string     `json:"token,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
 }
 
+// Limits on quiz length and per-question timers, whether set on the quiz, in
+// the player's preferences or in the server's defaults.
+const (
+	MaxQuizLength       = 50
+	MinQuizTimerSeconds = 5
+	MaxQuizTimerSeconds = 300
+)
+
 type Quiz struct {
-	Id             *uuid.UUID `json:"id"`
-	UserId         uuid.UUID  `json:"user_id"`
-	Score          *int       `json:"score"`
-	TotalQuestions *int       `json:"total_questions"`
-	CreatedAt      *time.Time `json:"created_at"`
-	UpdatedAt      *time.Time `json:"updated_at"`
+	Id              *uuid.UUID `json:"id"`
+	UserId          uuid.UUID  `json:"user_id"`
+	Mode            string     `json:"mode"`
+	PackId          *uuid.UUID `json:"pack_id"`
+	Shuffle         bool       `json:"shuffle"`
+	IncludeTags     []string   `json:"include_tags"`
+	ExcludeTags     []string   `json:"exclude_tags"`
+	Score           *int       `json:"score"`
+	TotalQuestions  *int       `json:"total_questions"`
+	ChallengeQuizId *uuid.UUID `json:"challenge_quiz_id"`
+	AssignmentId    *uuid.UUID `json:"assignment_id"`
+	QuestionLimit   *int       `json:"question_limit"`
+	Difficulty      string     `json:"difficulty"`
+	TimerSeconds    *int       `json:"timer_seconds"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+	// Where the quiz was started from, for anti-cheat. Never returned.
+	ClientIP string `json:"-"`
+	DeviceId string `json:"-"`
 }
 
 type QuizQuestion struct {
 	Id          *uuid.UUID `json:"id"`
 	QuizId      uuid.UUID  `json:"quiz_session_id"`
 	QuestionId  uuid.UUID  `json:"question_id"`
+	RevisionId  *uuid.UUID `json:"revision_id"`
 	IsCorrect   bool       `json:"is_correct"`
 	UserAnswer  int        `json:"user_answer"`
 	OrderNumber int        `json:"order_number"`
@@ -39,17 +70,38 @@ type QuizQuestion struct {
 	UpdatedAt   *time.Time `json:"updated_at"`
 }
 
+type CreateQuizInput struct {
+	Name        string     `json:"name"`
+	Mode        string     `json:"mode"`
+	PackId      *uuid.UUID `json:"pack_id"`
+	Shuffle     bool       `json:"shuffle"`
+	IncludeTags []string   `json:"include_tags"`
+	ExcludeTags []string   `json:"exclude_tags"`
+	// Settings left unset fall back to the player's quiz preferences.
+	QuestionLimit *int    `json:"question_limit"`
+	Difficulty    *string `json:"difficulty"`
+	TimerSeconds  *int    `json:"timer_seconds"`
+	// Set by the handler from the request, not the body.
+	ClientIP string `json:"-"`
+	DeviceId string `json:"-"`
+}
+
 type QuizAnswerInput struct {
 	QuizId     uuid.UUID `json:"quiz_id"`
 	QuestionId uuid.UUID `json:"question_id"`
 	UserName   string    `json:"user_name"`
 	Answer     string    `json:"answer"`
+	// Token is the question token the question was issued with.
+	Token string `json:"token"`
 }
 
 type QuizAnswerResponse struct {
-	IsCorrect      bool `json:"is_correct"`
-	Score          int  `json:"score"`
-	TotalQuestions int  `json:"total_questions"`
+	IsCorrect       bool          `json:"is_correct"`
+	TimedOut        bool          `json:"timed_out"`
+	Score           int           `json:"score"`
+	TotalQuestions  int           `json:"total_questions"`
+	QuizCompleted   bool          `json:"quiz_completed"`
+	NewAchievements []Achievement `json:"new_achievements"`
 }
 
 type QuizScore struct {
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type RatedPlayer struct {
+	Rank       int       `json:"rank"`
+	UserId     uuid.UUID `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	Rating     float64   `json:"rating"`
+	RatedGames int       `json:"rated_games"`
+}
+
+type LeaderboardFilter struct {
+	MinGames int `form:"min_games"`
+	Limit    int `form:"limit"`
+}
+
+type RatingRecomputeResult struct {
+	AnswersReplayed int `json:"answers_replayed"`
+	PlayersRated    int `json:"players_rated"`
+	QuestionsRated  int `json:"questions_rated"`
+}
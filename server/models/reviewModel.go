@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	QuizModeClassic  = "classic"
+	QuizModePractice = "practice"
+)
+
+type ReviewCard struct {
+	QuestionId     uuid.UUID  `json:"question_id"`
+	City           string     `json:"city"`
+	Country        string     `json:"country"`
+	Ease           float64    `json:"ease"`
+	IntervalDays   int        `json:"interval_days"`
+	Repetitions    int        `json:"repetitions"`
+	DueAt          time.Time  `json:"due_at"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at"`
+}
+
+type ReviewQueue struct {
+	UserId     uuid.UUID    `json:"user_id"`
+	DueToday   int          `json:"due_today"`
+	TotalCards int          `json:"total_cards"`
+	Cards      []ReviewCard `json:"cards"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+type SessionInput struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+type Session struct {
+	User      User      `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles, from least to most privileged. Every user is a player; moderators
+// and admins reach the /admin routes their role allows with a staff key.
+const (
+	RolePlayer    = "player"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Actor is the staff member behind an /admin request.
+type Actor struct {
+	UserId   uuid.UUID `json:"user_id"`
+	UserName string    `json:"username"`
+	Role     string    `json:"role"`
+}
+
+// StaffGrant is the result of changing a user's role. StaffKey is only set
+// when a new key was issued, and is shown this once.
+type StaffGrant struct {
+	Actor
+	StaffKey string `json:"staff_key,omitempty"`
+}
+
+type RoleInput struct {
+	Role string `json:"role"`
+}
+
+// AuditEntry is one privileged action. Entries are never changed or removed.
+type AuditEntry struct {
+	Id int64 `json:"id"`
+	// ActorId is nil for actions taken from the command line.
+	ActorId   *uuid.UUID        `json:"actor_id"`
+	Actor     string            `json:"actor"`
+	ActorRole string            `json:"actor_role"`
+	Action    string            `json:"action"`
+	Target    map[string]string `json:"target"`
+	// Status is the HTTP status the action was answered with.
+	Status    *int      `json:"status"`
+	RequestId string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditFilter narrows the audit log. Before pages back from an entry id.
+type AuditFilter struct {
+	Actor  string `form:"actor"`
+	Action string `form:"action"`
+	Before int64  `form:"before"`
+	Limit  int    `form:"limit"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type AccuracyBreakdown struct {
+	Name     string  `json:"name"`
+	Answers  int     `json:"answers"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+type MissedCity struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+	Misses  int    `json:"misses"`
+}
+
+type UserStats struct {
+	UserId            uuid.UUID           `json:"user_id"`
+	TotalAnswers      int                 `json:"total_answers"`
+	CorrectAnswers    int                 `json:"correct_answers"`
+	Accuracy          float64             `json:"accuracy"`
+	BestStreak        int                 `json:"best_streak"`
+	CurrentStreak     int                 `json:"current_streak"`
+	AverageResponseMs *float64            `json:"average_response_ms"`
+	ByCountry         []AccuracyBreakdown `json:"by_country"`
+	ByContinent       []AccuracyBreakdown `json:"by_continent"`
+	MostMissed        []MissedCity        `json:"most_missed"`
+}
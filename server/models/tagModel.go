@@ -0,0 +1,21 @@
+package models
+
+type TagCount struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	QuestionCount int    `json:"question_count"`
+}
+
+type TagCoverage struct {
+	TotalQuestions   int        `json:"total_questions"`
+	MissingContinent int        `json:"missing_continent"`
+	MissingRegion    int        `json:"missing_region"`
+	Untagged         int        `json:"untagged"`
+	Tags             []TagCount `json:"tags"`
+}
+
+type QuestionTagsInput struct {
+	Continent string   `json:"continent"`
+	Region    string   `json:"region"`
+	Tags      []string `json:"tags"`
+}
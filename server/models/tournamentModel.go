@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TournamentStatusScheduled = "scheduled"
+	TournamentStatusRunning   = "running"
+	TournamentStatusFinished  = "finished"
+
+	RoundFormatTopN    = "top_n"
+	RoundFormatBracket = "bracket"
+
+	RoundStatusPending = "pending"
+	RoundStatusOpen    = "open"
+	RoundStatusClosed  = "closed"
+)
+
+type Tournament struct {
+	Id                   *uuid.UUID        `json:"id"`
+	Name                 string            `json:"name"`
+	Status               string            `json:"status"`
+	RegistrationOpensAt  time.Time         `json:"registration_opens_at"`
+	RegistrationClosesAt time.Time         `json:"registration_closes_at"`
+	Rounds               []TournamentRound `json:"rounds"`
+	ParticipantCount     int               `json:"participant_count"`
+	CreatedBy            string            `json:"created_by"`
+	CreatedAt            *time.Time        `json:"created_at"`
+	UpdatedAt            *time.Time        `json:"updated_at"`
+}
+
+type TournamentRound struct {
+	Id           *uuid.UUID `json:"id"`
+	TournamentId uuid.UUID  `json:"tournament_id"`
+	RoundNumber  int        `json:"round_number"`
+	Format       string     `json:"format"`
+	AdvanceCount *int       `json:"advance_count"`
+	PackId       uuid.UUID  `json:"pack_id"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	Status       string     `json:"status"`
+}
+
+type TournamentMatch struct {
+	Id            uuid.UUID  `json:"id"`
+	RoundNumber   int        `json:"round_number"`
+	PlayerOneId   uuid.UUID  `json:"player_one_id"`
+	PlayerOneName string     `json:"player_one_name"`
+	PlayerTwoId   *uuid.UUID `json:"player_two_id"`
+	PlayerTwoName *string    `json:"player_two_name"`
+	WinnerId      *uuid.UUID `json:"winner_id"`
+}
+
+type TournamentStanding struct {
+	Rank              int       `json:"rank"`
+	UserId            uuid.UUID `json:"user_id"`
+	UserName          string    `json:"user_name"`
+	EliminatedInRound *int      `json:"eliminated_in_round"`
+	RoundsPlayed      int       `json:"rounds_played"`
+	TotalScore        int       `json:"total_score"`
+}
+
+type TournamentStandings struct {
+	Tournament Tournament           `json:"tournament"`
+	Standings  []TournamentStanding `json:"standings"`
+	Matches    []TournamentMatch    `json:"matches"`
+}
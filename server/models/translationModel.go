@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuestionTranslation holds the player-facing text of a question in one
+// locale. Empty fields fall back to the canonical question, and Options must
+// line up with the canonical options so correct_answer still applies.
+type QuestionTranslation struct {
+	QuestionId uuid.UUID  `json:"question_id"`
+	Locale     string     `json:"locale"`
+	City       string     `json:"city"`
+	Country    string     `json:"country"`
+	Clues      []string   `json:"clues"`
+	FunFact    []string   `json:"fun_fact"`
+	Trivia     []string   `json:"trivia"`
+	Options    []string   `json:"options"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
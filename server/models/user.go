@@ -7,9 +7,45 @@ import (
 )
 
 type User struct {
-	Id        *uuid.UUID `json:"id"`
-	Name      string     `json:"name"`
-	Score     *int       `json:"score"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	Id              *uuid.UUID      `json:"id"`
+	Name            string          `json:"name"`
+	DisplayName     string          `json:"display_name"`
+	AvatarUrl       string          `json:"avatar_url"`
+	HomeCountry     string          `json:"home_country"`
+	Score           *int            `json:"score"`
+	Locale          string          `json:"locale"`
+	QuizPreferences QuizPreferences `json:"quiz_preferences"`
+	Rating          float64         `json:"rating"`
+	RatedGames      int             `json:"rated_games"`
+	DeletedAt       *time.Time      `json:"-"`
+	CreatedAt       *time.Time      `json:"created_at"`
+	UpdatedAt       *time.Time      `json:"updated_at"`
+	// Secret signs the player in. It is only set in the response that
+	// issues it.
+	Secret string `json:"secret,omitempty"`
+}
+
+// QuizPreferences are the defaults for quizzes the player creates without
+// saying otherwise. Unset fields leave the quiz unlimited.
+type QuizPreferences struct {
+	Length       *int   `json:"length"`
+	Difficulty   string `json:"difficulty"`
+	TimerSeconds *int   `json:"timer_seconds"`
+}
+
+// UserProfileInput is a partial profile update: only the fields present in
+// the request change. An empty string clears a text field.
+type UserProfileInput struct {
+	DisplayName     *string               `json:"display_name"`
+	AvatarUrl       *string               `json:"avatar_url"`
+	HomeCountry     *string               `json:"home_country"`
+	Locale          *string               `json:"locale"`
+	QuizPreferences *QuizPreferencesInput `json:"quiz_preferences"`
+}
+
+// QuizPreferencesInput updates quiz preferences. Zero clears a number.
+type QuizPreferencesInput struct {
+	Length       *int    `json:"length"`
+	Difficulty   *string `json:"difficulty"`
+	TimerSeconds *int    `json:"timer_seconds"`
 }
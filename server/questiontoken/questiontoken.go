@@ -0,0 +1,147 @@
+// Package questiontoken issues and verifies the tokens that come with every
+// question a quiz is given. An answer must carry its question's token, which
+// proves the server issued that question to that quiz at that position.
+package questiontoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTTL is how long after issue a question may be answered.
+const DefaultTTL = time.Hour
+
+var (
+	ErrInvalidToken = errors.New("invalid question token")
+	ErrExpiredToken = errors.New("question token has expired")
+)
+
+// Claims are what a token binds: the question's place in one quiz and when
+// it was handed out.
+type Claims struct {
+	QuizId     uuid.UUID
+	QuestionId uuid.UUID
+	Position   int
+	IssuedAt   time.Time
+}
+
+// Key is a signing secret and the id tokens name it by.
+type Key struct {
+	Id     string
+	Secret []byte
+}
+
+// Keyring creates tokens of the form keyId.payload.signature, signed with
+// HMAC-SHA256 by its first key. Tokens signed by any of its keys verify, so
+// a new key can be put first while the old one still accepts the answers
+// to questions it signed.
+type Keyring struct {
+	keys []Key
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewKeyring(keys []Key, ttl time.Duration) *Keyring {
+	return &Keyring{keys: keys, ttl: ttl, now: time.Now}
+}
+
+// GenerateKey returns a key with a random 32-byte secret, for servers that
+// have none configured. Its tokens do not verify on other instances or after
+// a restart.
+func GenerateKey(id string) (Key, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	return Key{Id: id, Secret: secret}, nil
+}
+
+// Sign issues a token for claims. A zero IssuedAt means now; the token
+// expires the keyring's TTL after it.
+func (k *Keyring) Sign(claims Claims) (string, error) {
+	if len(k.keys) == 0 {
+		return "", errors.New("no question token key")
+	}
+	if claims.IssuedAt.IsZero() {
+		claims.IssuedAt = k.now()
+	}
+	key := k.keys[0]
+	payload := strings.Join([]string{
+		claims.QuizId.String(),
+		claims.QuestionId.String(),
+		strconv.Itoa(claims.Position),
+		strconv.FormatInt(claims.IssuedAt.Unix(), 10),
+	}, "|")
+	signed := key.Id + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return signed + "." + signature(key.Secret, signed), nil
+}
+
+// Verify returns the claims of a token signed by one of the keys that has
+// not yet expired.
+func (k *Keyring) Verify(token string) (Claims, error) {
+	last := strings.LastIndex(token, ".")
+	if last < 0 {
+		return Claims{}, ErrInvalidToken
+	}
+	signed, sig := token[:last], token[last+1:]
+	keyId, encoded, ok := strings.Cut(signed, ".")
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+	key, ok := k.key(keyId)
+	if !ok || !hmac.Equal([]byte(sig), []byte(signature(key.Secret, signed))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 4 {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	if claims.QuizId, err = uuid.Parse(fields[0]); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.QuestionId, err = uuid.Parse(fields[1]); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.Position, err = strconv.Atoi(fields[2]); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	issuedUnix, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	claims.IssuedAt = time.Unix(issuedUnix, 0)
+	if k.now().After(claims.IssuedAt.Add(k.ttl)) {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func (k *Keyring) key(id string) (Key, bool) {
+	for _, key := range k.keys {
+		if key.Id == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+func signature(secret []byte, signed string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,149 @@
+package questiontoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	oldKey = Key{Id: "2026-10", Secret: []byte("old-secret")}
+	newKey = Key{Id: "2026-11", Secret: []byte("new-secret")}
+	issued = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+)
+
+// keyringAt returns a keyring whose clock reads now.
+func keyringAt(now time.Time, keys ...Key) *Keyring {
+	k := NewKeyring(keys, time.Hour)
+	k.now = func() time.Time { return now }
+	return k
+}
+
+func sign(t *testing.T, k *Keyring, claims Claims) string {
+	t.Helper()
+	token, err := k.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return token
+}
+
+func testClaims() Claims {
+	return Claims{QuizId: uuid.New(), QuestionId: uuid.New(), Position: 3, IssuedAt: issued}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	k := keyringAt(issued.Add(time.Minute), oldKey)
+	claims := testClaims()
+
+	got, err := k.Verify(sign(t, k, claims))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.QuizId != claims.QuizId || got.QuestionId != claims.QuestionId || got.Position != claims.Position || !got.IssuedAt.Equal(claims.IssuedAt) {
+		t.Errorf("Verify() = %+v, want %+v", got, claims)
+	}
+}
+
+func TestSignDefaultsIssuedAtToNow(t *testing.T) {
+	k := keyringAt(issued, oldKey)
+	claims := testClaims()
+	claims.IssuedAt = time.Time{}
+
+	got, err := k.Verify(sign(t, k, claims))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !got.IssuedAt.Equal(issued) {
+		t.Errorf("IssuedAt = %v, want %v", got.IssuedAt, issued)
+	}
+}
+
+func TestVerifyRejectsTamperedPayload(t *testing.T) {
+	k := keyringAt(issued, oldKey)
+	token := sign(t, k, testClaims())
+
+	// Move the question to another position, keeping the signature.
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(string(payload), "|")
+	fields[2] = "1"
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Join(fields, "|")))
+
+	if _, err := k.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsTamperedSignature(t *testing.T) {
+	k := keyringAt(issued, oldKey)
+	token := sign(t, k, testClaims())
+
+	last := token[len(token)-1]
+	flipped := byte('A')
+	if last == 'A' {
+		flipped = 'B'
+	}
+	if _, err := k.Verify(token[:len(token)-1] + string(flipped)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsUnknownKey(t *testing.T) {
+	token := sign(t, keyringAt(issued, newKey), testClaims())
+
+	if _, err := keyringAt(issued, oldKey).Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsMalformedTokens(t *testing.T) {
+	k := keyringAt(issued, oldKey)
+	for _, token := range []string{"", "nodots", "2026-10.onlyone", "2026-10.!!!.sig"} {
+		if _, err := k.Verify(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q) error = %v, want %v", token, err, ErrInvalidToken)
+		}
+	}
+}
+
+func TestVerifyAfterKeyRotation(t *testing.T) {
+	before := keyringAt(issued, oldKey)
+	after := keyringAt(issued, newKey, oldKey)
+
+	oldToken := sign(t, before, testClaims())
+	if _, err := after.Verify(oldToken); err != nil {
+		t.Errorf("token signed before rotation: Verify() error = %v", err)
+	}
+
+	newToken := sign(t, after, testClaims())
+	if !strings.HasPrefix(newToken, newKey.Id+".") {
+		t.Errorf("token signed after rotation is %q, want it signed by %s", newToken, newKey.Id)
+	}
+	if _, err := before.Verify(newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("new token on a keyring without the new key: Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyExpiresAfterTTL(t *testing.T) {
+	token := sign(t, keyringAt(issued, oldKey), testClaims())
+
+	if _, err := keyringAt(issued.Add(time.Hour), oldKey).Verify(token); err != nil {
+		t.Errorf("at exactly the TTL: Verify() error = %v, want nil", err)
+	}
+	if _, err := keyringAt(issued.Add(time.Hour+time.Second), oldKey).Verify(token); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("a second after the TTL: Verify() error = %v, want %v", err, ErrExpiredToken)
+	}
+}
+
+func TestSignWithoutKeys(t *testing.T) {
+	if _, err := keyringAt(issued).Sign(testClaims()); err == nil {
+		t.Error("Sign() error = nil, want an error without keys")
+	}
+}
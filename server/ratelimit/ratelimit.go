@@ -0,0 +1,112 @@
+// Package ratelimit implements token-bucket rate limits behind a Store, so
+// that buckets can live in memory or in a store shared between servers.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Burst tokens and refills at Rate
+// tokens per second. Each request takes one token. A zero Rate means no
+// limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Per returns the limit allowing n requests per period, in bursts of up to
+// burst.
+func Per(n int, period time.Duration, burst int) Limit {
+	if n <= 0 || period <= 0 {
+		return Limit{}
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: burst}
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Allow takes a token from the bucket at key. When none is left it
+	// reports false and how long until one will be.
+	Allow(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore keeps buckets in this process. Each server counts on its own,
+// so limits multiply with the number of servers.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	return &MemoryStore{now: now, buckets: make(map[string]*bucket), lastSweep: now()}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = b.refill(now)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration(math.Ceil((1 - b.tokens) / limit.Rate * float64(time.Second)))
+	return false, wait, nil
+}
+
+// refill returns the tokens the bucket holds at now.
+func (b *bucket) refill(now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*b.limit.Rate
+	if burst := float64(b.limit.Burst); tokens > burst {
+		tokens = burst
+	}
+	return tokens
+}
+
+// sweep drops buckets that would be full by now, since a new bucket starts
+// full anyway.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.refill(now) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
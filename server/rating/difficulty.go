@@ -0,0 +1,60 @@
+package rating
+
+import "math"
+
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Band is a half-open range of question ratings, [Min, Max).
+type Band struct {
+	Min float64
+	Max float64
+}
+
+// Questions within this distance of Initial count as medium.
+const mediumSpread = 100.0
+
+// DifficultyBand returns the question ratings that make up a difficulty
+// level. The empty difficulty allows every rating.
+func DifficultyBand(difficulty string) (Band, bool) {
+	switch difficulty {
+	case "":
+		return Band{Min: -math.MaxFloat64, Max: math.MaxFloat64}, true
+	case DifficultyEasy:
+		return Band{Min: -math.MaxFloat64, Max: Initial - mediumSpread}, true
+	case DifficultyMedium:
+		return Band{Min: Initial - mediumSpread, Max: Initial + mediumSpread}, true
+	case DifficultyHard:
+		return Band{Min: Initial + mediumSpread, Max: math.MaxFloat64}, true
+	}
+	return Band{}, false
+}
+
+// DifficultyBands returns the bands a quiz of the given difficulty picks
+// questions from, nearest first: the difficulty's own band, then the others.
+// Every question starts at Initial, so until questions have been played
+// enough to spread out, easy and hard quizzes fall back to medium ones.
+func DifficultyBands(difficulty string) ([]Band, bool) {
+	var order []string
+	switch difficulty {
+	case "":
+		order = []string{""}
+	case DifficultyEasy:
+		order = []string{DifficultyEasy, DifficultyMedium, DifficultyHard}
+	case DifficultyMedium:
+		order = []string{DifficultyMedium, DifficultyEasy, DifficultyHard}
+	case DifficultyHard:
+		order = []string{DifficultyHard, DifficultyMedium, DifficultyEasy}
+	default:
+		return nil, false
+	}
+
+	bands := make([]Band, len(order))
+	for i, d := range order {
+		bands[i], _ = DifficultyBand(d)
+	}
+	return bands, true
+}
@@ -0,0 +1,54 @@
+// Package rating scores players and questions on a shared Elo scale. Every
+// answer is a match between the player and the question: a correct answer is
+// a win for the player, a wrong one a win for the question.
+package rating
+
+import "math"
+
+// Initial is the rating every player and question starts from.
+const Initial = 1500.0
+
+// ProvisionalGames is how many rated answers a rating needs before it settles
+// and moves in smaller steps.
+const ProvisionalGames = 30
+
+const (
+	provisionalK = 40.0
+	establishedK = 16.0
+)
+
+type Rating struct {
+	Value float64
+	Games int
+}
+
+// NewRating returns an unplayed rating.
+func NewRating() Rating {
+	return Rating{Value: Initial}
+}
+
+// Expected is the probability that a rating of a beats a rating of b.
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Match returns the player and question ratings after the player answered the
+// question, correctly or not.
+func Match(player, question Rating, correct bool) (Rating, Rating) {
+	outcome := 0.0
+	if correct {
+		outcome = 1
+	}
+	expected := Expected(player.Value, question.Value)
+
+	newPlayer := Rating{Value: player.Value + kFactor(player)*(outcome-expected), Games: player.Games + 1}
+	newQuestion := Rating{Value: question.Value - kFactor(question)*(outcome-expected), Games: question.Games + 1}
+	return newPlayer, newQuestion
+}
+
+func kFactor(r Rating) float64 {
+	if r.Games < ProvisionalGames {
+		return provisionalK
+	}
+	return establishedK
+}
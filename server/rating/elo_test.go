@@ -0,0 +1,75 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestExpected(t *testing.T) {
+	tests := []struct {
+		a, b float64
+		want float64
+	}{
+		{a: 1500, b: 1500, want: 0.5},
+		{a: 1900, b: 1500, want: 10.0 / 11},
+		{a: 1500, b: 1900, want: 1.0 / 11},
+	}
+
+	for _, tt := range tests {
+		if got := Expected(tt.a, tt.b); !near(got, tt.want) {
+			t.Errorf("Expected(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMovesBothRatingsByTheirOwnK(t *testing.T) {
+	// Between equal ratings each side stands to gain or lose half its K.
+	tests := []struct {
+		name          string
+		playerGames   int
+		questionGames int
+		correct       bool
+		wantPlayer    float64
+		wantQuestion  float64
+	}{
+		{name: "new player answers right", playerGames: 0, questionGames: 0, correct: true, wantPlayer: 1520, wantQuestion: 1480},
+		{name: "new player answers wrong", playerGames: 0, questionGames: 0, correct: false, wantPlayer: 1480, wantQuestion: 1520},
+		{name: "last provisional game", playerGames: ProvisionalGames - 1, questionGames: 0, correct: true, wantPlayer: 1520, wantQuestion: 1480},
+		{name: "first established game", playerGames: ProvisionalGames, questionGames: 0, correct: true, wantPlayer: 1508, wantQuestion: 1480},
+		{name: "established question", playerGames: 0, questionGames: ProvisionalGames, correct: true, wantPlayer: 1520, wantQuestion: 1492},
+		{name: "both established", playerGames: 100, questionGames: 100, correct: false, wantPlayer: 1492, wantQuestion: 1508},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := Rating{Value: Initial, Games: tt.playerGames}
+			question := Rating{Value: Initial, Games: tt.questionGames}
+
+			gotPlayer, gotQuestion := Match(player, question, tt.correct)
+			if !near(gotPlayer.Value, tt.wantPlayer) || !near(gotQuestion.Value, tt.wantQuestion) {
+				t.Errorf("Match() ratings = %v and %v, want %v and %v", gotPlayer.Value, gotQuestion.Value, tt.wantPlayer, tt.wantQuestion)
+			}
+			if gotPlayer.Games != tt.playerGames+1 || gotQuestion.Games != tt.questionGames+1 {
+				t.Errorf("Match() games = %d and %d, want %d and %d", gotPlayer.Games, gotQuestion.Games, tt.playerGames+1, tt.questionGames+1)
+			}
+		})
+	}
+}
+
+func TestMatchRewardsUpsets(t *testing.T) {
+	player := Rating{Value: 1500, Games: ProvisionalGames}
+	question := Rating{Value: 1900, Games: ProvisionalGames}
+
+	// The player was expected to get this wrong 10 times in 11.
+	gotPlayer, gotQuestion := Match(player, question, true)
+	if want := 1500 + 16*10.0/11; !near(gotPlayer.Value, want) {
+		t.Errorf("player rating = %v, want %v", gotPlayer.Value, want)
+	}
+	if want := 1900 - 16*10.0/11; !near(gotQuestion.Value, want) {
+		t.Errorf("question rating = %v, want %v", gotQuestion.Value, want)
+	}
+}
@@ -0,0 +1,55 @@
+// Package rbac decides which roles may take which privileged actions.
+// Moderators look after content and reports; admins may do everything.
+package rbac
+
+import "github.com/axitdhola/globetrotter/server/models"
+
+type Permission string
+
+const (
+	// ReviewQuestions covers editing questions, their status, tags and
+	// translations.
+	ReviewQuestions Permission = "review_questions"
+	// DeleteQuestions covers removing questions outright.
+	DeleteQuestions Permission = "delete_questions"
+	// HandleReports covers reviewing quizzes flagged for cheating.
+	HandleReports     Permission = "handle_reports"
+	ManagePacks       Permission = "manage_packs"
+	ManageTournaments Permission = "manage_tournaments"
+	// ManageUsers covers granting and revoking roles and resetting players'
+	// sign-in secrets.
+	ManageUsers Permission = "manage_users"
+	// ManageSystem covers backfills and recomputations.
+	ManageSystem Permission = "manage_system"
+	ViewAuditLog Permission = "view_audit_log"
+)
+
+var moderatorPermissions = map[Permission]bool{
+	ReviewQuestions: true,
+	HandleReports:   true,
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	switch role {
+	case models.RolePlayer, models.RoleModerator, models.RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// IsStaff reports whether role reaches any /admin route.
+func IsStaff(role string) bool {
+	return role == models.RoleModerator || role == models.RoleAdmin
+}
+
+// Can reports whether role grants p.
+func Can(role string, p Permission) bool {
+	switch role {
+	case models.RoleAdmin:
+		return true
+	case models.RoleModerator:
+		return moderatorPermissions[p]
+	}
+	return false
+}
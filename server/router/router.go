@@ -1,38 +1,234 @@
 package router
 
 import (
+	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/axitdhola/globetrotter/server/auth"
+	"github.com/axitdhola/globetrotter/server/config"
 	"github.com/axitdhola/globetrotter/server/handlers"
+	"github.com/axitdhola/globetrotter/server/metrics"
+	"github.com/axitdhola/globetrotter/server/middleware"
+	"github.com/axitdhola/globetrotter/server/rbac"
+	"github.com/axitdhola/globetrotter/server/tracing"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func InitRouter(userHandler handlers.UserHandler, quizHandler handlers.QuizHandler) *gin.Engine {
-	r := gin.Default()
+type Handlers struct {
+	User        handlers.UserHandler
+	Quiz        handlers.QuizHandler
+	Pack        handlers.PackHandler
+	Tag         handlers.TagHandler
+	Question    handlers.QuestionHandler
+	Achievement handlers.AchievementHandler
+	Rating      handlers.RatingHandler
+	Friend      handlers.FriendHandler
+	Group       handlers.GroupHandler
+	Tournament  handlers.TournamentHandler
+	AntiCheat   handlers.AntiCheatHandler
+	Staff       handlers.StaffHandler
+	Health      handlers.HealthHandler
+}
+
+// Options are the settings the routes depend on.
+type Options struct {
+	// Staff authenticates the /admin routes, which are disabled without it.
+	Staff middleware.StaffAuthenticator
+	// Audit, when set, records every privileged action.
+	Audit       middleware.AuditRecorder
+	CORSOrigins []string
+	Features    config.Features
+	Signer      *auth.Signer
+	// RequestTimeout is the deadline for requests whose route is not in
+	// RouteTimeouts.
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+	// Logger is the base for each request's logger.
+	Logger *slog.Logger
+	// Metrics, when set, are recorded for every request and served at
+	// /metrics.
+	Metrics *metrics.Metrics
+	// Tracing starts a span for every request, continuing any W3C trace
+	// context the client sent.
+	Tracing bool
+	// TrustedProxies are the proxies whose X-Forwarded-For names the
+	// client; see config.Server.
+	TrustedProxies []string
+	// RateLimiter, when set, applies RateLimits to the answer, registration
+	// and sign-in routes.
+	RateLimiter *middleware.RateLimiter
+	RateLimits  config.RateLimit
+}
+
+func InitRouter(h Handlers, opts Options) *gin.Engine {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(opts.TrustedProxies); err != nil {
+		// config.Validate has already checked them.
+		panic(err)
+	}
+	if opts.Tracing {
+		r.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+			// Probes and scrapes would drown out real traffic.
+			switch req.URL.Path {
+			case "/healthz", "/readyz", "/metrics":
+				return false
+			}
+			return true
+		})))
+	}
+	r.Use(middleware.RequestID(logger), middleware.AccessLog(), middleware.Recovery())
+	if opts.Metrics != nil {
+		r.Use(middleware.Metrics(opts.Metrics))
+	}
+	signer := opts.Signer
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"}, // Only allow your frontend origin
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Accept"}, // Added 'Accept'
-		ExposeHeaders:    []string{"Content-Length"},
+	corsConfig := cors.Config{
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Accept-Language", "traceparent", "tracestate", middleware.RequestIDHeader, handlers.DeviceIdHeader, middleware.AdminKeyHeader}, // Added 'Accept'
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
-	}))
+	}
+	corsConfig.AllowOrigins = opts.CORSOrigins
+	for _, origin := range opts.CORSOrigins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+			corsConfig.AllowOrigins = nil
+		}
+	}
+	r.Use(cors.New(corsConfig))
+
+	limit := func(group string, rates config.RateLimitGroup) gin.HandlerFunc {
+		if opts.RateLimiter == nil {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return opts.RateLimiter.Limit(group, rates.PerIP.Limit(), rates.PerUser.Limit())
+	}
+	limitAnswers := func(group string, rates config.RateLimitGroup) gin.HandlerFunc {
+		if opts.RateLimiter == nil {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return opts.RateLimiter.LimitAnswers(group, rates.PerIP.Limit(), rates.PerUser.Limit())
+	}
+	r.Use(middleware.Timeout(opts.RequestTimeout, opts.RouteTimeouts))
+
+	r.GET("/healthz", h.Health.Healthz)
+	r.GET("/readyz", h.Health.Readyz)
+	if opts.Metrics != nil {
+		r.GET("/metrics", gin.WrapH(opts.Metrics.Handler()))
+	}
 
 	userGroup := r.Group("/user")
 	{
-		userGroup.GET("/:id", userHandler.GetUser)
-		userGroup.POST("/register", userHandler.RegisterUser)
+		userGroup.GET("/me", middleware.RequireUser(signer), h.User.GetProfile)
+		userGroup.PATCH("/me", middleware.RequireUser(signer), h.User.UpdateProfile)
+		userGroup.DELETE("/me", middleware.RequireUser(signer), h.User.DeleteUser)
+		userGroup.GET("/:id", h.User.GetUser)
+		userGroup.POST("/register", limit("register", opts.RateLimits.Register), h.User.RegisterUser)
+		userGroup.POST("/session", limit("session", opts.RateLimits.Session), h.User.CreateSession)
+		userGroup.GET("/:id/stats", h.User.GetUserStats)
+		userGroup.GET("/:id/achievements", h.User.GetUserAchievements)
+		userGroup.GET("/:id/reviews", h.User.GetReviewQueue)
 	}
 
 	quizGroup := r.Group("/quiz")
 	{
-		quizGroup.GET("/:quiz_id/question", quizHandler.GetQuizQuestion)
-		quizGroup.POST("/answer", quizHandler.SaveQuizAnswer)
-		quizGroup.POST(("/create"), quizHandler.CreateQuiz)
-		quizGroup.GET("/:quiz_id/score", quizHandler.GetQuizScore)
-		quizGroup.GET("/list/:username", quizHandler.ListQuizByUserName)
+		quizGroup.GET("/:quiz_id/question", h.Quiz.GetQuizQuestion)
+		quizGroup.POST("/answer", limitAnswers("answer", opts.RateLimits.Answer), h.Quiz.SaveQuizAnswer)
+		quizGroup.POST(("/create"), h.Quiz.CreateQuiz)
+		quizGroup.GET("/:quiz_id/score", h.Quiz.GetQuizScore)
+		quizGroup.GET("/list/:username", h.Quiz.ListQuizByUserName)
+	}
+
+	r.GET("/leaderboard", h.Rating.GetLeaderboard)
+
+	if opts.Features.Friends {
+		friendGroup := r.Group("/friends", middleware.RequireUser(signer))
+		friendGroup.GET("", h.Friend.ListFriends)
+		friendGroup.DELETE("/:user_id", h.Friend.RemoveFriend)
+		friendGroup.GET("/requests", h.Friend.ListFriendRequests)
+		friendGroup.POST("/requests", h.Friend.SendFriendRequest)
+		friendGroup.POST("/requests/:request_id/accept", h.Friend.AcceptFriendRequest)
+		friendGroup.POST("/requests/:request_id/decline", h.Friend.DeclineFriendRequest)
+		friendGroup.POST("/blocks", h.Friend.BlockUser)
+		friendGroup.DELETE("/blocks/:user_id", h.Friend.UnblockUser)
+		friendGroup.GET("/leaderboard", h.Friend.GetFriendsLeaderboard)
+		friendGroup.GET("/activity", h.Friend.GetFriendActivity)
+	}
+
+	if opts.Features.Groups {
+		groupGroup := r.Group("/groups", middleware.RequireUser(signer))
+		groupGroup.GET("", h.Group.ListGroups)
+		groupGroup.POST("", h.Group.CreateGroup)
+		groupGroup.POST("/join", h.Group.JoinGroup)
+		groupGroup.GET("/assignments", h.Group.ListOpenAssignments)
+		groupGroup.POST("/assignments/:assignment_id/start", h.Group.StartAssignment)
+		groupGroup.GET("/:group_id/members", h.Group.ListGroupMembers)
+		groupGroup.GET("/:group_id/assignments", h.Group.ListGroupAssignments)
+		groupGroup.POST("/:group_id/assignments", h.Group.CreateAssignment)
+		groupGroup.GET("/:group_id/assignments/:assignment_id/report", h.Group.GetAssignmentReport)
+	}
+
+	if opts.Features.Tournaments {
+		tournamentGroup := r.Group("/tournaments")
+		requireUser := middleware.RequireUser(signer)
+		tournamentGroup.GET("", h.Tournament.ListTournaments)
+		tournamentGroup.GET("/:tournament_id", h.Tournament.GetTournament)
+		tournamentGroup.GET("/:tournament_id/standings", h.Tournament.GetStandings)
+		tournamentGroup.POST("/:tournament_id/register", requireUser, h.Tournament.Register)
+		tournamentGroup.POST("/:tournament_id/rounds/:round_number/start", requireUser, h.Tournament.StartRound)
+	}
+
+	packGroup := r.Group("/pack")
+	{
+		packGroup.GET("", h.Pack.ListPacks)
+		packGroup.GET("/:pack_id", h.Pack.GetPack)
+		packGroup.GET("/:pack_id/scores", h.Pack.GetPackHighScores)
+	}
+
+	adminGroup := r.Group("/admin", middleware.RequireStaff(opts.Staff))
+	if opts.Audit != nil {
+		adminGroup.Use(middleware.Audit(opts.Audit))
+	}
+	{
+		can := middleware.RequirePermission
+
+		adminGroup.POST("/packs", can(rbac.ManagePacks), h.Pack.CreatePack)
+		adminGroup.PUT("/packs/:pack_id", can(rbac.ManagePacks), h.Pack.UpdatePack)
+		adminGroup.DELETE("/packs/:pack_id", can(rbac.ManagePacks), h.Pack.DeletePack)
+		adminGroup.GET("/tags", can(rbac.ReviewQuestions), h.Tag.GetTagCoverage)
+		adminGroup.GET("/questions", can(rbac.ReviewQuestions), h.Question.ListQuestions)
+		adminGroup.POST("/questions", can(rbac.ReviewQuestions), h.Question.CreateQuestion)
+		adminGroup.GET("/questions/:question_id", can(rbac.ReviewQuestions), h.Question.GetQuestion)
+		adminGroup.PUT("/questions/:question_id", can(rbac.ReviewQuestions), h.Question.UpdateQuestion)
+		adminGroup.DELETE("/questions/:question_id", can(rbac.DeleteQuestions), h.Question.DeleteQuestion)
+		adminGroup.POST("/questions/:question_id/status", can(rbac.ReviewQuestions), h.Question.SetQuestionStatus)
+		adminGroup.PUT("/questions/:question_id/tags", can(rbac.ReviewQuestions), h.Tag.SetQuestionTags)
+		adminGroup.GET("/questions/:question_id/revisions", can(rbac.ReviewQuestions), h.Question.ListQuestionRevisions)
+		adminGroup.GET("/questions/:question_id/revisions/diff", can(rbac.ReviewQuestions), h.Question.DiffQuestionRevisions)
+		adminGroup.GET("/questions/:question_id/translations", can(rbac.ReviewQuestions), h.Question.ListQuestionTranslations)
+		adminGroup.PUT("/questions/:question_id/translations/:locale", can(rbac.ReviewQuestions), h.Question.SaveQuestionTranslation)
+		adminGroup.DELETE("/questions/:question_id/translations/:locale", can(rbac.ReviewQuestions), h.Question.DeleteQuestionTranslation)
+		adminGroup.POST("/achievements/backfill", can(rbac.ManageSystem), h.Achievement.BackfillAchievements)
+		adminGroup.POST("/ratings/recompute", can(rbac.ManageSystem), h.Rating.RecomputeRatings)
+		adminGroup.GET("/quiz-flags", can(rbac.HandleReports), h.AntiCheat.ListQuizFlags)
+		adminGroup.POST("/quiz-flags/:quiz_id/review", can(rbac.HandleReports), h.AntiCheat.ReviewQuizFlag)
+		adminGroup.GET("/staff", can(rbac.ManageUsers), h.Staff.ListStaff)
+		adminGroup.PUT("/users/:user_id/role", can(rbac.ManageUsers), h.Staff.SetUserRole)
+		adminGroup.POST("/users/:user_id/secret", can(rbac.ManageUsers), h.User.ResetSecret)
+		adminGroup.GET("/audit-log", can(rbac.ViewAuditLog), h.Staff.ListAuditLog)
+		if opts.Features.Tournaments {
+			adminGroup.POST("/tournaments", can(rbac.ManageTournaments), h.Tournament.CreateTournament)
+			adminGroup.POST("/tournaments/advance", can(rbac.ManageTournaments), h.Tournament.AdvanceRounds)
+		}
 	}
 
 	return r
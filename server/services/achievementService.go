@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/axitdhola/globetrotter/server/achievements"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+type AchievementService interface {
+	CheckAchievements(ctx context.Context, userId uuid.UUID, quizId *uuid.UUID, trigger achievements.Trigger) ([]models.Achievement, error)
+	ListUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error)
+	BackfillAchievements(ctx context.Context) (models.AchievementBackfillResult, error)
+}
+
+type achievementServiceImpl struct {
+	achievementDao dao.AchievementDao
+}
+
+func NewAchievementService(achievementDao dao.AchievementDao) AchievementService {
+	return &achievementServiceImpl{achievementDao: achievementDao}
+}
+
+// CheckAchievements runs the rules for trigger against the user's history and
+// awards whatever they newly qualify for.
+func (a *achievementServiceImpl) CheckAchievements(ctx context.Context, userId uuid.UUID, quizId *uuid.UUID, trigger achievements.Trigger) ([]models.Achievement, error) {
+	earned, err := a.achievementDao.ListUserAchievements(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	earnedIds := make(map[string]bool, len(earned))
+	for _, achievement := range earned {
+		earnedIds[achievement.Id] = true
+	}
+	if len(earnedIds) == len(achievements.Rules) {
+		return []models.Achievement{}, nil
+	}
+
+	facts, err := a.achievementDao.GetUserFacts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := achievements.Evaluate(trigger, facts, earnedIds)
+	if len(rules) == 0 {
+		return []models.Achievement{}, nil
+	}
+	ids := make([]string, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.Id
+	}
+
+	awarded, err := a.achievementDao.AwardAchievements(ctx, userId, quizId, ids)
+	if err != nil {
+		return nil, err
+	}
+	return describeAchievements(awarded), nil
+}
+
+func (a *achievementServiceImpl) ListUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error) {
+	earned, err := a.achievementDao.ListUserAchievements(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return describeAchievements(earned), nil
+}
+
+// BackfillAchievements checks every rule for every user, so badges added
+// after the fact are awarded for history that already qualifies.
+func (a *achievementServiceImpl) BackfillAchievements(ctx context.Context) (models.AchievementBackfillResult, error) {
+	var result models.AchievementBackfillResult
+
+	userIds, err := a.achievementDao.ListUserIds(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, userId := range userIds {
+		awarded, err := a.CheckAchievements(ctx, userId, nil, achievements.OnBackfill)
+		if err != nil {
+			return result, err
+		}
+		result.UsersChecked++
+		result.AchievementsAwarded += len(awarded)
+	}
+
+	return result, nil
+}
+
+// describeAchievements fills in names and descriptions from the rules. Badges
+// whose rule has since been removed are dropped.
+func describeAchievements(earned []models.Achievement) []models.Achievement {
+	described := make([]models.Achievement, 0, len(earned))
+	for _, achievement := range earned {
+		rule, ok := achievements.Lookup(achievement.Id)
+		if !ok {
+			continue
+		}
+		achievement.Name = rule.Name
+		achievement.Description = rule.Description
+		described = append(described, achievement)
+	}
+	return described
+}
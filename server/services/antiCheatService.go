@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/anticheat"
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/tracing"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrQuizFlagNotFound      = apperrors.New(apperrors.NotFound, "quiz has not been flagged")
+	ErrInvalidQuizFlagStatus = apperrors.New(apperrors.Validation, "status must be open, cleared, confirmed or all")
+	ErrInvalidQuizFlagReview = apperrors.New(apperrors.Validation, "status must be cleared or confirmed")
+)
+
+type AntiCheatService interface {
+	CheckQuiz(ctx context.Context, quizId uuid.UUID, answered int, completed bool) error
+	ListFlags(ctx context.Context, status string) ([]models.QuizFlag, error)
+	ReviewFlag(ctx context.Context, quizId uuid.UUID, status string, reviewer string) (models.QuizFlag, error)
+}
+
+type antiCheatServiceImpl struct {
+	antiCheatDao dao.AntiCheatDao
+
+	rules anticheat.Rules
+	// checkEvery is how many answers pass between checks; 0 turns checking
+	// off while leaving existing flags to review.
+	checkEvery    int
+	accountWindow time.Duration
+}
+
+func NewAntiCheatService(antiCheatDao dao.AntiCheatDao, rules anticheat.Rules, checkEvery int, accountWindow time.Duration) AntiCheatService {
+	return &antiCheatServiceImpl{antiCheatDao: antiCheatDao, rules: rules, checkEvery: checkEvery, accountWindow: accountWindow}
+}
+
+// CheckQuiz runs the rules over a quiz after its answered-th answer, every
+// checkEvery answers and once more when it is completed, and flags it if any
+// rule is broken. Quizzes already flagged are left alone, so a review is
+// never overturned.
+func (a *antiCheatServiceImpl) CheckQuiz(ctx context.Context, quizId uuid.UUID, answered int, completed bool) error {
+	if a.checkEvery <= 0 || (!completed && answered%a.checkEvery != 0) {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "AntiCheatService.CheckQuiz", tracing.QuizIdKey.String(quizId.String()))
+	defer span.End()
+
+	flagged, err := a.antiCheatDao.IsQuizFlagged(ctx, quizId)
+	if err != nil || flagged {
+		return err
+	}
+
+	history := anticheat.History{}
+	history.Answers, err = a.antiCheatDao.ListQuizAnswers(ctx, quizId)
+	if err != nil {
+		return err
+	}
+	if a.rules.MaxAccountsPerIP > 0 || a.rules.MaxAccountsPerDevice > 0 {
+		history.AccountsOnIP, history.AccountsOnDevice, err = a.antiCheatDao.CountSharedAccounts(ctx, quizId, a.accountWindow)
+		if err != nil {
+			return err
+		}
+	}
+	if a.rules.MinSequenceLength > 0 && len(history.Answers) >= a.rules.MinSequenceLength {
+		history.IdenticalQuizzes, err = a.antiCheatDao.CountIdenticalQuizzes(ctx, quizId, a.rules.MinSequenceLength)
+		if err != nil {
+			return err
+		}
+	}
+
+	flags := a.rules.Check(history)
+	if len(flags) == 0 {
+		return nil
+	}
+
+	reasons := make([]models.QuizFlagReason, len(flags))
+	for i, flag := range flags {
+		reasons[i] = models.QuizFlagReason{Rule: flag.Rule, Detail: flag.Detail}
+	}
+	return a.antiCheatDao.FlagQuiz(ctx, quizId, reasons)
+}
+
+func (a *antiCheatServiceImpl) ListFlags(ctx context.Context, status string) ([]models.QuizFlag, error) {
+	switch status {
+	case "", models.QuizFlagOpen, models.QuizFlagCleared, models.QuizFlagConfirmed:
+	default:
+		return nil, ErrInvalidQuizFlagStatus
+	}
+	return a.antiCheatDao.ListQuizFlags(ctx, status)
+}
+
+// ReviewFlag records an admin's verdict on a flagged quiz. Cleared quizzes
+// return to the leaderboards; confirmed ones stay off them.
+func (a *antiCheatServiceImpl) ReviewFlag(ctx context.Context, quizId uuid.UUID, status string, reviewer string) (models.QuizFlag, error) {
+	if status != models.QuizFlagCleared && status != models.QuizFlagConfirmed {
+		return models.QuizFlag{}, ErrInvalidQuizFlagReview
+	}
+
+	flag, err := a.antiCheatDao.ReviewQuizFlag(ctx, quizId, status, reviewer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.QuizFlag{}, ErrQuizFlagNotFound
+	}
+	return flag, err
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+type AuditService interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditServiceImpl struct {
+	auditDao dao.AuditDao
+}
+
+func NewAuditService(auditDao dao.AuditDao) AuditService {
+	return &auditServiceImpl{auditDao: auditDao}
+}
+
+func (a *auditServiceImpl) Record(ctx context.Context, entry models.AuditEntry) error {
+	return a.auditDao.RecordAudit(ctx, entry)
+}
+
+func (a *auditServiceImpl) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	if filter.Limit > maxAuditLogLimit {
+		filter.Limit = maxAuditLogLimit
+	}
+	return a.auditDao.ListAuditLog(ctx, filter)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFriendActivityLimit = 20
+	maxFriendActivityLimit     = 100
+)
+
+var (
+	ErrFriendRequestNotFound = apperrors.New(apperrors.NotFound, "friend request not found")
+	ErrFriendRequestExists   = apperrors.New(apperrors.Conflict, "a friend request between you is already open")
+	ErrAlreadyFriends        = apperrors.New(apperrors.Conflict, "already friends")
+	ErrNotFriends            = apperrors.New(apperrors.NotFound, "not friends")
+	ErrNotBlocked            = apperrors.New(apperrors.NotFound, "user is not blocked")
+	ErrSelfFriendship        = apperrors.New(apperrors.Validation, "cannot befriend or block yourself")
+	ErrBlocked               = apperrors.New(apperrors.Forbidden, "user is blocked")
+)
+
+type FriendService interface {
+	SendFriendRequest(ctx context.Context, userId uuid.UUID, input models.FriendRequestInput) (models.FriendRequest, error)
+	RespondToFriendRequest(ctx context.Context, userId uuid.UUID, requestId uuid.UUID, accept bool) error
+	ListFriendRequests(ctx context.Context, userId uuid.UUID) (models.FriendRequests, error)
+	ListFriends(ctx context.Context, userId uuid.UUID) ([]models.Friend, error)
+	RemoveFriend(ctx context.Context, userId uuid.UUID, friendId uuid.UUID) error
+	BlockUser(ctx context.Context, userId uuid.UUID, input models.FriendRequestInput) error
+	UnblockUser(ctx context.Context, userId uuid.UUID, blockedId uuid.UUID) error
+	IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
+	GetFriendsLeaderboard(ctx context.Context, userId uuid.UUID) ([]models.FriendStanding, error)
+	GetFriendActivity(ctx context.Context, userId uuid.UUID, limit int) ([]models.FriendActivity, error)
+}
+
+type friendServiceImpl struct {
+	friendDao dao.FriendDao
+	userDao   dao.UserDao
+}
+
+func NewFriendService(friendDao dao.FriendDao, userDao dao.UserDao) FriendService {
+	return &friendServiceImpl{friendDao: friendDao, userDao: userDao}
+}
+
+// SendFriendRequest asks the named user to be friends. If they already asked
+// the sender, their request is accepted instead.
+func (f *friendServiceImpl) SendFriendRequest(ctx context.Context, userId uuid.UUID, input models.FriendRequestInput) (models.FriendRequest, error) {
+	recipientId, err := f.otherUserId(ctx, userId, input.UserName)
+	if err != nil {
+		return models.FriendRequest{}, err
+	}
+
+	blocked, err := f.friendDao.IsBlocked(ctx, userId, recipientId)
+	if err != nil {
+		return models.FriendRequest{}, err
+	}
+	if blocked {
+		return models.FriendRequest{}, ErrBlocked
+	}
+
+	friends, err := f.friendDao.AreFriends(ctx, userId, recipientId)
+	if err != nil {
+		return models.FriendRequest{}, err
+	}
+	if friends {
+		return models.FriendRequest{}, ErrAlreadyFriends
+	}
+
+	reverse, err := f.friendDao.GetPendingFriendRequest(ctx, recipientId, userId)
+	if err == nil {
+		if err := f.friendDao.RespondToFriendRequest(ctx, reverse.Id, userId, true); err != nil {
+			return models.FriendRequest{}, err
+		}
+		reverse.Status = models.FriendRequestAccepted
+		return reverse, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.FriendRequest{}, err
+	}
+
+	request, err := f.friendDao.CreateFriendRequest(ctx, userId, recipientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.FriendRequest{}, ErrFriendRequestExists
+	}
+	return request, err
+}
+
+func (f *friendServiceImpl) RespondToFriendRequest(ctx context.Context, userId uuid.UUID, requestId uuid.UUID, accept bool) error {
+	err := f.friendDao.RespondToFriendRequest(ctx, requestId, userId, accept)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFriendRequestNotFound
+	}
+	return err
+}
+
+func (f *friendServiceImpl) ListFriendRequests(ctx context.Context, userId uuid.UUID) (models.FriendRequests, error) {
+	return f.friendDao.ListFriendRequests(ctx, userId)
+}
+
+func (f *friendServiceImpl) ListFriends(ctx context.Context, userId uuid.UUID) ([]models.Friend, error) {
+	return f.friendDao.ListFriends(ctx, userId)
+}
+
+func (f *friendServiceImpl) RemoveFriend(ctx context.Context, userId uuid.UUID, friendId uuid.UUID) error {
+	err := f.friendDao.RemoveFriend(ctx, userId, friendId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFriends
+	}
+	return err
+}
+
+func (f *friendServiceImpl) BlockUser(ctx context.Context, userId uuid.UUID, input models.FriendRequestInput) error {
+	blockedId, err := f.otherUserId(ctx, userId, input.UserName)
+	if err != nil {
+		return err
+	}
+	return f.friendDao.BlockUser(ctx, userId, blockedId)
+}
+
+func (f *friendServiceImpl) UnblockUser(ctx context.Context, userId uuid.UUID, blockedId uuid.UUID) error {
+	err := f.friendDao.UnblockUser(ctx, userId, blockedId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotBlocked
+	}
+	return err
+}
+
+func (f *friendServiceImpl) IsBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	return f.friendDao.IsBlocked(ctx, userId, otherId)
+}
+
+func (f *friendServiceImpl) GetFriendsLeaderboard(ctx context.Context, userId uuid.UUID) ([]models.FriendStanding, error) {
+	return f.friendDao.GetFriendsLeaderboard(ctx, userId)
+}
+
+func (f *friendServiceImpl) GetFriendActivity(ctx context.Context, userId uuid.UUID, limit int) ([]models.FriendActivity, error) {
+	if limit <= 0 {
+		limit = defaultFriendActivityLimit
+	}
+	if limit > maxFriendActivityLimit {
+		limit = maxFriendActivityLimit
+	}
+	return f.friendDao.GetFriendActivity(ctx, userId, limit)
+}
+
+// otherUserId looks up the named user, who must not be userId.
+func (f *friendServiceImpl) otherUserId(ctx context.Context, userId uuid.UUID, name string) (uuid.UUID, error) {
+	user, err := findUserByName(ctx, f.userDao, name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if *user.Id == userId {
+		return uuid.Nil, ErrSelfFriendship
+	}
+	return *user.Id, nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+const (
+	inviteCodeLength         = 8
+	inviteCodeAttempts       = 5
+	assignmentMissedLimit    = 10
+	inviteCodeAlphabet       = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	maxGroupNameLength       = 255
+	maxAssignmentTitleLength = 255
+)
+
+var (
+	ErrGroupNotFound      = apperrors.New(apperrors.NotFound, "group not found")
+	ErrAssignmentNotFound = apperrors.New(apperrors.NotFound, "assignment not found")
+	ErrNotGroupOwner      = apperrors.New(apperrors.Forbidden, "only the group owner can do this")
+	ErrNotGroupMember     = apperrors.New(apperrors.Forbidden, "not a member of this group")
+	ErrInvalidGroup       = apperrors.New(apperrors.Validation, "invalid group")
+	ErrInvalidAssignment  = apperrors.New(apperrors.Validation, "invalid assignment")
+	ErrAssignmentClosed   = apperrors.New(apperrors.Conflict, "assignment is past due")
+)
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, userId uuid.UUID, input models.GroupInput) (models.Group, error)
+	ListGroups(ctx context.Context, userId uuid.UUID) ([]models.Group, error)
+	JoinGroup(ctx context.Context, userId uuid.UUID, input models.JoinGroupInput) (models.Group, error)
+	ListGroupMembers(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) ([]models.GroupMember, error)
+	CreateAssignment(ctx context.Context, userId uuid.UUID, groupId uuid.UUID, input models.Assignment) (models.Assignment, error)
+	ListGroupAssignments(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) ([]models.Assignment, error)
+	ListOpenAssignments(ctx context.Context, userId uuid.UUID) ([]models.Assignment, error)
+	StartAssignment(ctx context.Context, userId uuid.UUID, assignmentId uuid.UUID) (models.Quiz, error)
+	GetAssignmentReport(ctx context.Context, userId uuid.UUID, groupId uuid.UUID, assignmentId uuid.UUID) (models.AssignmentReport, error)
+}
+
+type groupServiceImpl struct {
+	groupDao dao.GroupDao
+	quizDao  dao.QuizDao
+	packDao  dao.PackDao
+	observer QuizObserver
+	now      func() time.Time
+}
+
+func NewGroupService(groupDao dao.GroupDao, quizDao dao.QuizDao, packDao dao.PackDao, observer QuizObserver) GroupService {
+	return &groupServiceImpl{groupDao: groupDao, quizDao: quizDao, packDao: packDao, observer: observer, now: time.Now}
+}
+
+func (g *groupServiceImpl) CreateGroup(ctx context.Context, userId uuid.UUID, input models.GroupInput) (models.Group, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxGroupNameLength {
+		return models.Group{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidGroup, maxGroupNameLength)
+	}
+
+	// Codes are random, so a clash is rare; just draw again.
+	for attempt := 0; attempt < inviteCodeAttempts; attempt++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return models.Group{}, err
+		}
+
+		group, err := g.groupDao.CreateGroup(ctx, models.Group{Name: name, OwnerId: userId, InviteCode: code})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		return group, err
+	}
+	return models.Group{}, errors.New("could not generate a unique invite code")
+}
+
+// ListGroups returns the groups the user owns or belongs to. Only owners see
+// invite codes.
+func (g *groupServiceImpl) ListGroups(ctx context.Context, userId uuid.UUID) ([]models.Group, error) {
+	groups, err := g.groupDao.ListUserGroups(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if groups[i].OwnerId != userId {
+			groups[i].InviteCode = ""
+		}
+	}
+	return groups, nil
+}
+
+func (g *groupServiceImpl) JoinGroup(ctx context.Context, userId uuid.UUID, input models.JoinGroupInput) (models.Group, error) {
+	group, err := g.groupDao.GetGroupByInviteCode(ctx, strings.ToUpper(strings.TrimSpace(input.InviteCode)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	// The owner runs the group; they are not one of its members.
+	if group.OwnerId == userId {
+		return group, nil
+	}
+
+	if err := g.groupDao.AddGroupMember(ctx, *group.Id, userId); err != nil {
+		return models.Group{}, err
+	}
+	group.InviteCode = ""
+	return group, nil
+}
+
+func (g *groupServiceImpl) ListGroupMembers(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) ([]models.GroupMember, error) {
+	if _, err := g.ownedGroup(ctx, userId, groupId); err != nil {
+		return nil, err
+	}
+	return g.groupDao.ListGroupMembers(ctx, groupId)
+}
+
+func (g *groupServiceImpl) CreateAssignment(ctx context.Context, userId uuid.UUID, groupId uuid.UUID, input models.Assignment) (models.Assignment, error) {
+	if _, err := g.ownedGroup(ctx, userId, groupId); err != nil {
+		return models.Assignment{}, err
+	}
+
+	input.GroupId = groupId
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" || len(input.Title) > maxAssignmentTitleLength {
+		return models.Assignment{}, fmt.Errorf("%w: title is required", ErrInvalidAssignment)
+	}
+	if (input.PackId == nil) == (input.SeedQuizId == nil) {
+		return models.Assignment{}, fmt.Errorf("%w: set exactly one of pack_id and seed_quiz_id", ErrInvalidAssignment)
+	}
+	if input.DueAt != nil {
+		dueAt := input.DueAt.UTC()
+		input.DueAt = &dueAt
+	}
+
+	if input.PackId != nil {
+		if _, err := g.packDao.GetPackById(ctx, *input.PackId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.Assignment{}, ErrPackNotFound
+			}
+			return models.Assignment{}, err
+		}
+	} else {
+		questions, err := g.quizDao.GetAllQuestionsByQuizId(ctx, *input.SeedQuizId)
+		if err != nil {
+			return models.Assignment{}, err
+		}
+		if len(questions) == 0 {
+			return models.Assignment{}, fmt.Errorf("%w: the seed quiz has no answered questions", ErrInvalidAssignment)
+		}
+	}
+
+	return g.groupDao.CreateAssignment(ctx, input)
+}
+
+func (g *groupServiceImpl) ListGroupAssignments(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) ([]models.Assignment, error) {
+	if _, err := g.visibleGroup(ctx, userId, groupId); err != nil {
+		return nil, err
+	}
+	return g.groupDao.ListGroupAssignments(ctx, groupId)
+}
+
+func (g *groupServiceImpl) ListOpenAssignments(ctx context.Context, userId uuid.UUID) ([]models.Assignment, error) {
+	return g.groupDao.ListOpenAssignments(ctx, userId, g.now().UTC())
+}
+
+// StartAssignment creates the member's quiz for the assignment: a pack quiz,
+// or a replay of the seed quiz.
+func (g *groupServiceImpl) StartAssignment(ctx context.Context, userId uuid.UUID, assignmentId uuid.UUID) (models.Quiz, error) {
+	assignment, err := g.groupDao.GetAssignmentById(ctx, assignmentId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Quiz{}, ErrAssignmentNotFound
+	}
+	if err != nil {
+		return models.Quiz{}, err
+	}
+
+	member, err := g.groupDao.IsGroupMember(ctx, assignment.GroupId, userId)
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	if !member {
+		return models.Quiz{}, ErrNotGroupMember
+	}
+	if assignment.DueAt != nil && !g.now().Before(*assignment.DueAt) {
+		return models.Quiz{}, ErrAssignmentClosed
+	}
+
+	quiz, err := g.quizDao.CreateQuiz(ctx, models.Quiz{
+		UserId:          userId,
+		Mode:            models.QuizModeClassic,
+		PackId:          assignment.PackId,
+		ChallengeQuizId: assignment.SeedQuizId,
+		AssignmentId:    assignment.Id,
+		IncludeTags:     []string{},
+		ExcludeTags:     []string{},
+	})
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	g.observer.QuizCreated(quiz.Mode)
+	return quiz, nil
+}
+
+func (g *groupServiceImpl) GetAssignmentReport(ctx context.Context, userId uuid.UUID, groupId uuid.UUID, assignmentId uuid.UUID) (models.AssignmentReport, error) {
+	if _, err := g.ownedGroup(ctx, userId, groupId); err != nil {
+		return models.AssignmentReport{}, err
+	}
+
+	assignment, err := g.groupDao.GetAssignmentById(ctx, assignmentId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && assignment.GroupId != groupId) {
+		return models.AssignmentReport{}, ErrAssignmentNotFound
+	}
+	if err != nil {
+		return models.AssignmentReport{}, err
+	}
+
+	return g.groupDao.GetAssignmentReport(ctx, assignment, assignmentMissedLimit)
+}
+
+// ownedGroup returns the group if userId owns it.
+func (g *groupServiceImpl) ownedGroup(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) (models.Group, error) {
+	group, err := g.groupDao.GetGroupById(ctx, groupId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return models.Group{}, err
+	}
+	if group.OwnerId != userId {
+		return models.Group{}, ErrNotGroupOwner
+	}
+	return group, nil
+}
+
+// visibleGroup returns the group if userId owns it or belongs to it.
+func (g *groupServiceImpl) visibleGroup(ctx context.Context, userId uuid.UUID, groupId uuid.UUID) (models.Group, error) {
+	group, err := g.ownedGroup(ctx, userId, groupId)
+	if !errors.Is(err, ErrNotGroupOwner) {
+		return group, err
+	}
+
+	member, err := g.groupDao.IsGroupMember(ctx, groupId, userId)
+	if err != nil {
+		return models.Group{}, err
+	}
+	if !member {
+		return models.Group{}, ErrNotGroupMember
+	}
+	return group, nil
+}
+
+// newInviteCode draws a code from an alphabet without look-alike characters,
+// so it can be read out in class.
+func newInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating invite code: %v", err)
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
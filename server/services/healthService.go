@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/logging"
+	"github.com/axitdhola/globetrotter/server/models"
+)
+
+// readinessTimeout bounds each readiness check so a hung database cannot
+// hang the probe.
+const readinessTimeout = 2 * time.Second
+
+type HealthService interface {
+	CheckReadiness(ctx context.Context) models.Readiness
+	// SetDraining marks the server as shutting down, so readiness fails
+	// while in-flight requests finish.
+	SetDraining()
+}
+
+type healthServiceImpl struct {
+	healthDao        dao.HealthDao
+	migrationVersion int64
+	draining         atomic.Bool
+}
+
+// NewHealthService checks the database against migrationVersion, the newest
+// migration this build ships with.
+func NewHealthService(healthDao dao.HealthDao, migrationVersion int64) HealthService {
+	return &healthServiceImpl{healthDao: healthDao, migrationVersion: migrationVersion}
+}
+
+func (h *healthServiceImpl) CheckReadiness(ctx context.Context) models.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	res := models.Readiness{Status: models.HealthStatusOK, Checks: map[string]string{}}
+	fail := func(check string, problem string) {
+		res.Status = models.HealthStatusUnavailable
+		res.Checks[check] = problem
+	}
+
+	if h.draining.Load() {
+		fail("server", "shutting down")
+	} else {
+		res.Checks["server"] = models.HealthStatusOK
+	}
+
+	// Probes are unauthenticated, so driver errors, which can name hosts and
+	// users, are logged rather than returned.
+	if err := h.healthDao.Ping(ctx); err != nil {
+		logging.FromContext(ctx).Warn("readiness: database ping failed", "error", err)
+		fail("database", "unreachable")
+		fail("migrations", "database unreachable")
+		return res
+	}
+	res.Checks["database"] = models.HealthStatusOK
+
+	version, err := h.healthDao.GetMigrationVersion(ctx)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Warn("readiness: could not read migration version", "error", err)
+		fail("migrations", "version unknown")
+	case version < h.migrationVersion:
+		fail("migrations", fmt.Sprintf("database is at %d, expected %d", version, h.migrationVersion))
+	default:
+		res.Checks["migrations"] = models.HealthStatusOK
+	}
+	return res
+}
+
+func (h *healthServiceImpl) SetDraining() {
+	h.draining.Store(true)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale canonical question text is written in.
+const DefaultLocale = "en"
+
+// localeCandidates lists the locales to try for a player, most preferred
+// first: their saved preference, then the Accept-Language header by weight.
+// Each regional locale is followed by its base language ("pt-br", "pt").
+// The list stops at the default locale, since the canonical text covers it.
+// Locales are lower-cased.
+func localeCandidates(preferred string, acceptLanguage string) []string {
+	var tags []language.Tag
+	if preferred != "" {
+		if tag, err := language.Parse(preferred); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	if accepted, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil {
+		tags = append(tags, accepted...)
+	}
+
+	candidates := []string{}
+	seen := map[string]bool{}
+	add := func(locale string) bool {
+		locale = strings.ToLower(locale)
+		if locale == DefaultLocale {
+			return false
+		}
+		if !seen[locale] {
+			seen[locale] = true
+			candidates = append(candidates, locale)
+		}
+		return true
+	}
+
+	for _, tag := range tags {
+		if !add(tag.String()) {
+			break
+		}
+		base, _ := tag.Base()
+		if !add(base.String()) {
+			break
+		}
+	}
+
+	return candidates
+}
+
+// normalizeLocale validates a BCP 47 locale and returns its canonical form,
+// such as "pt-BR".
+func normalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil {
+		return "", err
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+const defaultPackHighScoreLimit = 10
+
+var (
+	ErrPackNotFound = apperrors.New(apperrors.NotFound, "pack not found")
+	ErrInvalidPack  = apperrors.New(apperrors.Validation, "invalid pack")
+)
+
+type PackService interface {
+	CreatePack(ctx context.Context, pack models.Pack) (models.Pack, error)
+	UpdatePack(ctx context.Context, pack models.Pack) (models.Pack, error)
+	DeletePack(ctx context.Context, packId uuid.UUID) error
+	GetPack(ctx context.Context, packId uuid.UUID) (models.Pack, error)
+	ListPacks(ctx context.Context) ([]models.Pack, error)
+	GetPackHighScores(ctx context.Context, packId uuid.UUID, limit int) ([]models.PackHighScore, error)
+}
+
+type packServiceImpl struct {
+	packDao dao.PackDao
+}
+
+func NewPackService(packDao dao.PackDao) PackService {
+	return &packServiceImpl{packDao: packDao}
+}
+
+func (p *packServiceImpl) CreatePack(ctx context.Context, pack models.Pack) (models.Pack, error) {
+	if err := p.validatePack(ctx, &pack); err != nil {
+		return models.Pack{}, err
+	}
+	return p.packDao.CreatePack(ctx, pack)
+}
+
+func (p *packServiceImpl) UpdatePack(ctx context.Context, pack models.Pack) (models.Pack, error) {
+	if pack.Id == nil || *pack.Id == uuid.Nil {
+		return models.Pack{}, fmt.Errorf("%w: missing id", ErrInvalidPack)
+	}
+	if err := p.validatePack(ctx, &pack); err != nil {
+		return models.Pack{}, err
+	}
+
+	res, err := p.packDao.UpdatePack(ctx, pack)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Pack{}, ErrPackNotFound
+	}
+	return res, err
+}
+
+func (p *packServiceImpl) DeletePack(ctx context.Context, packId uuid.UUID) error {
+	err := p.packDao.DeletePack(ctx, packId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPackNotFound
+	}
+	return err
+}
+
+func (p *packServiceImpl) GetPack(ctx context.Context, packId uuid.UUID) (models.Pack, error) {
+	pack, err := p.packDao.GetPackById(ctx, packId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Pack{}, ErrPackNotFound
+	}
+	return pack, err
+}
+
+func (p *packServiceImpl) ListPacks(ctx context.Context) ([]models.Pack, error) {
+	return p.packDao.ListPacks(ctx)
+}
+
+func (p *packServiceImpl) GetPackHighScores(ctx context.Context, packId uuid.UUID, limit int) ([]models.PackHighScore, error) {
+	if _, err := p.GetPack(ctx, packId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultPackHighScoreLimit
+	}
+	return p.packDao.GetPackHighScores(ctx, packId, limit)
+}
+
+func (p *packServiceImpl) validatePack(ctx context.Context, pack *models.Pack) error {
+	pack.Title = strings.TrimSpace(pack.Title)
+	if pack.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidPack)
+	}
+	if len(pack.QuestionIds) == 0 {
+		return fmt.Errorf("%w: at least one question is required", ErrInvalidPack)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(pack.QuestionIds))
+	for _, id := range pack.QuestionIds {
+		if seen[id] {
+			return fmt.Errorf("%w: duplicate question %s", ErrInvalidPack, id)
+		}
+		seen[id] = true
+	}
+
+	missing, unpublished, err := p.packDao.FindUnplayableQuestionIds(ctx, pack.QuestionIds)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: unknown question %s", ErrInvalidPack, missing[0])
+	}
+	if len(unpublished) > 0 {
+		return fmt.Errorf("%w: question %s is not published", ErrInvalidPack, unpublished[0])
+	}
+
+	return nil
+}
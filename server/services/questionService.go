@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultQuestionListLimit = 50
+	maxQuestionListLimit     = 200
+	minQuestionOptions       = 2
+	maxQuestionOptions       = 6
+	maxQuestionFieldLength   = 255
+)
+
+var (
+	ErrQuestionNotFound        = apperrors.New(apperrors.NotFound, "question not found")
+	ErrInvalidQuestion         = apperrors.New(apperrors.Validation, "invalid question")
+	ErrInvalidStatusTransition = apperrors.New(apperrors.Conflict, "invalid status transition")
+	ErrRevisionNotFound        = apperrors.New(apperrors.NotFound, "revision not found")
+	ErrTranslationNotFound     = apperrors.New(apperrors.NotFound, "translation not found")
+)
+
+// questionTransitions lists the lifecycle states a question may move to from
+// each state. Editing a published question's content or translations also
+// moves it back to in_review.
+var questionTransitions = map[string][]string{
+	models.QuestionStatusDraft:     {models.QuestionStatusInReview, models.QuestionStatusRetired},
+	models.QuestionStatusInReview:  {models.QuestionStatusDraft, models.QuestionStatusPublished, models.QuestionStatusRetired},
+	models.QuestionStatusPublished: {models.QuestionStatusRetired},
+	models.QuestionStatusRetired:   {models.QuestionStatusDraft},
+}
+
+type QuestionService interface {
+	CreateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error)
+	UpdateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error)
+	SetQuestionStatus(ctx context.Context, questionId uuid.UUID, status string, actor string) (models.AdminQuestion, error)
+	DeleteQuestion(ctx context.Context, questionId uuid.UUID, actor string) error
+	GetQuestion(ctx context.Context, questionId uuid.UUID) (models.AdminQuestion, error)
+	ListQuestions(ctx context.Context, filter models.QuestionListFilter) ([]models.AdminQuestion, error)
+	ListQuestionRevisions(ctx context.Context, questionId uuid.UUID) ([]models.QuestionRevision, error)
+	DiffQuestionRevisions(ctx context.Context, questionId uuid.UUID, from int, to int) (models.RevisionDiff, error)
+	ListQuestionTranslations(ctx context.Context, questionId uuid.UUID) ([]models.QuestionTranslation, error)
+	SaveQuestionTranslation(ctx context.Context, translation models.QuestionTranslation, actor string) (models.QuestionTranslation, error)
+	DeleteQuestionTranslation(ctx context.Context, questionId uuid.UUID, locale string, actor string) error
+}
+
+type questionServiceImpl struct {
+	questionDao dao.QuestionDao
+}
+
+func NewQuestionService(questionDao dao.QuestionDao) QuestionService {
+	return &questionServiceImpl{questionDao: questionDao}
+}
+
+func (q *questionServiceImpl) CreateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error) {
+	if err := validateQuestion(&question); err != nil {
+		return models.AdminQuestion{}, err
+	}
+	return q.questionDao.CreateQuestion(ctx, question, actor)
+}
+
+func (q *questionServiceImpl) UpdateQuestion(ctx context.Context, question models.Question, actor string) (models.AdminQuestion, error) {
+	if question.Id == nil || *question.Id == uuid.Nil {
+		return models.AdminQuestion{}, fmt.Errorf("%w: missing id", ErrInvalidQuestion)
+	}
+	if err := validateQuestion(&question); err != nil {
+		return models.AdminQuestion{}, err
+	}
+
+	res, err := q.questionDao.UpdateQuestion(ctx, question, actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AdminQuestion{}, ErrQuestionNotFound
+	}
+	return res, err
+}
+
+func (q *questionServiceImpl) SetQuestionStatus(ctx context.Context, questionId uuid.UUID, status string, actor string) (models.AdminQuestion, error) {
+	current, err := q.GetQuestion(ctx, questionId)
+	if err != nil {
+		return models.AdminQuestion{}, err
+	}
+	if current.DeletedAt != nil {
+		return models.AdminQuestion{}, ErrQuestionNotFound
+	}
+
+	allowed := false
+	for _, next := range questionTransitions[current.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return models.AdminQuestion{}, fmt.Errorf("%w: %s to %q", ErrInvalidStatusTransition, current.Status, status)
+	}
+
+	res, err := q.questionDao.SetQuestionStatus(ctx, questionId, current.Status, status, actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AdminQuestion{}, fmt.Errorf("%w: question changed concurrently", ErrInvalidStatusTransition)
+	}
+	return res, err
+}
+
+func (q *questionServiceImpl) DeleteQuestion(ctx context.Context, questionId uuid.UUID, actor string) error {
+	err := q.questionDao.DeleteQuestion(ctx, questionId, actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	return err
+}
+
+func (q *questionServiceImpl) GetQuestion(ctx context.Context, questionId uuid.UUID) (models.AdminQuestion, error) {
+	question, err := q.questionDao.GetAdminQuestionById(ctx, questionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AdminQuestion{}, ErrQuestionNotFound
+	}
+	return question, err
+}
+
+func (q *questionServiceImpl) ListQuestions(ctx context.Context, filter models.QuestionListFilter) ([]models.AdminQuestion, error) {
+	if filter.Status != "" {
+		if _, ok := questionTransitions[filter.Status]; !ok {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidQuestion, filter.Status)
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultQuestionListLimit
+	}
+	if filter.Limit > maxQuestionListLimit {
+		filter.Limit = maxQuestionListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return q.questionDao.ListQuestions(ctx, filter)
+}
+
+func (q *questionServiceImpl) ListQuestionRevisions(ctx context.Context, questionId uuid.UUID) ([]models.QuestionRevision, error) {
+	if _, err := q.GetQuestion(ctx, questionId); err != nil {
+		return nil, err
+	}
+	return q.questionDao.ListQuestionRevisions(ctx, questionId)
+}
+
+// DiffQuestionRevisions compares two revisions of a question by revision
+// number. A zero to means the latest revision and a zero from the one before
+// it.
+func (q *questionServiceImpl) DiffQuestionRevisions(ctx context.Context, questionId uuid.UUID, from int, to int) (models.RevisionDiff, error) {
+	revisions, err := q.ListQuestionRevisions(ctx, questionId)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+	if len(revisions) == 0 {
+		return models.RevisionDiff{}, ErrRevisionNotFound
+	}
+
+	if to == 0 {
+		to = revisions[len(revisions)-1].RevisionNumber
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	var older, newer *models.QuestionRevision
+	for i := range revisions {
+		switch revisions[i].RevisionNumber {
+		case from:
+			older = &revisions[i]
+		case to:
+			newer = &revisions[i]
+		}
+	}
+	if older == nil || newer == nil {
+		return models.RevisionDiff{}, fmt.Errorf("%w: cannot compare %d with %d", ErrRevisionNotFound, from, to)
+	}
+
+	return models.RevisionDiff{
+		QuestionId: questionId,
+		From:       from,
+		To:         to,
+		Changes:    diffRevisions(*older, *newer),
+	}, nil
+}
+
+func diffRevisions(older models.QuestionRevision, newer models.QuestionRevision) []models.RevisionFieldChange {
+	changes := []models.RevisionFieldChange{}
+	add := func(field string, old any, new any, changed bool) {
+		if changed {
+			changes = append(changes, models.RevisionFieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("city", older.City, newer.City, older.City != newer.City)
+	add("country", older.Country, newer.Country, older.Country != newer.Country)
+	add("clues", older.Clues, newer.Clues, !equalStrings(older.Clues, newer.Clues))
+	add("fun_fact", older.FunFact, newer.FunFact, !equalStrings(older.FunFact, newer.FunFact))
+	add("trivia", older.Trivia, newer.Trivia, !equalStrings(older.Trivia, newer.Trivia))
+	add("options", older.Options, newer.Options, !equalStrings(older.Options, newer.Options))
+	add("correct_answer", older.CorrectAnswer, newer.CorrectAnswer, older.CorrectAnswer != newer.CorrectAnswer)
+	return changes
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *questionServiceImpl) ListQuestionTranslations(ctx context.Context, questionId uuid.UUID) ([]models.QuestionTranslation, error) {
+	if _, err := q.GetQuestion(ctx, questionId); err != nil {
+		return nil, err
+	}
+	return q.questionDao.ListQuestionTranslations(ctx, questionId)
+}
+
+func (q *questionServiceImpl) SaveQuestionTranslation(ctx context.Context, translation models.QuestionTranslation, actor string) (models.QuestionTranslation, error) {
+	question, err := q.GetQuestion(ctx, translation.QuestionId)
+	if err != nil {
+		return models.QuestionTranslation{}, err
+	}
+
+	locale, err := normalizeLocale(translation.Locale)
+	if err != nil {
+		return models.QuestionTranslation{}, fmt.Errorf("%w: invalid locale %q", ErrInvalidQuestion, translation.Locale)
+	}
+	if strings.EqualFold(locale, DefaultLocale) {
+		return models.QuestionTranslation{}, fmt.Errorf("%w: %s is the canonical locale", ErrInvalidQuestion, DefaultLocale)
+	}
+	translation.Locale = locale
+	translation.City = strings.TrimSpace(translation.City)
+	translation.Country = strings.TrimSpace(translation.Country)
+
+	if len(translation.Options) > 0 {
+		if len(translation.Options) != len(question.Options) {
+			return models.QuestionTranslation{}, fmt.Errorf("%w: expected %d options", ErrInvalidQuestion, len(question.Options))
+		}
+		// The translated correct option is what localized players submit, so
+		// it must be the translated city name that grading accepts.
+		if translation.Options[question.CorrectAnswer] != translation.City {
+			return models.QuestionTranslation{}, fmt.Errorf("%w: the correct option must be the translated city", ErrInvalidQuestion)
+		}
+	}
+
+	return q.questionDao.UpsertQuestionTranslation(ctx, translation, actor)
+}
+
+func (q *questionServiceImpl) DeleteQuestionTranslation(ctx context.Context, questionId uuid.UUID, locale string, actor string) error {
+	err := q.questionDao.DeleteQuestionTranslation(ctx, questionId, locale, actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTranslationNotFound
+	}
+	return err
+}
+
+// validateQuestion normalizes a question in place and checks it against the
+// rules a question must meet before the admin API creates or updates it.
+// Failures wrap ErrInvalidQuestion.
+func validateQuestion(question *models.Question) error {
+	question.City = strings.TrimSpace(question.City)
+	question.Country = strings.TrimSpace(question.Country)
+	question.Continent = strings.TrimSpace(question.Continent)
+	question.Region = strings.TrimSpace(question.Region)
+	question.Tags = normalizeTags(question.Tags)
+
+	if question.City == "" || question.Country == "" {
+		return fmt.Errorf("%w: city and country are required", ErrInvalidQuestion)
+	}
+	for _, field := range []string{question.City, question.Country, question.Continent, question.Region} {
+		if len(field) > maxQuestionFieldLength {
+			return fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidQuestion, field, maxQuestionFieldLength)
+		}
+	}
+	for _, tag := range question.Tags {
+		if len(tag) > maxTagLength {
+			return fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidQuestion, tag, maxTagLength)
+		}
+	}
+
+	if len(question.Clues) == 0 {
+		return fmt.Errorf("%w: at least one clue is required", ErrInvalidQuestion)
+	}
+	for name, texts := range map[string][]string{"clue": question.Clues, "fun fact": question.FunFact, "trivia": question.Trivia} {
+		for _, text := range texts {
+			if strings.TrimSpace(text) == "" {
+				return fmt.Errorf("%w: empty %s", ErrInvalidQuestion, name)
+			}
+		}
+	}
+
+	if len(question.Options) < minQuestionOptions || len(question.Options) > maxQuestionOptions {
+		return fmt.Errorf("%w: between %d and %d options are required", ErrInvalidQuestion, minQuestionOptions, maxQuestionOptions)
+	}
+	seen := make(map[string]bool, len(question.Options))
+	for i, option := range question.Options {
+		option = strings.TrimSpace(option)
+		key := strings.ToLower(option)
+		if option == "" || seen[key] {
+			return fmt.Errorf("%w: options must be non-empty and unique", ErrInvalidQuestion)
+		}
+		seen[key] = true
+		question.Options[i] = option
+	}
+
+	if question.CorrectAnswer < 0 || question.CorrectAnswer >= len(question.Options) {
+		return fmt.Errorf("%w: correct_answer is out of range", ErrInvalidQuestion)
+	}
+	// Answers are graded against the city name, so the correct option has to
+	// be the city itself.
+	if question.Options[question.CorrectAnswer] != question.City {
+		return fmt.Errorf("%w: the correct option must be the city", ErrInvalidQuestion)
+	}
+
+	return nil
+}
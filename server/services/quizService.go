@@ -1,61 +1,428 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/axitdhola/globetrotter/server/achievements"
+	"github.com/axitdhola/globetrotter/server/apperrors"
 	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/logging"
 	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/questiontoken"
+	"github.com/axitdhola/globetrotter/server/rating"
+	"github.com/axitdhola/globetrotter/server/tracing"
+	"github.com/axitdhola/globetrotter/server/username"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrQuizNotFound        = apperrors.New(apperrors.NotFound, "quiz not found")
+	ErrInvalidQuizMode     = apperrors.New(apperrors.Validation, "invalid quiz mode")
+	ErrInvalidQuizSettings = apperrors.New(apperrors.Validation, "invalid quiz settings")
+
+	ErrInvalidQuestionToken = apperrors.New(apperrors.Forbidden, "invalid question token")
+	ErrExpiredQuestionToken = apperrors.New(apperrors.Forbidden, "question token has expired")
+	ErrQuestionAnswered     = apperrors.New(apperrors.Conflict, "question has already been answered")
+)
+
 type quizServiceImpl struct {
-	quizDao dao.QuizDao
-	userDao dao.UserDao
+	quizDao     dao.QuizDao
+	userDao     dao.UserDao
+	packDao     dao.PackDao
+	questionDao dao.QuestionDao
+
+	achievementService AchievementService
+	reviewService      ReviewService
+	friendService      FriendService
+	antiCheatService   AntiCheatService
+
+	// tokens signs the token issued with each question and checks it when
+	// the question is answered.
+	tokens *questiontoken.Keyring
+
+	// defaults fill in quiz settings the player has no preference for.
+	defaults models.QuizPreferences
+	observer QuizObserver
+}
+
+// QuizObserver is told about quiz activity once it has been saved, for
+// metrics.
+type QuizObserver interface {
+	QuizCreated(mode string)
+	QuizFinished(mode string)
+	AnswerGraded(mode string, correct, timedOut bool)
 }
 
+// NopQuizObserver ignores quiz activity.
+var NopQuizObserver QuizObserver = nopQuizObserver{}
+
+type nopQuizObserver struct{}
+
+func (nopQuizObserver) QuizCreated(string)              {}
+func (nopQuizObserver) QuizFinished(string)             {}
+func (nopQuizObserver) AnswerGraded(string, bool, bool) {}
+
 type QuizService interface {
-	GetQuizQuestion(quizId uuid.UUID, invitedQuizId *uuid.UUID) (models.Question, error)
-	CreateQuiz(user models.User) (models.Quiz, error)
-	SaveQuizAnswer(input models.QuizAnswerInput) (models.QuizAnswerResponse, error)
-	GetQuizScoreById(quizId uuid.UUID) (models.QuizScore, error)
-	ListQuizByUserName(userName string) ([]models.Quiz, error)
+	GetQuizQuestion(ctx context.Context, quizId uuid.UUID, invitedQuizId *uuid.UUID, acceptLanguage string) (models.Question, error)
+	CreateQuiz(ctx context.Context, input models.CreateQuizInput) (models.Quiz, error)
+	SaveQuizAnswer(ctx context.Context, input models.QuizAnswerInput) (models.QuizAnswerResponse, error)
+	GetQuizScoreById(ctx context.Context, quizId uuid.UUID) (models.QuizScore, error)
+	ListQuizByUserName(ctx context.Context, userName string) ([]models.Quiz, error)
 }
 
-func NewQuizService(quizDao dao.QuizDao, userDao dao.UserDao) QuizService {
-	return &quizServiceImpl{quizDao: quizDao, userDao: userDao}
+func NewQuizService(quizDao dao.QuizDao, userDao dao.UserDao, packDao dao.PackDao, questionDao dao.QuestionDao, achievementService AchievementService, reviewService ReviewService, friendService FriendService, antiCheatService AntiCheatService, tokens *questiontoken.Keyring, defaults models.QuizPreferences, observer QuizObserver) QuizService {
+	return &quizServiceImpl{quizDao: quizDao, userDao: userDao, packDao: packDao, questionDao: questionDao, achievementService: achievementService, reviewService: reviewService, friendService: friendService, antiCheatService: antiCheatService, tokens: tokens, defaults: defaults, observer: observer}
 }
 
-func (f *quizServiceImpl) GetQuizQuestion(quizId uuid.UUID, invitedQuizId *uuid.UUID) (models.Question, error) {
-	if invitedQuizId == nil || *invitedQuizId == uuid.Nil {
-		return f.quizDao.GetQuizQuestion(quizId)
+func (f *quizServiceImpl) GetQuizQuestion(ctx context.Context, quizId uuid.UUID, invitedQuizId *uuid.UUID, acceptLanguage string) (models.Question, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.GetQuizQuestion", tracing.QuizIdKey.String(quizId.String()))
+	defer span.End()
+
+	quiz, err := f.getQuiz(ctx, quizId)
+	if err != nil {
+		return models.Question{}, err
+	}
+
+	if invitedQuizId != nil && *invitedQuizId != uuid.Nil && quiz.ChallengeQuizId == nil {
+		if err := f.acceptChallenge(ctx, &quiz, *invitedQuizId); err != nil {
+			return models.Question{}, err
+		}
 	}
 
-	all_questions, err := f.quizDao.GetAllQuestionsByQuizId(quizId)
+	question, err := f.pickQuestion(ctx, quiz)
+	if err != nil || question.Id == nil {
+		return question, err
+	}
+
+	position, issuedAt, err := f.quizDao.RecordIssuedQuestion(ctx, quizId, question)
+	if err != nil {
+		return models.Question{}, err
+	}
+	question.Token, err = f.tokens.Sign(questiontoken.Claims{QuizId: quizId, QuestionId: *question.Id, Position: position, IssuedAt: issuedAt})
 	if err != nil {
+		return models.Question{}, fmt.Errorf("error signing question token: %v", err)
+	}
+
+	if err := f.localizeQuestion(ctx, quiz.UserId, &question, acceptLanguage); err != nil {
 		return models.Question{}, err
 	}
-	return f.quizDao.GetQuizQuestionByOrder(*invitedQuizId, len(all_questions)+1)
+	return question, nil
+}
+
+func (f *quizServiceImpl) getQuiz(ctx context.Context, quizId uuid.UUID) (models.Quiz, error) {
+	quiz, err := f.quizDao.GetQuizById(ctx, quizId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Quiz{}, ErrQuizNotFound
+	}
+	return quiz, err
+}
+
+// acceptChallenge makes the quiz a replay of the challenged quiz, unless
+// either player has blocked the other.
+func (f *quizServiceImpl) acceptChallenge(ctx context.Context, quiz *models.Quiz, challengeQuizId uuid.UUID) error {
+	challenge, err := f.getQuiz(ctx, challengeQuizId)
+	if err != nil {
+		return err
+	}
+
+	blocked, err := f.friendService.IsBlocked(ctx, quiz.UserId, challenge.UserId)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+
+	if err := f.quizDao.SetQuizChallenge(ctx, *quiz.Id, challengeQuizId); err != nil {
+		return err
+	}
+	quiz.ChallengeQuizId = &challengeQuizId
+	return nil
+}
+
+// localizeQuestion swaps in the best available translation for the player.
+// Anything a translation leaves out keeps the canonical text. Translations
+// are taken from the question's revision, so a pinned revision is served
+// in every locale as it was; questions without a revision have none.
+func (f *quizServiceImpl) localizeQuestion(ctx context.Context, userId uuid.UUID, question *models.Question, acceptLanguage string) error {
+	question.Locale = DefaultLocale
+
+	preferred, err := f.userDao.GetUserLocale(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	candidates := localeCandidates(preferred, acceptLanguage)
+	if len(candidates) == 0 || question.RevisionId == nil {
+		return nil
+	}
+
+	translation, err := f.questionDao.GetQuestionTranslation(ctx, *question.RevisionId, candidates)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	question.Locale = translation.Locale
+	if translation.City != "" {
+		question.City = translation.City
+	}
+	if translation.Country != "" {
+		question.Country = translation.Country
+	}
+	if len(translation.Clues) > 0 {
+		question.Clues = translation.Clues
+	}
+	if len(translation.FunFact) > 0 {
+		question.FunFact = translation.FunFact
+	}
+	if len(translation.Trivia) > 0 {
+		question.Trivia = translation.Trivia
+	}
+	if len(translation.Options) == len(question.Options) {
+		question.Options = translation.Options
+	}
+	return nil
+}
+
+// pickQuestion chooses the next question for the quiz. Challenge replays
+// follow the challenged quiz in order. Practice quizzes ask the player's due
+// review cards first. Otherwise the quiz's pack or tag filters decide, and
+// random questions are drawn from the quiz's difficulty. An empty question
+// means the quiz has run out or reached its length.
+func (f *quizServiceImpl) pickQuestion(ctx context.Context, quiz models.Quiz) (models.Question, error) {
+	all_questions, err := f.quizDao.GetAllQuestionsByQuizId(ctx, *quiz.Id)
+	if err != nil {
+		return models.Question{}, err
+	}
+	if quiz.QuestionLimit != nil && len(all_questions) >= *quiz.QuestionLimit {
+		return models.Question{}, nil
+	}
+
+	bands, ok := rating.DifficultyBands(quiz.Difficulty)
+	if !ok {
+		return models.Question{}, ErrInvalidQuizSettings
+	}
+
+	if quiz.Mode == models.QuizModePractice {
+		question, err := f.reviewService.GetDueQuestion(ctx, *quiz.Id, quiz.UserId)
+		if err != nil || question.Id != nil {
+			return question, err
+		}
+		return f.getQuestionNearBand(ctx, quiz, bands)
+	}
+
+	if quiz.ChallengeQuizId == nil {
+		if quiz.PackId != nil {
+			return f.quizDao.GetPackQuizQuestion(ctx, *quiz.Id, *quiz.PackId, quiz.Shuffle)
+		}
+		return f.getQuestionNearBand(ctx, quiz, bands)
+	}
+
+	return f.quizDao.GetQuizQuestionByOrder(ctx, *quiz.ChallengeQuizId, len(all_questions)+1)
+}
+
+// getQuestionNearBand picks a question from the first of bands that still
+// has one the quiz has not been given.
+func (f *quizServiceImpl) getQuestionNearBand(ctx context.Context, quiz models.Quiz, bands []rating.Band) (models.Question, error) {
+	for _, band := range bands {
+		question, err := f.quizDao.GetQuizQuestion(ctx, *quiz.Id, quiz.IncludeTags, quiz.ExcludeTags, band)
+		if err != nil || question.Id != nil {
+			return question, err
+		}
+	}
+	return models.Question{}, nil
+}
+
+// validateQuizSettings checks a quiz length, difficulty and per-question
+// timer. Nil numbers and an empty difficulty mean no limit.
+func validateQuizSettings(length *int, difficulty string, timerSeconds *int) error {
+	if length != nil && (*length < 1 || *length > models.MaxQuizLength) {
+		return fmt.Errorf("%w: length must be between 1 and %d", ErrInvalidQuizSettings, models.MaxQuizLength)
+	}
+	if _, ok := rating.DifficultyBand(difficulty); !ok {
+		return fmt.Errorf("%w: difficulty must be %s, %s or %s", ErrInvalidQuizSettings, rating.DifficultyEasy, rating.DifficultyMedium, rating.DifficultyHard)
+	}
+	if timerSeconds != nil && (*timerSeconds < models.MinQuizTimerSeconds || *timerSeconds > models.MaxQuizTimerSeconds) {
+		return fmt.Errorf("%w: timer must be between %d and %d seconds", ErrInvalidQuizSettings, models.MinQuizTimerSeconds, models.MaxQuizTimerSeconds)
+	}
+	return nil
 }
 
-func (f *quizServiceImpl) CreateQuiz(user models.User) (models.Quiz, error) {
-	user, err := f.userDao.GetUserByName(user.Name)
+func (f *quizServiceImpl) CreateQuiz(ctx context.Context, input models.CreateQuizInput) (models.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.CreateQuiz")
+	defer span.End()
+
+	user, err := findUserByName(ctx, f.userDao, input.Name)
 	if err != nil {
 		return models.Quiz{}, err
 	}
 
-	return f.quizDao.CreateQuiz(user)
+	// Settings the request leaves out come from the player's preferences,
+	// then from the server's defaults.
+	prefs := user.QuizPreferences
+	if prefs.Length == nil {
+		prefs.Length = f.defaults.Length
+	}
+	if prefs.Difficulty == "" {
+		prefs.Difficulty = f.defaults.Difficulty
+	}
+	if prefs.TimerSeconds == nil {
+		prefs.TimerSeconds = f.defaults.TimerSeconds
+	}
+	if input.QuestionLimit != nil {
+		prefs.Length = zeroAsNil(*input.QuestionLimit)
+	}
+	if input.Difficulty != nil {
+		prefs.Difficulty = strings.ToLower(strings.TrimSpace(*input.Difficulty))
+	}
+	if input.TimerSeconds != nil {
+		prefs.TimerSeconds = zeroAsNil(*input.TimerSeconds)
+	}
+	if err := validateQuizSettings(prefs.Length, prefs.Difficulty, prefs.TimerSeconds); err != nil {
+		return models.Quiz{}, err
+	}
+
+	switch input.Mode {
+	case "":
+		input.Mode = models.QuizModeClassic
+	case models.QuizModeClassic:
+	case models.QuizModePractice:
+		// Practice draws from the review queue, not from a pack.
+		input.PackId = nil
+	default:
+		return models.Quiz{}, ErrInvalidQuizMode
+	}
+
+	if input.PackId != nil && *input.PackId != uuid.Nil {
+		if _, err := f.packDao.GetPackById(ctx, *input.PackId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.Quiz{}, ErrPackNotFound
+			}
+			return models.Quiz{}, err
+		}
+	} else {
+		input.PackId = nil
+		input.Shuffle = false
+	}
+
+	quiz, err := f.quizDao.CreateQuiz(ctx, models.Quiz{
+		UserId:        *user.Id,
+		Mode:          input.Mode,
+		PackId:        input.PackId,
+		Shuffle:       input.Shuffle,
+		IncludeTags:   normalizeTags(input.IncludeTags),
+		ExcludeTags:   normalizeTags(input.ExcludeTags),
+		QuestionLimit: prefs.Length,
+		Difficulty:    prefs.Difficulty,
+		TimerSeconds:  prefs.TimerSeconds,
+		ClientIP:      input.ClientIP,
+		DeviceId:      input.DeviceId,
+	})
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	span.SetAttributes(tracing.QuizIdKey.String(quiz.Id.String()), tracing.UserIdKey.String(quiz.UserId.String()))
+	f.observer.QuizCreated(quiz.Mode)
+	return quiz, nil
 }
 
-func (f *quizServiceImpl) SaveQuizAnswer(input models.QuizAnswerInput) (models.QuizAnswerResponse, error) {
-	return f.quizDao.SaveQuizAnswer(input)
+// SaveQuizAnswer checks the answer's question token, then grades and saves
+// the answer together with, outside practice, the player and question
+// ratings. It updates the player's review card, completes the quiz once it
+// has no questions left, checks it for cheating and awards any achievements
+// the answer unlocked.
+func (f *quizServiceImpl) SaveQuizAnswer(ctx context.Context, input models.QuizAnswerInput) (models.QuizAnswerResponse, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.SaveQuizAnswer", tracing.QuizIdKey.String(input.QuizId.String()))
+	defer span.End()
+
+	position, err := f.verifyQuestionToken(input)
+	if err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+
+	res, err := f.quizDao.SaveQuizAnswer(ctx, input, position)
+	if errors.Is(err, dao.ErrQuestionAnswered) {
+		return models.QuizAnswerResponse{}, ErrQuestionAnswered
+	}
+	if err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+
+	quiz, err := f.getQuiz(ctx, input.QuizId)
+	if err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+
+	span.SetAttributes(tracing.UserIdKey.String(quiz.UserId.String()))
+	f.observer.AnswerGraded(quiz.Mode, res.IsCorrect, res.TimedOut)
+
+	practice := quiz.Mode == models.QuizModePractice
+	if err := f.reviewService.RecordAnswer(ctx, quiz.UserId, input.QuestionId, res.IsCorrect, practice); err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+
+	trigger := achievements.OnAnswer
+	next, err := f.pickQuestion(ctx, quiz)
+	if err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+	if next.Id == nil {
+		res.QuizCompleted = true
+		completed, err := f.quizDao.MarkQuizCompleted(ctx, input.QuizId)
+		if err != nil {
+			return models.QuizAnswerResponse{}, err
+		}
+		if completed {
+			trigger = achievements.OnQuizCompleted
+			f.observer.QuizFinished(quiz.Mode)
+		}
+	}
+
+	// A failed check must not cost the player their answer; the quiz is
+	// checked again later.
+	if err := f.antiCheatService.CheckQuiz(ctx, input.QuizId, res.TotalQuestions, res.QuizCompleted); err != nil {
+		logging.FromContext(ctx).Error("anti-cheat check failed", "quiz_id", input.QuizId, "error", err)
+	}
+
+	res.NewAchievements, err = f.achievementService.CheckAchievements(ctx, quiz.UserId, quiz.Id, trigger)
+	if err != nil {
+		return models.QuizAnswerResponse{}, err
+	}
+	return res, nil
 }
 
-func (f *quizServiceImpl) GetQuizScoreById(quizId uuid.UUID) (models.QuizScore, error) {
-	quiz, err := f.quizDao.GetQuizById(quizId)
+// verifyQuestionToken checks that the answer's token was issued for its quiz
+// and question and has not expired, and returns the position the question
+// was issued at. The DAO spends the token as it saves the answer, so the
+// answer cannot be replayed.
+func (f *quizServiceImpl) verifyQuestionToken(input models.QuizAnswerInput) (int, error) {
+	claims, err := f.tokens.Verify(input.Token)
+	if errors.Is(err, questiontoken.ErrExpiredToken) {
+		return 0, ErrExpiredQuestionToken
+	}
+	if err != nil || claims.QuizId != input.QuizId || claims.QuestionId != input.QuestionId {
+		return 0, ErrInvalidQuestionToken
+	}
+	return claims.Position, nil
+}
+
+func (f *quizServiceImpl) GetQuizScoreById(ctx context.Context, quizId uuid.UUID) (models.QuizScore, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.GetQuizScoreById", tracing.QuizIdKey.String(quizId.String()))
+	defer span.End()
+
+	quiz, err := f.getQuiz(ctx, quizId)
 	if err != nil {
 		return models.QuizScore{}, err
 	}
 
-	total_questions, err := f.quizDao.GetAllQuestionsByQuizId(quizId)
+	total_questions, err := f.quizDao.GetAllQuestionsByQuizId(ctx, quizId)
 	if err != nil {
 		return models.QuizScore{}, err
 	}
@@ -66,6 +433,12 @@ func (f *quizServiceImpl) GetQuizScoreById(quizId uuid.UUID) (models.QuizScore,
 	}, nil
 }
 
-func (f *quizServiceImpl) ListQuizByUserName(userName string) ([]models.Quiz, error) {
-	return f.quizDao.ListQuizByUserName(userName)
-}
\ No newline at end of file
+func (f *quizServiceImpl) ListQuizByUserName(ctx context.Context, userName string) ([]models.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.ListQuizByUserName")
+	defer span.End()
+
+	if _, err := findUserByName(ctx, f.userDao, userName); err != nil {
+		return nil, err
+	}
+	return f.quizDao.ListQuizByUsernameKey(ctx, username.Key(userName))
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/rating"
+	"github.com/google/uuid"
+)
+
+// fakeQuestionPool serves unplayed questions by rating, the way
+// GetQuizQuestion filters them in Postgres.
+type fakeQuestionPool struct {
+	dao.QuizDao
+
+	questions []ratedQuestion
+}
+
+type ratedQuestion struct {
+	models.Question
+	rating float64
+}
+
+func (d *fakeQuestionPool) GetAllQuestionsByQuizId(ctx context.Context, quizId uuid.UUID) ([]models.Question, error) {
+	return nil, nil
+}
+
+func (d *fakeQuestionPool) GetQuizQuestion(ctx context.Context, quizId uuid.UUID, includeTags []string, excludeTags []string, band rating.Band) (models.Question, error) {
+	for _, question := range d.questions {
+		if question.rating >= band.Min && question.rating < band.Max {
+			return question.Question, nil
+		}
+	}
+	return models.Question{}, nil
+}
+
+func questionRated(r float64) ratedQuestion {
+	id := uuid.New()
+	return ratedQuestion{Question: models.Question{Id: &id, City: "Paris", Country: "France"}, rating: r}
+}
+
+func TestPickQuestionFallsBackToNearestBand(t *testing.T) {
+	// A fresh database, where nothing has moved from the starting rating.
+	fresh := []ratedQuestion{questionRated(rating.Initial), questionRated(rating.Initial)}
+	easy, medium, hard := questionRated(1200), questionRated(1500), questionRated(1800)
+
+	tests := []struct {
+		name       string
+		difficulty string
+		questions  []ratedQuestion
+		want       *uuid.UUID
+	}{
+		{name: "easy on a fresh database", difficulty: rating.DifficultyEasy, questions: fresh, want: fresh[0].Id},
+		{name: "hard on a fresh database", difficulty: rating.DifficultyHard, questions: fresh, want: fresh[0].Id},
+		{name: "easy prefers its own band", difficulty: rating.DifficultyEasy, questions: []ratedQuestion{hard, medium, easy}, want: easy.Id},
+		{name: "hard prefers its own band", difficulty: rating.DifficultyHard, questions: []ratedQuestion{easy, medium, hard}, want: hard.Id},
+		{name: "hard falls back to medium before easy", difficulty: rating.DifficultyHard, questions: []ratedQuestion{easy, medium}, want: medium.Id},
+		{name: "easy falls back to hard last", difficulty: rating.DifficultyEasy, questions: []ratedQuestion{hard}, want: hard.Id},
+		{name: "no questions left", difficulty: rating.DifficultyEasy, questions: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &quizServiceImpl{quizDao: &fakeQuestionPool{questions: tt.questions}}
+			quizId := uuid.New()
+			quiz := models.Quiz{Id: &quizId, Mode: models.QuizModeClassic, Difficulty: tt.difficulty}
+
+			got, err := service.pickQuestion(context.Background(), quiz)
+			if err != nil {
+				t.Fatalf("pickQuestion() error = %v", err)
+			}
+			if (got.Id == nil) != (tt.want == nil) || (got.Id != nil && *got.Id != *tt.want) {
+				t.Errorf("pickQuestion() = %v, want %v", got.Id, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+)
+
+const (
+	defaultLeaderboardMinGames = 10
+	defaultLeaderboardLimit    = 50
+	maxLeaderboardLimit        = 200
+)
+
+type RatingService interface {
+	GetLeaderboard(ctx context.Context, filter models.LeaderboardFilter) ([]models.RatedPlayer, error)
+	RecomputeRatings(ctx context.Context) (models.RatingRecomputeResult, error)
+}
+
+type ratingServiceImpl struct {
+	ratingDao dao.RatingDao
+}
+
+func NewRatingService(ratingDao dao.RatingDao) RatingService {
+	return &ratingServiceImpl{ratingDao: ratingDao}
+}
+
+// GetLeaderboard ranks players by rating. Players with fewer than MinGames
+// rated answers are left out, since a handful of lucky answers would
+// otherwise top the board.
+func (r *ratingServiceImpl) GetLeaderboard(ctx context.Context, filter models.LeaderboardFilter) ([]models.RatedPlayer, error) {
+	if filter.MinGames <= 0 {
+		filter.MinGames = defaultLeaderboardMinGames
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLeaderboardLimit
+	}
+	if filter.Limit > maxLeaderboardLimit {
+		filter.Limit = maxLeaderboardLimit
+	}
+	return r.ratingDao.GetLeaderboard(ctx, filter.MinGames, filter.Limit)
+}
+
+func (r *ratingServiceImpl) RecomputeRatings(ctx context.Context) (models.RatingRecomputeResult, error) {
+	return r.ratingDao.RecomputeRatings(ctx)
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+type ReviewService interface {
+	GetDueQuestion(ctx context.Context, quizId uuid.UUID, userId uuid.UUID) (models.Question, error)
+	RecordAnswer(ctx context.Context, userId uuid.UUID, questionId uuid.UUID, correct bool, practice bool) error
+	GetReviewQueue(ctx context.Context, userId uuid.UUID) (models.ReviewQueue, error)
+}
+
+type reviewServiceImpl struct {
+	reviewDao dao.ReviewDao
+	now       func() time.Time
+}
+
+func NewReviewService(reviewDao dao.ReviewDao) ReviewService {
+	return &reviewServiceImpl{reviewDao: reviewDao, now: time.Now}
+}
+
+// Review times are kept in UTC, since the columns carry no time zone.
+func (r *reviewServiceImpl) utcNow(ctx context.Context) time.Time {
+	return r.now().UTC()
+}
+
+func (r *reviewServiceImpl) GetDueQuestion(ctx context.Context, quizId uuid.UUID, userId uuid.UUID) (models.Question, error) {
+	return r.reviewDao.GetDueReviewQuestion(ctx, quizId, userId, r.utcNow(ctx))
+}
+
+func (r *reviewServiceImpl) RecordAnswer(ctx context.Context, userId uuid.UUID, questionId uuid.UUID, correct bool, practice bool) error {
+	return r.reviewDao.RecordReview(ctx, userId, questionId, correct, practice, r.utcNow(ctx))
+}
+
+// GetReviewQueue counts cards due before the end of the current UTC day as
+// due today.
+func (r *reviewServiceImpl) GetReviewQueue(ctx context.Context, userId uuid.UUID) (models.ReviewQueue, error) {
+	endOfDay := r.utcNow(ctx).Truncate(24 * time.Hour).Add(24*time.Hour - time.Nanosecond)
+
+	queue, err := r.reviewDao.GetReviewQueue(ctx, userId, endOfDay)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ReviewQueue{}, ErrUserNotFound
+	}
+	return queue, err
+}
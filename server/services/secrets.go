@@ -0,0 +1,33 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+// secretBytes is the length of the random part of staff keys and account
+// secrets.
+const secretBytes = 32
+
+func newSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating secret: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret hashes a secret for storage. Secrets are random, so a plain
+// SHA-256 is enough.
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// secretMatches reports whether secret hashes to hash, in constant time.
+func secretMatches(secret string, hash []byte) bool {
+	return len(hash) > 0 && subtle.ConstantTimeCompare(hashSecret(secret), hash) == 1
+}
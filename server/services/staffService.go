@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/rbac"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidStaffKey = apperrors.New(apperrors.Unauthorized, "invalid admin key")
+	ErrInvalidRole     = apperrors.New(apperrors.Validation, "role must be player, moderator or admin")
+	ErrLastAdmin       = apperrors.New(apperrors.Conflict, "cannot remove the last admin")
+)
+
+type StaffService interface {
+	Authenticate(ctx context.Context, key string) (models.Actor, error)
+	ListStaff(ctx context.Context) ([]models.Actor, error)
+	SetRole(ctx context.Context, userId uuid.UUID, role string) (models.StaffGrant, error)
+	GrantRole(ctx context.Context, userName string, role string) (models.StaffGrant, error)
+}
+
+type staffServiceImpl struct {
+	staffDao dao.StaffDao
+	userDao  dao.UserDao
+}
+
+func NewStaffService(staffDao dao.StaffDao, userDao dao.UserDao) StaffService {
+	return &staffServiceImpl{staffDao: staffDao, userDao: userDao}
+}
+
+// Authenticate returns the staff member a key belongs to.
+func (s *staffServiceImpl) Authenticate(ctx context.Context, key string) (models.Actor, error) {
+	if key == "" {
+		return models.Actor{}, ErrInvalidStaffKey
+	}
+	actor, err := s.staffDao.GetActorByKeyHash(ctx, hashSecret(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Actor{}, ErrInvalidStaffKey
+	}
+	return actor, err
+}
+
+func (s *staffServiceImpl) ListStaff(ctx context.Context) ([]models.Actor, error) {
+	return s.staffDao.ListStaff(ctx)
+}
+
+// SetRole changes the user's role. Promoting a player issues them a staff
+// key, returned this once; demoting to player revokes it. Moving between
+// staff roles keeps the key.
+func (s *staffServiceImpl) SetRole(ctx context.Context, userId uuid.UUID, role string) (models.StaffGrant, error) {
+	if !rbac.ValidRole(role) {
+		return models.StaffGrant{}, ErrInvalidRole
+	}
+
+	var key string
+	var keyHash []byte
+	if rbac.IsStaff(role) {
+		staff, err := s.staffDao.ListStaff(ctx)
+		if err != nil {
+			return models.StaffGrant{}, err
+		}
+		if !containsActor(staff, userId) {
+			if key, err = newSecret(); err != nil {
+				return models.StaffGrant{}, err
+			}
+			keyHash = hashSecret(key)
+		}
+	}
+
+	return s.setRole(ctx, userId, role, key, keyHash)
+}
+
+// GrantRole sets the named user's role and, for staff roles, always issues a
+// new key, so that it can bootstrap the first admin or replace a lost key.
+func (s *staffServiceImpl) GrantRole(ctx context.Context, userName string, role string) (models.StaffGrant, error) {
+	if !rbac.ValidRole(role) {
+		return models.StaffGrant{}, ErrInvalidRole
+	}
+	user, err := findUserByName(ctx, s.userDao, userName)
+	if err != nil {
+		return models.StaffGrant{}, err
+	}
+
+	var key string
+	var keyHash []byte
+	if rbac.IsStaff(role) {
+		if key, err = newSecret(); err != nil {
+			return models.StaffGrant{}, err
+		}
+		keyHash = hashSecret(key)
+	}
+
+	return s.setRole(ctx, *user.Id, role, key, keyHash)
+}
+
+func (s *staffServiceImpl) setRole(ctx context.Context, userId uuid.UUID, role string, key string, keyHash []byte) (models.StaffGrant, error) {
+	actor, err := s.staffDao.SetRole(ctx, userId, role, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.StaffGrant{}, ErrUserNotFound
+	}
+	if errors.Is(err, dao.ErrLastAdmin) {
+		return models.StaffGrant{}, ErrLastAdmin
+	}
+	if err != nil {
+		return models.StaffGrant{}, err
+	}
+	return models.StaffGrant{Actor: actor, StaffKey: key}, nil
+}
+
+func containsActor(actors []models.Actor, userId uuid.UUID) bool {
+	for _, actor := range actors {
+		if actor.UserId == userId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/google/uuid"
+)
+
+const maxTagLength = 64
+
+type TagService interface {
+	GetTagCoverage(ctx context.Context) (models.TagCoverage, error)
+	SetQuestionTags(ctx context.Context, questionId uuid.UUID, input models.QuestionTagsInput) error
+}
+
+type tagServiceImpl struct {
+	tagDao dao.TagDao
+}
+
+func NewTagService(tagDao dao.TagDao) TagService {
+	return &tagServiceImpl{tagDao: tagDao}
+}
+
+func (t *tagServiceImpl) GetTagCoverage(ctx context.Context) (models.TagCoverage, error) {
+	return t.tagDao.GetTagCoverage(ctx)
+}
+
+func (t *tagServiceImpl) SetQuestionTags(ctx context.Context, questionId uuid.UUID, input models.QuestionTagsInput) error {
+	input.Continent = strings.TrimSpace(input.Continent)
+	input.Region = strings.TrimSpace(input.Region)
+	input.Tags = normalizeTags(input.Tags)
+	for _, tag := range input.Tags {
+		if len(tag) > maxTagLength {
+			return fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidQuestion, tag, maxTagLength)
+		}
+	}
+
+	err := t.tagDao.SetQuestionTags(ctx, questionId, input)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	return err
+}
+
+// normalizeTags trims tags, drops empty ones and removes case-insensitive
+// duplicates, keeping the first spelling seen. It never returns nil.
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/tournament"
+	"github.com/axitdhola/globetrotter/server/tracing"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTournamentNotFound = apperrors.New(apperrors.NotFound, "tournament not found")
+	ErrInvalidTournament  = apperrors.New(apperrors.Validation, "invalid tournament")
+	ErrRegistrationClosed = apperrors.New(apperrors.Conflict, "registration is not open")
+	ErrRoundNotOpen       = apperrors.New(apperrors.Conflict, "round is not open")
+	ErrNotInTournament    = apperrors.New(apperrors.Forbidden, "not an active participant in this tournament")
+)
+
+type TournamentService interface {
+	CreateTournament(ctx context.Context, t models.Tournament, actor string) (models.Tournament, error)
+	GetTournament(ctx context.Context, tournamentId uuid.UUID) (models.Tournament, error)
+	ListTournaments(ctx context.Context) ([]models.Tournament, error)
+	Register(ctx context.Context, userId uuid.UUID, tournamentId uuid.UUID) error
+	StartRound(ctx context.Context, userId uuid.UUID, tournamentId uuid.UUID, roundNumber int) (models.Quiz, error)
+	GetStandings(ctx context.Context, tournamentId uuid.UUID) (models.TournamentStandings, error)
+	AdvanceRounds(ctx context.Context, now time.Time) error
+}
+
+type tournamentServiceImpl struct {
+	tournamentDao dao.TournamentDao
+	quizDao       dao.QuizDao
+	packDao       dao.PackDao
+	observer      QuizObserver
+	clock         tournament.Clock
+}
+
+func NewTournamentService(tournamentDao dao.TournamentDao, quizDao dao.QuizDao, packDao dao.PackDao, observer QuizObserver, clock tournament.Clock) TournamentService {
+	return &tournamentServiceImpl{tournamentDao: tournamentDao, quizDao: quizDao, packDao: packDao, observer: observer, clock: clock}
+}
+
+// CreateTournament checks that registration closes before the first round
+// and that rounds follow one another without overlapping. Rounds are
+// numbered in the order given.
+func (t *tournamentServiceImpl) CreateTournament(ctx context.Context, input models.Tournament, actor string) (models.Tournament, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return models.Tournament{}, fmt.Errorf("%w: name is required", ErrInvalidTournament)
+	}
+	input.RegistrationOpensAt = input.RegistrationOpensAt.UTC()
+	input.RegistrationClosesAt = input.RegistrationClosesAt.UTC()
+	if !input.RegistrationOpensAt.Before(input.RegistrationClosesAt) {
+		return models.Tournament{}, fmt.Errorf("%w: registration must open before it closes", ErrInvalidTournament)
+	}
+	if len(input.Rounds) == 0 {
+		return models.Tournament{}, fmt.Errorf("%w: at least one round is required", ErrInvalidTournament)
+	}
+
+	previousEnd := input.RegistrationClosesAt
+	for i := range input.Rounds {
+		round := &input.Rounds[i]
+		round.RoundNumber = i + 1
+		round.StartsAt = round.StartsAt.UTC()
+		round.EndsAt = round.EndsAt.UTC()
+
+		if round.StartsAt.Before(previousEnd) || !round.StartsAt.Before(round.EndsAt) {
+			return models.Tournament{}, fmt.Errorf("%w: round %d must start after the previous stage ends and end after it starts", ErrInvalidTournament, round.RoundNumber)
+		}
+		previousEnd = round.EndsAt
+
+		switch round.Format {
+		case models.RoundFormatTopN:
+			if round.AdvanceCount == nil || *round.AdvanceCount < 1 {
+				return models.Tournament{}, fmt.Errorf("%w: round %d needs an advance_count of at least 1", ErrInvalidTournament, round.RoundNumber)
+			}
+		case models.RoundFormatBracket:
+			round.AdvanceCount = nil
+		default:
+			return models.Tournament{}, fmt.Errorf("%w: round %d has unknown format %q", ErrInvalidTournament, round.RoundNumber, round.Format)
+		}
+
+		if _, err := t.packDao.GetPackById(ctx, round.PackId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.Tournament{}, fmt.Errorf("%w: round %d", ErrPackNotFound, round.RoundNumber)
+			}
+			return models.Tournament{}, err
+		}
+	}
+
+	return t.tournamentDao.CreateTournament(ctx, input, actor)
+}
+
+func (t *tournamentServiceImpl) GetTournament(ctx context.Context, tournamentId uuid.UUID) (models.Tournament, error) {
+	res, err := t.tournamentDao.GetTournament(ctx, tournamentId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Tournament{}, ErrTournamentNotFound
+	}
+	return res, err
+}
+
+func (t *tournamentServiceImpl) ListTournaments(ctx context.Context) ([]models.Tournament, error) {
+	return t.tournamentDao.ListTournaments(ctx)
+}
+
+func (t *tournamentServiceImpl) Register(ctx context.Context, userId uuid.UUID, tournamentId uuid.UUID) error {
+	tour, err := t.GetTournament(ctx, tournamentId)
+	if err != nil {
+		return err
+	}
+
+	now := t.clock.Now()
+	if tour.Status != models.TournamentStatusScheduled || now.Before(tour.RegistrationOpensAt) || !now.Before(tour.RegistrationClosesAt) {
+		return ErrRegistrationClosed
+	}
+	return t.tournamentDao.RegisterParticipant(ctx, tournamentId, userId)
+}
+
+// StartRound returns the player's quiz for an open round, creating it on the
+// first call. The quiz plays the round's pack in order.
+func (t *tournamentServiceImpl) StartRound(ctx context.Context, userId uuid.UUID, tournamentId uuid.UUID, roundNumber int) (models.Quiz, error) {
+	round, err := t.tournamentDao.GetRound(ctx, tournamentId, roundNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Quiz{}, ErrTournamentNotFound
+	}
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	if round.Status != models.RoundStatusOpen || !t.clock.Now().Before(round.EndsAt) {
+		return models.Quiz{}, ErrRoundNotOpen
+	}
+
+	active, err := t.tournamentDao.IsActiveParticipant(ctx, tournamentId, userId)
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	if !active {
+		return models.Quiz{}, ErrNotInTournament
+	}
+
+	if quizId, err := t.tournamentDao.GetEntryQuizId(ctx, *round.Id, userId); err != nil || quizId != nil {
+		if err != nil {
+			return models.Quiz{}, err
+		}
+		return t.quizDao.GetQuizById(ctx, *quizId)
+	}
+
+	quiz, err := t.quizDao.CreateQuiz(ctx, models.Quiz{
+		UserId:      userId,
+		Mode:        models.QuizModeClassic,
+		PackId:      &round.PackId,
+		IncludeTags: []string{},
+		ExcludeTags: []string{},
+	})
+	if err != nil {
+		return models.Quiz{}, err
+	}
+
+	created, err := t.tournamentDao.CreateEntry(ctx, *round.Id, userId, *quiz.Id)
+	if err != nil {
+		return models.Quiz{}, err
+	}
+	if !created {
+		// A concurrent call won; hand back its quiz instead.
+		quizId, err := t.tournamentDao.GetEntryQuizId(ctx, *round.Id, userId)
+		if err != nil {
+			return models.Quiz{}, err
+		}
+		return t.quizDao.GetQuizById(ctx, *quizId)
+	}
+	t.observer.QuizCreated(quiz.Mode)
+	return quiz, nil
+}
+
+func (t *tournamentServiceImpl) GetStandings(ctx context.Context, tournamentId uuid.UUID) (models.TournamentStandings, error) {
+	tour, err := t.GetTournament(ctx, tournamentId)
+	if err != nil {
+		return models.TournamentStandings{}, err
+	}
+
+	standings, err := t.tournamentDao.GetStandings(ctx, tournamentId)
+	if err != nil {
+		return models.TournamentStandings{}, err
+	}
+	matches, err := t.tournamentDao.ListMatches(ctx, tournamentId)
+	if err != nil {
+		return models.TournamentStandings{}, err
+	}
+
+	return models.TournamentStandings{Tournament: tour, Standings: standings, Matches: matches}, nil
+}
+
+// AdvanceRounds closes every open round whose end has passed and opens every
+// pending round whose start has passed. It is what the scheduler runs.
+func (t *tournamentServiceImpl) AdvanceRounds(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "TournamentService.AdvanceRounds")
+	defer span.End()
+
+	rounds, err := t.tournamentDao.ListDueRounds(ctx, now.UTC())
+	if err != nil {
+		return err
+	}
+
+	for _, round := range rounds {
+		if round.Status == models.RoundStatusOpen {
+			err = t.closeRound(ctx, round)
+		} else {
+			err = t.openRound(ctx, round)
+		}
+		if err != nil {
+			return fmt.Errorf("tournament %s round %d: %w", round.TournamentId, round.RoundNumber, err)
+		}
+	}
+	return nil
+}
+
+func (t *tournamentServiceImpl) openRound(ctx context.Context, round models.TournamentRound) error {
+	var pairings []tournament.Pairing
+	if round.Format == models.RoundFormatBracket {
+		var previousRoundId *uuid.UUID
+		if round.RoundNumber > 1 {
+			previous, err := t.tournamentDao.GetRound(ctx, round.TournamentId, round.RoundNumber-1)
+			if err != nil {
+				return err
+			}
+			previousRoundId = previous.Id
+		}
+
+		seeded, err := t.tournamentDao.ListSeededParticipants(ctx, round.TournamentId, previousRoundId)
+		if err != nil {
+			return err
+		}
+		pairings = tournament.PairBracket(seeded)
+	}
+
+	return t.tournamentDao.OpenRound(ctx, round, pairings)
+}
+
+func (t *tournamentServiceImpl) closeRound(ctx context.Context, round models.TournamentRound) error {
+	players, results, err := t.tournamentDao.GetRoundResults(ctx, round)
+	if err != nil {
+		return err
+	}
+
+	var eliminated []uuid.UUID
+	var winners map[uuid.UUID]uuid.UUID
+	if round.Format == models.RoundFormatBracket {
+		matches, err := t.tournamentDao.ListRoundMatches(ctx, *round.Id)
+		if err != nil {
+			return err
+		}
+		winners, eliminated = tournament.DecideMatches(matches, results)
+	} else {
+		_, eliminated = tournament.SelectTopN(players, results, *round.AdvanceCount)
+	}
+
+	tour, err := t.tournamentDao.GetTournament(ctx, round.TournamentId)
+	if err != nil {
+		return err
+	}
+	finished := round.RoundNumber == len(tour.Rounds)
+
+	return t.tournamentDao.CloseRound(ctx, round, results, eliminated, winners, finished)
+}
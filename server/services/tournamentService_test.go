@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/dao"
+	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/tournament"
+	"github.com/google/uuid"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// fakeTournamentDao keeps one tournament in memory and follows the same
+// contract as the Postgres DAO for the calls the scheduler makes.
+type fakeTournamentDao struct {
+	dao.TournamentDao
+
+	tournament models.Tournament
+	// participants are in seeding order for ties, standing in for rating.
+	participants []uuid.UUID
+	eliminated   map[uuid.UUID]int
+	// results are what each round's quizzes scored, by round number.
+	results  map[int]map[uuid.UUID]tournament.Result
+	matches  map[uuid.UUID][]tournament.Match
+	winners  map[uuid.UUID]uuid.UUID
+	finished bool
+}
+
+func (d *fakeTournamentDao) round(roundId uuid.UUID) *models.TournamentRound {
+	for i := range d.tournament.Rounds {
+		if *d.tournament.Rounds[i].Id == roundId {
+			return &d.tournament.Rounds[i]
+		}
+	}
+	return nil
+}
+
+func (d *fakeTournamentDao) active() []uuid.UUID {
+	var active []uuid.UUID
+	for _, p := range d.participants {
+		if _, out := d.eliminated[p]; !out {
+			active = append(active, p)
+		}
+	}
+	return active
+}
+
+func (d *fakeTournamentDao) GetTournament(ctx context.Context, tournamentId uuid.UUID) (models.Tournament, error) {
+	return d.tournament, nil
+}
+
+func (d *fakeTournamentDao) GetRound(ctx context.Context, tournamentId uuid.UUID, roundNumber int) (models.TournamentRound, error) {
+	return d.tournament.Rounds[roundNumber-1], nil
+}
+
+func (d *fakeTournamentDao) ListDueRounds(ctx context.Context, now time.Time) ([]models.TournamentRound, error) {
+	var closing, opening []models.TournamentRound
+	earlierClosed := true
+	for _, round := range d.tournament.Rounds {
+		switch {
+		case round.Status == models.RoundStatusOpen && !round.EndsAt.After(now):
+			closing = append(closing, round)
+		case round.Status == models.RoundStatusPending && !round.StartsAt.After(now) && earlierClosed:
+			opening = append(opening, round)
+		}
+		earlierClosed = earlierClosed && round.Status == models.RoundStatusClosed
+	}
+	return append(closing, opening...), nil
+}
+
+func (d *fakeTournamentDao) ListSeededParticipants(ctx context.Context, tournamentId uuid.UUID, previousRoundId *uuid.UUID) ([]uuid.UUID, error) {
+	seeded := d.active()
+	if previousRoundId != nil {
+		scores := d.results[d.round(*previousRoundId).RoundNumber]
+		sort.SliceStable(seeded, func(i, j int) bool { return scores[seeded[i]].Score > scores[seeded[j]].Score })
+	}
+	return seeded, nil
+}
+
+func (d *fakeTournamentDao) OpenRound(ctx context.Context, round models.TournamentRound, pairings []tournament.Pairing) error {
+	d.round(*round.Id).Status = models.RoundStatusOpen
+	for _, pairing := range pairings {
+		d.matches[*round.Id] = append(d.matches[*round.Id], tournament.Match{Id: uuid.New(), Pairing: pairing})
+	}
+	return nil
+}
+
+func (d *fakeTournamentDao) GetRoundResults(ctx context.Context, round models.TournamentRound) ([]uuid.UUID, map[uuid.UUID]tournament.Result, error) {
+	return d.active(), d.results[round.RoundNumber], nil
+}
+
+func (d *fakeTournamentDao) ListRoundMatches(ctx context.Context, roundId uuid.UUID) ([]tournament.Match, error) {
+	return d.matches[roundId], nil
+}
+
+func (d *fakeTournamentDao) CloseRound(ctx context.Context, round models.TournamentRound, results map[uuid.UUID]tournament.Result, eliminated []uuid.UUID, winners map[uuid.UUID]uuid.UUID, finished bool) error {
+	d.round(*round.Id).Status = models.RoundStatusClosed
+	for _, p := range eliminated {
+		d.eliminated[p] = round.RoundNumber
+	}
+	for matchId, winner := range winners {
+		d.winners[matchId] = winner
+	}
+	d.finished = finished
+	return nil
+}
+
+func newRound(tournamentId uuid.UUID, number int, format string, advanceCount *int, startsAt, endsAt time.Time) models.TournamentRound {
+	id := uuid.New()
+	return models.TournamentRound{Id: &id, TournamentId: tournamentId, RoundNumber: number, Format: format, AdvanceCount: advanceCount,
+		StartsAt: startsAt, EndsAt: endsAt, Status: models.RoundStatusPending}
+}
+
+func TestSchedulerDrivesTournamentRounds(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	tournamentId := uuid.New()
+	four := 4
+	p := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	finished := func(minutes int) *time.Time {
+		t := start.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+
+	d := &fakeTournamentDao{
+		tournament: models.Tournament{Id: &tournamentId, Rounds: []models.TournamentRound{
+			newRound(tournamentId, 1, models.RoundFormatTopN, &four, start.Add(time.Hour), start.Add(2*time.Hour)),
+			newRound(tournamentId, 2, models.RoundFormatBracket, nil, start.Add(3*time.Hour), start.Add(4*time.Hour)),
+		}},
+		participants: p,
+		eliminated:   map[uuid.UUID]int{},
+		results: map[int]map[uuid.UUID]tournament.Result{
+			1: {
+				p[0]: {Score: 9, CompletedAt: finished(70)},
+				p[1]: {Score: 8, CompletedAt: finished(75)},
+				p[2]: {Score: 7, CompletedAt: finished(90)},
+				p[3]: {Score: 7, CompletedAt: finished(80)},
+				p[4]: {Score: 3, CompletedAt: finished(65)},
+			},
+			2: {
+				p[0]: {Score: 5, CompletedAt: finished(190)},
+				p[3]: {Score: 6, CompletedAt: finished(200)},
+				p[1]: {Score: 4, CompletedAt: finished(185)},
+				p[2]: {Score: 4, CompletedAt: finished(195)},
+			},
+		},
+		matches: map[uuid.UUID][]tournament.Match{},
+		winners: map[uuid.UUID]uuid.UUID{},
+	}
+	service := NewTournamentService(d, nil, nil, NopQuizObserver, clock)
+	scheduler := tournament.NewScheduler(service, clock, time.Minute)
+
+	tickAt := func(offset time.Duration) {
+		t.Helper()
+		clock.now = start.Add(offset)
+		if err := scheduler.Tick(context.Background()); err != nil {
+			t.Fatalf("tick at +%v: %v", offset, err)
+		}
+	}
+	wantStatuses := func(offset time.Duration, want ...string) {
+		t.Helper()
+		for i, round := range d.tournament.Rounds {
+			if round.Status != want[i] {
+				t.Errorf("at +%v round %d is %s, want %s", offset, round.RoundNumber, round.Status, want[i])
+			}
+		}
+	}
+
+	tickAt(0)
+	wantStatuses(0, models.RoundStatusPending, models.RoundStatusPending)
+
+	tickAt(time.Hour)
+	wantStatuses(time.Hour, models.RoundStatusOpen, models.RoundStatusPending)
+
+	tickAt(90 * time.Minute)
+	wantStatuses(90*time.Minute, models.RoundStatusOpen, models.RoundStatusPending)
+
+	// Round one closes on time; round two waits for its own start.
+	tickAt(2 * time.Hour)
+	wantStatuses(2*time.Hour, models.RoundStatusClosed, models.RoundStatusPending)
+	if want := map[uuid.UUID]int{p[4]: 1}; !reflect.DeepEqual(d.eliminated, want) {
+		t.Errorf("after round one eliminated = %v, want %v", d.eliminated, want)
+	}
+	if d.finished {
+		t.Error("tournament finished after round one")
+	}
+
+	// Round two seeds by round one's scores and pairs top against bottom.
+	tickAt(3 * time.Hour)
+	wantStatuses(3*time.Hour, models.RoundStatusClosed, models.RoundStatusOpen)
+	bracket := d.matches[*d.tournament.Rounds[1].Id]
+	wantPairings := [][2]uuid.UUID{{p[0], p[3]}, {p[1], p[2]}}
+	if len(bracket) != len(wantPairings) {
+		t.Fatalf("round two has %d matches, want %d", len(bracket), len(wantPairings))
+	}
+	for i, match := range bracket {
+		if match.PlayerOne != wantPairings[i][0] || match.PlayerTwo == nil || *match.PlayerTwo != wantPairings[i][1] {
+			t.Errorf("match %d pairs %v and %v, want %v", i+1, match.PlayerOne, match.PlayerTwo, wantPairings[i])
+		}
+	}
+
+	// The higher score wins one match, the earlier finish the other.
+	tickAt(4 * time.Hour)
+	wantStatuses(4*time.Hour, models.RoundStatusClosed, models.RoundStatusClosed)
+	if got, want := d.winners[bracket[0].Id], p[3]; got != want {
+		t.Errorf("match one won by %v, want %v", got, want)
+	}
+	if got, want := d.winners[bracket[1].Id], p[1]; got != want {
+		t.Errorf("match two won by %v, want %v", got, want)
+	}
+	if want := map[uuid.UUID]int{p[4]: 1, p[0]: 2, p[2]: 2}; !reflect.DeepEqual(d.eliminated, want) {
+		t.Errorf("after round two eliminated = %v, want %v", d.eliminated, want)
+	}
+	if !d.finished {
+		t.Error("tournament not finished after its last round")
+	}
+}
+
+func TestAdvanceRoundsCatchesUpOneStepPerTick(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	tournamentId := uuid.New()
+	two := 2
+	d := &fakeTournamentDao{
+		tournament: models.Tournament{Id: &tournamentId, Rounds: []models.TournamentRound{
+			newRound(tournamentId, 1, models.RoundFormatTopN, &two, start, start.Add(time.Hour)),
+			newRound(tournamentId, 2, models.RoundFormatTopN, &two, start.Add(2*time.Hour), start.Add(3*time.Hour)),
+		}},
+		participants: []uuid.UUID{uuid.New(), uuid.New()},
+		eliminated:   map[uuid.UUID]int{},
+		results:      map[int]map[uuid.UUID]tournament.Result{},
+		matches:      map[uuid.UUID][]tournament.Match{},
+		winners:      map[uuid.UUID]uuid.UUID{},
+	}
+	service := NewTournamentService(d, nil, nil, NopQuizObserver, &fakeClock{})
+
+	// A scheduler that was down all day catches up one step per tick, never
+	// opening a round in the tick that closes the one before it.
+	late := start.Add(24 * time.Hour)
+	want := [][]string{
+		{models.RoundStatusOpen, models.RoundStatusPending},
+		{models.RoundStatusClosed, models.RoundStatusPending},
+		{models.RoundStatusClosed, models.RoundStatusOpen},
+		{models.RoundStatusClosed, models.RoundStatusClosed},
+	}
+	for tick, statuses := range want {
+		if err := service.AdvanceRounds(context.Background(), late); err != nil {
+			t.Fatalf("tick %d: %v", tick+1, err)
+		}
+		for i, round := range d.tournament.Rounds {
+			if round.Status != statuses[i] {
+				t.Errorf("after tick %d round %d is %s, want %s", tick+1, round.RoundNumber, round.Status, statuses[i])
+			}
+		}
+	}
+}
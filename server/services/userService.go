@@ -1,37 +1,355 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/axitdhola/globetrotter/server/apperrors"
+	"github.com/axitdhola/globetrotter/server/auth"
 	"github.com/axitdhola/globetrotter/server/dao"
 	"github.com/axitdhola/globetrotter/server/models"
+	"github.com/axitdhola/globetrotter/server/tracing"
+	"github.com/axitdhola/globetrotter/server/username"
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
 )
 
+const (
+	mostMissedCitiesLimit = 10
+	maxDisplayNameLength  = 64
+	maxAvatarUrlLength    = 2048
+)
+
+// maxUsernameSuggestions caps the free names offered when a name is taken.
+const maxUsernameSuggestions = 3
+
+var (
+	ErrUserNotFound    = apperrors.New(apperrors.NotFound, "user not found")
+	ErrInvalidProfile  = apperrors.New(apperrors.Validation, "invalid profile")
+	ErrInvalidUsername = apperrors.New(apperrors.Validation, "invalid username")
+	ErrUsernameTaken   = apperrors.New(apperrors.Conflict, "username is taken")
+	ErrStaffAccount    = apperrors.New(apperrors.Conflict, "staff accounts must be demoted to player before they can be deleted")
+	// ErrInvalidCredentials does not say whether the name or the secret was
+	// wrong, so that it cannot be used to find out who has an account.
+	ErrInvalidCredentials = apperrors.New(apperrors.Unauthorized, "invalid name or secret")
+)
+
+// UsernameTakenError is ErrUsernameTaken with free names to offer instead.
+type UsernameTakenError struct {
+	Suggestions []string
+}
+
+func (e *UsernameTakenError) Error() string {
+	return ErrUsernameTaken.Error()
+}
+
+func (e *UsernameTakenError) Unwrap() error {
+	return ErrUsernameTaken
+}
+
 type UserService interface {
-	GetUser(id int) (models.User, error)
-	RegisterUser(user models.User) (models.User, error)
+	GetUser(ctx context.Context, userId uuid.UUID) (models.User, error)
+	RegisterUser(ctx context.Context, user models.User) (models.User, error)
+	GetUserStats(ctx context.Context, userId uuid.UUID) (models.UserStats, error)
+	GetUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error)
+	GetReviewQueue(ctx context.Context, userId uuid.UUID) (models.ReviewQueue, error)
+	CreateSession(ctx context.Context, input models.SessionInput) (models.Session, error)
+	ResetSecret(ctx context.Context, userId uuid.UUID) (models.User, error)
+	GetProfile(ctx context.Context, userId uuid.UUID) (models.User, error)
+	UpdateProfile(ctx context.Context, userId uuid.UUID, input models.UserProfileInput) (models.User, error)
+	DeleteUser(ctx context.Context, userId uuid.UUID) error
 }
 
 type userServiceImpl struct {
-	userDao dao.UserDao
+	userDao  dao.UserDao
+	statsDao dao.StatsDao
+
+	achievementService AchievementService
+	reviewService      ReviewService
+
+	signer *auth.Signer
+}
+
+func NewUserService(userDao dao.UserDao, statsDao dao.StatsDao, achievementService AchievementService, reviewService ReviewService, signer *auth.Signer) UserService {
+	return &userServiceImpl{userDao: userDao, statsDao: statsDao, achievementService: achievementService, reviewService: reviewService, signer: signer}
+}
+
+// GetUser returns the player with the given id. Deleted players are not
+// found.
+func (u *userServiceImpl) GetUser(ctx context.Context, userId uuid.UUID) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	user, err := u.userDao.GetUserById(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.DeletedAt != nil) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// RegisterUser stores the name in its normalized form. Names must be unique
+// ignoring case, so a taken name fails with the closest free alternatives.
+// The player's sign-in secret is returned this once; only its hash is kept.
+func (u *userServiceImpl) RegisterUser(ctx context.Context, user models.User) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer span.End()
+
+	user.Name = username.Normalize(user.Name)
+	if err := username.Validate(user.Name); err != nil {
+		return models.User{}, fmt.Errorf("%w: name %v", ErrInvalidUsername, err)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	created, err := u.userDao.CreateUser(ctx, user, username.Key(user.Name), hashSecret(secret))
+	if dao.IsUniqueViolation(err) {
+		return models.User{}, u.usernameTaken(ctx, user.Name)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	created.Secret = secret
+	return created, nil
+}
+
+func (u *userServiceImpl) usernameTaken(ctx context.Context, name string) error {
+	candidates := username.Suggestions(name)
+	keys := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		keys[i] = username.Key(candidate)
+	}
+
+	taken, err := u.userDao.ListTakenUsernameKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	takenKeys := make(map[string]bool, len(taken))
+	for _, key := range taken {
+		takenKeys[key] = true
+	}
+
+	suggestions := []string{}
+	for i, candidate := range candidates {
+		if !takenKeys[keys[i]] && len(suggestions) < maxUsernameSuggestions {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return &UsernameTakenError{Suggestions: suggestions}
+}
+
+// findUserByName looks a player up by name, however it is cased or encoded.
+func findUserByName(ctx context.Context, userDao dao.UserDao, name string) (models.User, error) {
+	user, err := userDao.GetUserByUsernameKey(ctx, username.Key(name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (u *userServiceImpl) GetUserStats(ctx context.Context, userId uuid.UUID) (models.UserStats, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserStats", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	stats, err := u.statsDao.GetUserStats(ctx, userId, mostMissedCitiesLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.UserStats{}, ErrUserNotFound
+	}
+	return stats, err
+}
+
+func (u *userServiceImpl) GetUserAchievements(ctx context.Context, userId uuid.UUID) ([]models.Achievement, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserAchievements", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	return u.achievementService.ListUserAchievements(ctx, userId)
 }
 
-func NewUserService(userDao dao.UserDao) UserService {
-	return &userServiceImpl{userDao: userDao}
+func (u *userServiceImpl) GetReviewQueue(ctx context.Context, userId uuid.UUID) (models.ReviewQueue, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReviewQueue", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	return u.reviewService.GetReviewQueue(ctx, userId)
 }
 
-func (u *userServiceImpl) GetUser(id int) (models.User, error) {
-	if id == 0 {
-		return models.User{}, errors.New("invalid user id")
+// CreateSession signs the named player in with the secret they were issued.
+// Players without one cannot sign in until it is reset; see ResetSecret.
+func (u *userServiceImpl) CreateSession(ctx context.Context, input models.SessionInput) (models.Session, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateSession")
+	defer span.End()
+
+	if input.Secret == "" {
+		return models.Session{}, ErrInvalidCredentials
+	}
+	user, err := findUserByName(ctx, u.userDao, input.Name)
+	if errors.Is(err, ErrUserNotFound) {
+		return models.Session{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.Session{}, err
+	}
+	secretHash, err := u.userDao.GetUserSecretHash(ctx, *user.Id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !secretMatches(input.Secret, secretHash)) {
+		return models.Session{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	token, err := u.signer.Sign(*user.Id)
+	if err != nil {
+		return models.Session{}, err
 	}
-	return u.userDao.GetUser(id)
+	return models.Session{User: user, Token: token, ExpiresAt: time.Now().Add(u.signer.TTL())}, nil
 }
 
-func (u *userServiceImpl) RegisterUser(user models.User) (models.User, error) {
-	user.Name = strings.TrimSpace(user.Name)
-	if user.Name == "" {
-		return models.User{}, errors.New("invalid user name")
+// ResetSecret issues the player a new sign-in secret, returned this once,
+// for accounts that lost theirs or predate secrets. Sessions already issued
+// stay valid until they expire.
+func (u *userServiceImpl) ResetSecret(ctx context.Context, userId uuid.UUID) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ResetSecret", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	user, err := u.GetUser(ctx, userId)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return models.User{}, err
+	}
+	err = u.userDao.SetUserSecretHash(ctx, userId, hashSecret(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	user.Secret = secret
+	return user, nil
+}
+
+// GetProfile returns the signed-in player.
+func (u *userServiceImpl) GetProfile(ctx context.Context, userId uuid.UUID) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	return u.GetUser(ctx, userId)
+}
+
+func (u *userServiceImpl) UpdateProfile(ctx context.Context, userId uuid.UUID, input models.UserProfileInput) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	user, err := u.GetProfile(ctx, userId)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err := applyProfileInput(&user, input); err != nil {
+		return models.User{}, err
 	}
-	return u.userDao.CreateUser(user)
-}
\ No newline at end of file
+
+	updated, err := u.userDao.UpdateUserProfile(ctx, user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	return updated, err
+}
+
+// DeleteUser anonymizes the player. Their quizzes stay behind without any
+// personal data, so leaderboards and group reports do not shift. Staff are
+// refused until an admin demotes them, which keeps the last admin and the
+// audit log intact.
+func (u *userServiceImpl) DeleteUser(ctx context.Context, userId uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser", tracing.UserIdKey.String(userId.String()))
+	defer span.End()
+
+	err := u.userDao.DeleteUser(ctx, userId, username.DeletedPrefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if errors.Is(err, dao.ErrStaffAccount) {
+		return ErrStaffAccount
+	}
+	return err
+}
+
+// applyProfileInput validates the fields present in input and copies them
+// onto user.
+func applyProfileInput(user *models.User, input models.UserProfileInput) error {
+	if input.DisplayName != nil {
+		name := strings.TrimSpace(*input.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return fmt.Errorf("%w: display name is longer than %d characters", ErrInvalidProfile, maxDisplayNameLength)
+		}
+		user.DisplayName = name
+	}
+
+	if input.AvatarUrl != nil {
+		avatarUrl := strings.TrimSpace(*input.AvatarUrl)
+		if avatarUrl != "" {
+			parsed, err := url.Parse(avatarUrl)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(avatarUrl) > maxAvatarUrlLength {
+				return fmt.Errorf("%w: avatar url must be an http or https link", ErrInvalidProfile)
+			}
+		}
+		user.AvatarUrl = avatarUrl
+	}
+
+	if input.HomeCountry != nil {
+		country := strings.TrimSpace(*input.HomeCountry)
+		if country != "" {
+			region, err := language.ParseRegion(country)
+			if err != nil || !region.IsCountry() {
+				return fmt.Errorf("%w: unknown country %q", ErrInvalidProfile, country)
+			}
+			country = region.String()
+		}
+		user.HomeCountry = country
+	}
+
+	if input.Locale != nil {
+		locale := strings.TrimSpace(*input.Locale)
+		if locale != "" {
+			normalized, err := normalizeLocale(locale)
+			if err != nil {
+				return fmt.Errorf("%w: invalid locale %q", ErrInvalidProfile, locale)
+			}
+			locale = normalized
+		}
+		user.Locale = locale
+	}
+
+	if prefs := input.QuizPreferences; prefs != nil {
+		if prefs.Length != nil {
+			user.QuizPreferences.Length = zeroAsNil(*prefs.Length)
+		}
+		if prefs.Difficulty != nil {
+			user.QuizPreferences.Difficulty = strings.ToLower(strings.TrimSpace(*prefs.Difficulty))
+		}
+		if prefs.TimerSeconds != nil {
+			user.QuizPreferences.TimerSeconds = zeroAsNil(*prefs.TimerSeconds)
+		}
+		if err := validateQuizSettings(user.QuizPreferences.Length, user.QuizPreferences.Difficulty, user.QuizPreferences.TimerSeconds); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+		}
+	}
+	return nil
+}
+
+func zeroAsNil(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,68 @@
+// Package srs schedules review cards with the SM-2 spaced-repetition
+// algorithm.
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// InitialEase is the ease factor of a new card.
+	InitialEase = 2.5
+	// MinEase stops a card that is often missed from coming back every day
+	// forever.
+	MinEase = 1.3
+)
+
+// SM-2 grades a review from 0 to 5; 3 and above is a pass. Quiz answers are
+// only right or wrong, so they map onto one passing and one failing grade.
+const (
+	QualityCorrect = 4
+	QualityMissed  = 1
+	passingQuality = 3
+)
+
+type Card struct {
+	Ease         float64
+	IntervalDays int
+	Repetitions  int
+	DueAt        time.Time
+}
+
+// NewCard returns a card that is due straight away.
+func NewCard(now time.Time) Card {
+	return Card{Ease: InitialEase, DueAt: now}
+}
+
+// Quality returns the grade for a right or wrong answer.
+func Quality(correct bool) int {
+	if correct {
+		return QualityCorrect
+	}
+	return QualityMissed
+}
+
+// Review returns the card rescheduled after a review graded quality at now.
+// A failed review starts the card over at a one day interval.
+func Review(card Card, quality int, now time.Time) Card {
+	if quality < passingQuality {
+		card.Repetitions = 0
+		card.IntervalDays = 1
+	} else {
+		card.Repetitions++
+		switch card.Repetitions {
+		case 1:
+			card.IntervalDays = 1
+		case 2:
+			card.IntervalDays = 6
+		default:
+			card.IntervalDays = int(math.Round(float64(card.IntervalDays) * card.Ease))
+		}
+	}
+
+	miss := float64(5 - quality)
+	card.Ease = math.Max(MinEase, card.Ease+0.1-miss*(0.08+miss*0.02))
+	card.DueAt = now.AddDate(0, 0, card.IntervalDays)
+	return card
+}
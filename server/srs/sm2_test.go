@@ -0,0 +1,87 @@
+package srs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func TestReviewIntervalsGrowWithEachPass(t *testing.T) {
+	// A correct answer grades 4, which leaves the ease at 2.5, so after the
+	// fixed first two steps each interval is the last times 2.5, rounded.
+	want := []int{1, 6, 15, 38, 95}
+
+	card := NewCard(start)
+	now := start
+	for i, days := range want {
+		card = Review(card, QualityCorrect, now)
+		if card.IntervalDays != days {
+			t.Fatalf("pass %d: interval = %d days, want %d", i+1, card.IntervalDays, days)
+		}
+		if card.Repetitions != i+1 {
+			t.Errorf("pass %d: repetitions = %d, want %d", i+1, card.Repetitions, i+1)
+		}
+		if want := now.AddDate(0, 0, days); !card.DueAt.Equal(want) {
+			t.Errorf("pass %d: due %v, want %v", i+1, card.DueAt, want)
+		}
+		if card.Ease != InitialEase {
+			t.Errorf("pass %d: ease = %v, want %v", i+1, card.Ease, InitialEase)
+		}
+		now = card.DueAt
+	}
+}
+
+func TestReviewUsesTheCardsEase(t *testing.T) {
+	card := Card{Ease: 1.5, IntervalDays: 10, Repetitions: 4}
+
+	got := Review(card, QualityCorrect, start)
+	if got.IntervalDays != 15 {
+		t.Errorf("interval = %d days, want 15", got.IntervalDays)
+	}
+}
+
+func TestReviewFailureStartsOver(t *testing.T) {
+	card := Card{Ease: 2.5, IntervalDays: 38, Repetitions: 4}
+
+	missed := Review(card, QualityMissed, start)
+	if missed.Repetitions != 0 || missed.IntervalDays != 1 {
+		t.Errorf("after a miss: repetitions = %d, interval = %d days, want 0 and 1", missed.Repetitions, missed.IntervalDays)
+	}
+	if want := start.AddDate(0, 0, 1); !missed.DueAt.Equal(want) {
+		t.Errorf("after a miss: due %v, want %v", missed.DueAt, want)
+	}
+	if missed.Ease >= card.Ease {
+		t.Errorf("after a miss: ease = %v, want less than %v", missed.Ease, card.Ease)
+	}
+
+	// The card then climbs the fixed steps again.
+	again := Review(missed, QualityCorrect, missed.DueAt)
+	again = Review(again, QualityCorrect, again.DueAt)
+	if again.IntervalDays != 6 {
+		t.Errorf("second pass after a miss: interval = %d days, want 6", again.IntervalDays)
+	}
+}
+
+func TestReviewEaseNeverDropsBelowMinimum(t *testing.T) {
+	tests := []struct {
+		misses int
+		want   float64
+	}{
+		{misses: 1, want: 1.96},
+		{misses: 2, want: 1.42},
+		{misses: 3, want: MinEase},
+		{misses: 10, want: MinEase},
+	}
+
+	for _, tt := range tests {
+		card := NewCard(start)
+		for i := 0; i < tt.misses; i++ {
+			card = Review(card, QualityMissed, start)
+		}
+		if math.Abs(card.Ease-tt.want) > 1e-9 {
+			t.Errorf("after %d misses: ease = %v, want %v", tt.misses, card.Ease, tt.want)
+		}
+	}
+}
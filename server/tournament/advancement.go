@@ -0,0 +1,123 @@
+// Package tournament holds the rules that move players between tournament
+// rounds, and the scheduler that opens and closes rounds on time.
+package tournament
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Result is one surviving player's showing in a round.
+type Result struct {
+	UserId uuid.UUID
+	Score  int
+	// CompletedAt is when the player finished the round's quiz; nil if they
+	// never did. Earlier finishers win ties.
+	CompletedAt *time.Time
+}
+
+// Pairing is a head-to-head match. PlayerTwo is nil for a bye.
+type Pairing struct {
+	PlayerOne uuid.UUID
+	PlayerTwo *uuid.UUID
+}
+
+// Match is a pairing together with its id, as stored.
+type Match struct {
+	Id uuid.UUID
+	Pairing
+}
+
+// Beats reports whether a ranks above b: a higher score first, then an
+// earlier finish, then any finish over none.
+func Beats(a, b Result) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	switch {
+	case a.CompletedAt == nil:
+		return false
+	case b.CompletedAt == nil:
+		return true
+	}
+	return a.CompletedAt.Before(*b.CompletedAt)
+}
+
+// PairBracket pairs players seeded best first: the top seed meets the bottom
+// seed, the second meets the second to last, and so on. With an odd count
+// the top seed gets a bye.
+func PairBracket(seeded []uuid.UUID) []Pairing {
+	var pairings []Pairing
+	lo, hi := 0, len(seeded)-1
+	if len(seeded)%2 == 1 {
+		pairings = append(pairings, Pairing{PlayerOne: seeded[0]})
+		lo++
+	}
+	for ; lo < hi; lo, hi = lo+1, hi-1 {
+		two := seeded[hi]
+		pairings = append(pairings, Pairing{PlayerOne: seeded[lo], PlayerTwo: &two})
+	}
+	return pairings
+}
+
+// SelectTopN returns the players who advance from a top-N round and those
+// eliminated. Players with no result count as a score of zero and no finish.
+func SelectTopN(players []uuid.UUID, results map[uuid.UUID]Result, n int) (advancing []uuid.UUID, eliminated []uuid.UUID) {
+	ranked := Rank(players, results)
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	return ranked[:n], ranked[n:]
+}
+
+// DecideMatches returns each match's winner, keyed by match id, and the
+// players knocked out. A bye is won by its only player.
+func DecideMatches(matches []Match, results map[uuid.UUID]Result) (winners map[uuid.UUID]uuid.UUID, eliminated []uuid.UUID) {
+	winners = make(map[uuid.UUID]uuid.UUID, len(matches))
+	for _, match := range matches {
+		if match.PlayerTwo == nil {
+			winners[match.Id] = match.PlayerOne
+			continue
+		}
+
+		one := resultFor(match.PlayerOne, results)
+		two := resultFor(*match.PlayerTwo, results)
+		if Beats(two, one) {
+			winners[match.Id] = two.UserId
+			eliminated = append(eliminated, one.UserId)
+		} else {
+			winners[match.Id] = one.UserId
+			eliminated = append(eliminated, two.UserId)
+		}
+	}
+	return winners, eliminated
+}
+
+// Rank orders players best first by their results.
+func Rank(players []uuid.UUID, results map[uuid.UUID]Result) []uuid.UUID {
+	ranked := make([]Result, len(players))
+	for i, player := range players {
+		ranked[i] = resultFor(player, results)
+	}
+	// Insertion sort keeps equal players in their given order.
+	for i := 1; i < len(ranked); i++ {
+		for j := i; j > 0 && Beats(ranked[j], ranked[j-1]); j-- {
+			ranked[j], ranked[j-1] = ranked[j-1], ranked[j]
+		}
+	}
+
+	ids := make([]uuid.UUID, len(ranked))
+	for i, result := range ranked {
+		ids[i] = result.UserId
+	}
+	return ids
+}
+
+func resultFor(player uuid.UUID, results map[uuid.UUID]Result) Result {
+	if result, ok := results[player]; ok {
+		result.UserId = player
+		return result
+	}
+	return Result{UserId: player}
+}
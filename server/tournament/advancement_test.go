@@ -0,0 +1,125 @@
+package tournament
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// players returns n player ids, standing in for seeds 1 to n.
+func players(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids
+}
+
+func finishedAt(minutes int) *time.Time {
+	t := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC).Add(time.Duration(minutes) * time.Minute)
+	return &t
+}
+
+func TestPairBracket(t *testing.T) {
+	p := players(5)
+	pair := func(one, two uuid.UUID) Pairing { return Pairing{PlayerOne: one, PlayerTwo: &two} }
+	bye := func(one uuid.UUID) Pairing { return Pairing{PlayerOne: one} }
+
+	tests := []struct {
+		name   string
+		seeded []uuid.UUID
+		want   []Pairing
+	}{
+		{name: "no players", seeded: nil, want: nil},
+		{name: "one player gets a bye", seeded: p[:1], want: []Pairing{bye(p[0])}},
+		{name: "two players", seeded: p[:2], want: []Pairing{pair(p[0], p[1])}},
+		{name: "top seed meets bottom seed", seeded: p[:4], want: []Pairing{pair(p[0], p[3]), pair(p[1], p[2])}},
+		{name: "odd count gives the top seed a bye", seeded: p[:5], want: []Pairing{bye(p[0]), pair(p[1], p[4]), pair(p[2], p[3])}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PairBracket(tt.seeded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PairBracket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTopN(t *testing.T) {
+	p := players(5)
+	results := map[uuid.UUID]Result{
+		p[0]: {Score: 3, CompletedAt: finishedAt(10)},
+		p[1]: {Score: 9, CompletedAt: finishedAt(30)},
+		p[2]: {Score: 7, CompletedAt: finishedAt(20)},
+		p[3]: {Score: 7, CompletedAt: finishedAt(5)},
+		// p[4] never played.
+	}
+
+	tests := []struct {
+		name           string
+		n              int
+		wantAdvancing  []uuid.UUID
+		wantEliminated []uuid.UUID
+	}{
+		{name: "earlier finish wins a tie", n: 2, wantAdvancing: []uuid.UUID{p[1], p[3]}, wantEliminated: []uuid.UUID{p[2], p[0], p[4]}},
+		{name: "missing results rank last", n: 4, wantAdvancing: []uuid.UUID{p[1], p[3], p[2], p[0]}, wantEliminated: []uuid.UUID{p[4]}},
+		{name: "n above the player count keeps everyone", n: 10, wantAdvancing: []uuid.UUID{p[1], p[3], p[2], p[0], p[4]}, wantEliminated: []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advancing, eliminated := SelectTopN(p, results, tt.n)
+			if !reflect.DeepEqual(advancing, tt.wantAdvancing) {
+				t.Errorf("advancing = %v, want %v", advancing, tt.wantAdvancing)
+			}
+			if !reflect.DeepEqual(eliminated, tt.wantEliminated) {
+				t.Errorf("eliminated = %v, want %v", eliminated, tt.wantEliminated)
+			}
+		})
+	}
+}
+
+func TestRankKeepsOrderOfEqualPlayers(t *testing.T) {
+	p := players(3)
+	got := Rank(p, map[uuid.UUID]Result{})
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("Rank() = %v, want the given order %v", got, p)
+	}
+}
+
+func TestDecideMatches(t *testing.T) {
+	p := players(7)
+	match := func(one, two uuid.UUID) Match {
+		return Match{Id: uuid.New(), Pairing: Pairing{PlayerOne: one, PlayerTwo: &two}}
+	}
+	bye := Match{Id: uuid.New(), Pairing: Pairing{PlayerOne: p[0]}}
+	higherScore := match(p[1], p[2])
+	earlierFinish := match(p[3], p[4])
+	noShows := match(p[5], p[6])
+
+	results := map[uuid.UUID]Result{
+		p[1]: {Score: 4, CompletedAt: finishedAt(1)},
+		p[2]: {Score: 6, CompletedAt: finishedAt(50)},
+		p[3]: {Score: 5, CompletedAt: finishedAt(40)},
+		p[4]: {Score: 5, CompletedAt: finishedAt(20)},
+	}
+
+	winners, eliminated := DecideMatches([]Match{bye, higherScore, earlierFinish, noShows}, results)
+
+	wantWinners := map[uuid.UUID]uuid.UUID{
+		bye.Id:           p[0],
+		higherScore.Id:   p[2],
+		earlierFinish.Id: p[4],
+		// With no results at all, player one keeps the match.
+		noShows.Id: p[5],
+	}
+	if !reflect.DeepEqual(winners, wantWinners) {
+		t.Errorf("winners = %v, want %v", winners, wantWinners)
+	}
+	if want := []uuid.UUID{p[1], p[3], p[6]}; !reflect.DeepEqual(eliminated, want) {
+		t.Errorf("eliminated = %v, want %v", eliminated, want)
+	}
+}
@@ -0,0 +1,62 @@
+package tournament
+
+import (
+	"context"
+	"time"
+
+	"github.com/axitdhola/globetrotter/server/logging"
+)
+
+// Clock tells the scheduler the time. Tests can swap in a fake clock to
+// drive rounds without waiting.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock reads the wall clock.
+var SystemClock Clock = systemClock{}
+
+// Advancer opens rounds whose start time has passed and closes rounds whose
+// end time has passed.
+type Advancer interface {
+	AdvanceRounds(ctx context.Context, now time.Time) error
+}
+
+// Scheduler calls an Advancer on a fixed interval.
+type Scheduler struct {
+	advancer Advancer
+	clock    Clock
+	interval time.Duration
+}
+
+func NewScheduler(advancer Advancer, clock Clock, interval time.Duration) *Scheduler {
+	return &Scheduler{advancer: advancer, clock: clock, interval: interval}
+}
+
+// Tick advances rounds once, as of the clock's current time.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	return s.advancer.AdvanceRounds(ctx, s.clock.Now())
+}
+
+// Run ticks until ctx is cancelled. Errors are logged and retried on the
+// next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			logging.FromContext(ctx).Error("tournament scheduler tick failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tournament
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock the test sets by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// recordingAdvancer remembers the time of every call and fails the calls
+// listed in fail.
+type recordingAdvancer struct {
+	mu    sync.Mutex
+	calls []time.Time
+	fail  map[int]bool
+	// called, when set, receives the number of calls so far after each one
+	// unless its buffer is full.
+	called chan int
+}
+
+func (a *recordingAdvancer) AdvanceRounds(ctx context.Context, now time.Time) error {
+	a.mu.Lock()
+	a.calls = append(a.calls, now)
+	n := len(a.calls)
+	a.mu.Unlock()
+
+	if a.called != nil {
+		select {
+		case a.called <- n:
+		default:
+		}
+	}
+	if a.fail[n] {
+		return errors.New("database is down")
+	}
+	return nil
+}
+
+func TestSchedulerTickUsesClock(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	advancer := &recordingAdvancer{}
+	scheduler := NewScheduler(advancer, clock, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if err := scheduler.Tick(context.Background()); err != nil {
+			t.Fatalf("Tick() error = %v", err)
+		}
+		clock.Advance(90 * time.Minute)
+	}
+
+	want := []time.Time{start, start.Add(90 * time.Minute), start.Add(180 * time.Minute)}
+	if len(advancer.calls) != len(want) {
+		t.Fatalf("advanced %d times, want %d", len(advancer.calls), len(want))
+	}
+	for i := range want {
+		if !advancer.calls[i].Equal(want[i]) {
+			t.Errorf("tick %d advanced at %v, want %v", i+1, advancer.calls[i], want[i])
+		}
+	}
+}
+
+func TestSchedulerTickReturnsAdvancerError(t *testing.T) {
+	advancer := &recordingAdvancer{fail: map[int]bool{1: true}}
+	scheduler := NewScheduler(advancer, &fakeClock{}, time.Hour)
+
+	if err := scheduler.Tick(context.Background()); err == nil {
+		t.Error("Tick() error = nil, want the advancer's error")
+	}
+}
+
+func TestSchedulerRunRetriesAfterErrorsUntilCancelled(t *testing.T) {
+	advancer := &recordingAdvancer{fail: map[int]bool{1: true}, called: make(chan int, 10)}
+	scheduler := NewScheduler(advancer, &fakeClock{}, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	// The first tick runs at once and fails; later ticks keep coming.
+	for want := 1; want <= 3; want++ {
+		select {
+		case n := <-advancer.called:
+			if n != want {
+				t.Fatalf("call %d reported as %d", want, n)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("scheduler stopped after %d ticks", want-1)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+}
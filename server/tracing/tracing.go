@@ -0,0 +1,115 @@
+// Package tracing sets up OpenTelemetry tracing and records database round
+// trips as spans of the request that made them.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/axitdhola/globetrotter/server/config"
+	"github.com/axitdhola/globetrotter/server/db"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the server in traces.
+const ServiceName = "globetrotter"
+
+const instrumentationName = "github.com/axitdhola/globetrotter/server"
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes buffered spans; call it on
+// shutdown. With the "none" exporter nothing is installed.
+func Setup(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case config.TraceExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case config.TraceExporterOTLP:
+		var endpoint string
+		if endpoint, err = tracesURL(cfg.OTLPEndpoint); err == nil {
+			exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+		}
+	case config.TraceExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace exporter: %v", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("error building trace resource: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Follow the caller's sampling decision so frontend traces stay whole.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// tracesURL turns the collector's base URL into the URL spans are posted
+// to. Like the SDKs do with OTEL_EXPORTER_OTLP_ENDPOINT, it appends the
+// traces path to whatever path the base URL already has.
+func tracesURL(endpoint string) (string, error) {
+	return url.JoinPath(endpoint, "v1/traces")
+}
+
+// Start begins a span named name as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// ObserveQuery is a db.Observer that records each round trip as a span named
+// after the function that made it. Statements are recorded without their
+// arguments.
+func ObserveQuery(ctx context.Context, q db.Query) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		// Queries outside a traced request, such as startup pings, would
+		// each start a trace of their own.
+		return
+	}
+
+	name := q.Caller
+	if name == "" {
+		name = "db." + q.Op
+	}
+	attrs := []attribute.KeyValue{semconv.DBSystemPostgreSQL, semconv.DBOperationName(q.Op)}
+	if q.SQL != "" {
+		attrs = append(attrs, semconv.DBQueryText(q.SQL))
+	}
+
+	_, span := otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithTimestamp(q.Start),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	if q.Err != nil {
+		span.RecordError(q.Err)
+		span.SetStatus(codes.Error, q.Err.Error())
+	}
+	span.End(trace.WithTimestamp(q.Start.Add(q.Duration)))
+}
+
+// Attribute keys for the ids spans carry.
+const (
+	QuizIdKey = attribute.Key("quiz.id")
+	UserIdKey = attribute.Key("user.id")
+)
@@ -0,0 +1,24 @@
+package tracing
+
+import "testing"
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "http://localhost:4318", want: "http://localhost:4318/v1/traces"},
+		{endpoint: "http://localhost:4318/", want: "http://localhost:4318/v1/traces"},
+		{endpoint: "https://collector.example.com/otlp", want: "https://collector.example.com/otlp/v1/traces"},
+	}
+
+	for _, tt := range tests {
+		got, err := tracesURL(tt.endpoint)
+		if err != nil {
+			t.Fatalf("tracesURL(%q) error = %v", tt.endpoint, err)
+		}
+		if got != tt.want {
+			t.Errorf("tracesURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package username normalizes and validates player names. Two names that fold
+// to the same key belong to the same player, however they are cased or
+// encoded.
+package username
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 32
+)
+
+// DeletedPrefix starts the names given to deleted accounts.
+const DeletedPrefix = "deleted-"
+
+// reserved names could be mistaken for the service itself or collide with
+// routes such as /user/me.
+var reserved = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"anonymous":     true,
+	"api":           true,
+	"deleted":       true,
+	"globetrotter":  true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"register":      true,
+	"root":          true,
+	"session":       true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+}
+
+var (
+	ErrTooShort     = fmt.Errorf("must be at least %d characters", MinLength)
+	ErrTooLong      = fmt.Errorf("must be at most %d characters", MaxLength)
+	ErrInvalidChars = errors.New("may only contain letters, digits, '.', '_' and '-', and must start with a letter or digit")
+	ErrReservedName = errors.New("is reserved")
+)
+
+// suggestionSuffixes are appended to a taken name to suggest free ones.
+var suggestionSuffixes = []string{"1", "2", "3", "_1", "7", "42", "99", "_99", "2026"}
+
+// Normalize returns the name as it is stored and shown: NFKC-normalized with
+// surrounding space removed. Letter case is kept.
+func Normalize(name string) string {
+	return strings.TrimSpace(norm.NFKC.String(name))
+}
+
+// Key returns the case-folded form of name that uniqueness and lookups use.
+func Key(name string) string {
+	return norm.NFKC.String(cases.Fold().String(Normalize(name)))
+}
+
+// Validate checks a normalized name against the length, character and
+// reserved-word rules.
+func Validate(name string) error {
+	length := utf8.RuneCountInString(name)
+	if length < MinLength {
+		return ErrTooShort
+	}
+	if length > MaxLength {
+		return ErrTooLong
+	}
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.Is(unicode.Mn, r) && i > 0:
+		case (r == '.' || r == '_' || r == '-') && i > 0:
+		default:
+			return ErrInvalidChars
+		}
+	}
+
+	key := Key(name)
+	if reserved[key] || strings.HasPrefix(key, DeletedPrefix) {
+		return ErrReservedName
+	}
+	return nil
+}
+
+// Suggestions returns variations of a taken name that pass Validate, in the
+// order they should be offered.
+func Suggestions(name string) []string {
+	base := Normalize(name)
+	var suggestions []string
+	for _, suffix := range suggestionSuffixes {
+		candidate := base
+		if runes := []rune(candidate); len(runes)+len(suffix) > MaxLength {
+			candidate = string(runes[:MaxLength-len(suffix)])
+		}
+		candidate += suffix
+		if Validate(candidate) == nil {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}